@@ -7,25 +7,36 @@ import (
 	"backupgo/task"
 	"context"
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/urfave/cli/v3"
 )
 
 func BackupCommand() *cli.Command {
 	return &cli.Command{
-		Name:  "backup",
-		Usage: "Run a specific backup task manually",
+		Name:      "backup",
+		Usage:     "Run a specific backup task manually",
+		ArgsUsage: "<backup-id>",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Export and compress without uploading, and print the objects retention would delete",
+			},
+		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			args := cmd.Args()
 			if args.Len() == 0 {
 				return fmt.Errorf("missing required argument: backup-id\nSee 'backupgo backup --help' for more information")
 			}
-			return runBackup(args.First())
+			return runBackup(ctx, os.Stdout, args.First(), cmd.Bool("dry-run"))
 		},
 	}
 }
 
-func runBackup(backupID string) error {
+func runBackup(ctx context.Context, output io.Writer, backupID string, dryRun bool) error {
 	config.InitConfig()
 
 	conf, ok := config.Config.FindBackupByID(backupID)
@@ -33,14 +44,69 @@ func runBackup(backupID string) error {
 		return fmt.Errorf("backup task not found: %s", backupID)
 	}
 
-	ossClient := oss.CreateOSSClient(config.Config.OSS)
+	destinations, err := task.NewDestinations(conf, oss.NewRegistry(config.Config))
+	if err != nil {
+		return err
+	}
 	noticeManager := notice.NewManagerFromConfig(config.Config)
 
-	holder := task.NewTaskHolder(conf, ossClient, noticeManager)
+	holder := task.NewTaskHolder(conf, destinations, noticeManager, config.Config.GetHistoryKeep())
+
+	// Ctrl-C 取消正在运行的任务，让它清理临时文件并中止未完成的上传
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if dryRun {
+		fmt.Fprintf(output, "Dry run of backup task: %s\n", backupID)
+		result, err := holder.DryRun(ctx)
+		if err != nil {
+			return fmt.Errorf("dry run failed: %w", err)
+		}
+		printDryRun(output, result)
+		return nil
+	}
 
-	fmt.Printf("Running backup task: %s\n", backupID)
-	holder.BackupTask()
-	fmt.Printf("Backup task completed: %s\n", backupID)
+	fmt.Fprintf(output, "Running backup task: %s\n", backupID)
+	holder.BackupTask(ctx)
+	fmt.Fprintf(output, "Backup task completed: %s\n", backupID)
 
 	return nil
 }
+
+func printDryRun(output io.Writer, result task.DryRunResult) {
+	if result.BeforeCmd != "" {
+		fmt.Fprintf(output, "Would run before_command (skipped): %s\n", result.BeforeCmd)
+	}
+	if result.AfterCmd != "" {
+		fmt.Fprintf(output, "Would run after_command (skipped): %s\n", result.AfterCmd)
+	}
+	if result.Key != "" {
+		fmt.Fprintf(output, "Would upload %s (%s)\n", result.Key, notice.FormatBytes(result.Size))
+	} else {
+		fmt.Fprintf(output, "Would scan %d files (%s) for the incremental snapshot\n", result.Files, notice.FormatBytes(result.Size))
+	}
+	if result.Excluded.Files > 0 {
+		fmt.Fprintf(output, "Excluded %d files (%s)\n", result.Excluded.Files, notice.FormatBytes(result.Excluded.Bytes))
+	}
+
+	for _, dest := range result.Destinations {
+		if len(dest.Expired) == 0 {
+			fmt.Fprintf(output, "Retention would delete nothing from %s (%s)\n", dest.Name, dest.Bucket)
+			continue
+		}
+		fmt.Fprintf(output, "Retention would delete %d objects from %s (%s):\n", len(dest.Expired), dest.Name, dest.Bucket)
+		for _, key := range dest.Expired {
+			fmt.Fprintf(output, "  %s\n", key)
+		}
+	}
+
+	for _, dest := range result.Destinations {
+		if len(dest.Pruned) == 0 {
+			continue
+		}
+		fmt.Fprintf(output, "Snapshot prune would delete up to %d objects from %s (%s):\n", len(dest.Pruned), dest.Name, dest.Bucket)
+		for _, key := range dest.Pruned {
+			fmt.Fprintf(output, "  %s\n", key)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package check
+
+import (
+	"backupgo/config"
+	"backupgo/exporter"
+	"backupgo/notice"
+	"backupgo/oss"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v3"
+)
+
+// checkTimeout 限制单个任务或存储的检查时间，避免连接不上的数据源一直卡住
+const checkTimeout = 30 * time.Second
+
+// checkRow 是结果矩阵中的一行，Err 为 nil 表示通过
+type checkRow struct {
+	Scope string
+	Name  string
+	Err   error
+}
+
+func CheckCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "check",
+		Usage:     "Check config, external tools, data source connections, storages and notifiers",
+		ArgsUsage: "[backup-id]",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "skip-notice",
+				Usage: "Do not send test messages to notice channels",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return runCheck(ctx, os.Stdout, cmd.Args().First(), cmd.Bool("skip-notice"))
+		},
+	}
+}
+
+func runCheck(ctx context.Context, output io.Writer, backupID string, skipNotice bool) error {
+	conf, err := config.LoadConfig()
+	rows := []checkRow{{Scope: "config", Name: "load and validate", Err: err}}
+	if err == nil {
+		rows, err = appendChecks(ctx, rows, conf, backupID, skipNotice)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := printMatrix(output, rows); err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, row := range rows {
+		if row.Err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(rows))
+	}
+	return nil
+}
+
+// appendChecks 检查指定任务（backupID 为空时检查全部任务）的备份源、用到的存储和通知渠道。
+func appendChecks(ctx context.Context, rows []checkRow, conf config.GlobalConfig, backupID string, skipNotice bool) ([]checkRow, error) {
+	tasks := conf.BackupConf
+	var channels []string
+	if backupID != "" {
+		task, ok := conf.FindBackupByID(backupID)
+		if !ok {
+			return nil, fmt.Errorf("backup task not found: %s", backupID)
+		}
+		tasks = []config.BackupConfig{task}
+		if task.Notice != nil {
+			channels = task.Notice.Channels
+		}
+	}
+
+	var storageNames []string
+	for _, task := range tasks {
+		rows = append(rows, checkSource(ctx, task)...)
+		for _, name := range task.GetStorageNames() {
+			if !slices.Contains(storageNames, name) {
+				storageNames = append(storageNames, name)
+			}
+		}
+	}
+
+	storages := oss.NewRegistry(conf)
+	for _, name := range storageNames {
+		rows = append(rows, checkStorage(ctx, storages, name))
+	}
+
+	if !skipNotice {
+		for _, result := range notice.NewManagerFromConfig(conf).SendTest(channels) {
+			rows = append(rows, checkRow{Scope: "notice " + result.Name, Name: "send test message", Err: result.Err})
+		}
+	}
+	return rows, nil
+}
+
+func checkSource(ctx context.Context, task config.BackupConfig) []checkRow {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	scope := "task " + task.GetID()
+	logger := slog.Default().With("component", "check", "task_id", task.GetID())
+	results, err := exporter.Check(ctx, task.GetID(), task, logger)
+	if err != nil {
+		return []checkRow{{Scope: scope, Name: "create source", Err: err}}
+	}
+
+	rows := make([]checkRow, 0, len(results))
+	for _, result := range results {
+		rows = append(rows, checkRow{Scope: scope, Name: result.Name, Err: result.Err})
+	}
+	return rows
+}
+
+func checkStorage(ctx context.Context, storages *oss.Registry, name string) checkRow {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	row := checkRow{Scope: "storage " + name, Name: "list, write and delete"}
+	storage, err := storages.Get(name)
+	if err != nil {
+		row.Err = err
+		return row
+	}
+	row.Err = oss.Probe(ctx, storage)
+	return row
+}
+
+func printMatrix(output io.Writer, rows []checkRow) error {
+	writer := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "SCOPE\tCHECK\tRESULT\tDETAIL")
+	for _, row := range rows {
+		result, detail := "PASS", "-"
+		if row.Err != nil {
+			// 命令的错误输出可能有多行，矩阵中只保留一行
+			result, detail = "FAIL", strings.Join(strings.Fields(row.Err.Error()), " ")
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", row.Scope, row.Name, result, detail)
+	}
+	return writer.Flush()
+}
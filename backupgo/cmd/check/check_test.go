@@ -0,0 +1,63 @@
+package check
+
+import (
+	"backupgo/config"
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAppendChecksCoversTaskAndStorage(t *testing.T) {
+	conf := config.GlobalConfig{
+		Storages: []config.StorageConfig{
+			{Name: "nas", Type: config.StorageTypeLocal, Local: &config.LocalStorageConfig{Path: t.TempDir()}},
+		},
+		BackupConf: []config.BackupConfig{
+			{ID: "app", BackupPath: t.TempDir(), Storage: "nas"},
+			{ID: "docs", BackupPath: filepath.Join(t.TempDir(), "missing"), Storage: "nas"},
+		},
+	}
+
+	rows, err := appendChecks(context.Background(), nil, conf, "", true)
+	if err != nil {
+		t.Fatalf("appendChecks returned error: %v", err)
+	}
+
+	if len(rows) != 3 {
+		t.Fatalf("unexpected rows: %+v", rows)
+	}
+	if rows[0].Scope != "task app" || rows[0].Err != nil {
+		t.Fatalf("unexpected app row: %+v", rows[0])
+	}
+	if rows[1].Scope != "task docs" || rows[1].Err == nil {
+		t.Fatalf("expected missing path to fail: %+v", rows[1])
+	}
+	if rows[2].Scope != "storage nas" || rows[2].Err != nil {
+		t.Fatalf("unexpected storage row: %+v", rows[2])
+	}
+
+	if _, err := appendChecks(context.Background(), nil, conf, "missing", true); err == nil {
+		t.Fatal("expected error for unknown backup id")
+	}
+}
+
+func TestPrintMatrix(t *testing.T) {
+	var output bytes.Buffer
+	err := printMatrix(&output, []checkRow{
+		{Scope: "config", Name: "load and validate"},
+		{Scope: "task pg", Name: "executable pg_dump", Err: errors.New("exec: \"pg_dump\":\nexecutable file not found")},
+	})
+	if err != nil {
+		t.Fatalf("printMatrix returned error: %v", err)
+	}
+
+	got := output.String()
+	for _, want := range []string{"SCOPE", "PASS", "FAIL", `exec: "pg_dump": executable file not found`} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("printMatrix output missing %q:\n%s", want, got)
+		}
+	}
+}
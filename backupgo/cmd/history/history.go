@@ -0,0 +1,113 @@
+package history
+
+import (
+	"backupgo/history"
+	"backupgo/notice"
+	"backupgo/pkg/consts"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v3"
+)
+
+const defaultHistoryLimit = 20
+
+func HistoryCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "history",
+		Usage:     "Show the run history of all backup tasks or a specific one",
+		ArgsUsage: "[backup-id]",
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:    "limit",
+				Aliases: []string{"n"},
+				Value:   defaultHistoryLimit,
+				Usage:   "Number of most recent runs to print, 0 prints all",
+			},
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "Print records as JSON",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return runHistory(os.Stdout, cmd.Args().First(), cmd.Int("limit"), cmd.Bool("json"))
+		},
+	}
+}
+
+func runHistory(output io.Writer, taskID string, limit int, asJSON bool) error {
+	if limit < 0 {
+		return fmt.Errorf("limit must be >= 0")
+	}
+
+	path, err := consts.HistoryFilePath()
+	if err != nil {
+		return err
+	}
+
+	records, err := history.Load(path, taskID)
+	if err != nil {
+		return err
+	}
+	records = latest(records, limit)
+
+	if asJSON {
+		encoder := json.NewEncoder(output)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(records)
+	}
+
+	if len(records) == 0 {
+		_, err := fmt.Fprintln(output, "No run history found")
+		return err
+	}
+	return printTable(output, records)
+}
+
+// latest 返回最近的 limit 条记录，按时间从新到旧排列。
+func latest(records []history.Record, limit int) []history.Record {
+	if limit > 0 && len(records) > limit {
+		records = records[len(records)-limit:]
+	}
+
+	result := make([]history.Record, 0, len(records))
+	for i := len(records) - 1; i >= 0; i-- {
+		result = append(result, records[i])
+	}
+	return result
+}
+
+func printTable(output io.Writer, records []history.Record) error {
+	writer := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "STARTED\tTASK\tSTATUS\tDURATION\tSIZE\tMODE\tKEY\tERROR")
+	for _, record := range records {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			record.StartedAt.Local().Format(time.DateTime),
+			record.TaskID,
+			record.Status,
+			formatDuration(record.Duration),
+			notice.FormatBytes(record.Size),
+			valueOrDash(record.Mode),
+			valueOrDash(record.Key),
+			valueOrDash(strings.Join(record.Errors, "; ")),
+		)
+	}
+	return writer.Flush()
+}
+
+func formatDuration(seconds float64) string {
+	return (time.Duration(seconds * float64(time.Second))).Round(time.Second).String()
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,50 @@
+package history
+
+import (
+	"backupgo/history"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLatestReturnsNewestFirst(t *testing.T) {
+	t.Parallel()
+
+	records := []history.Record{{Key: "a"}, {Key: "b"}, {Key: "c"}}
+
+	got := latest(records, 2)
+	if len(got) != 2 || got[0].Key != "c" || got[1].Key != "b" {
+		t.Fatalf("latest() = %+v, want c, b", got)
+	}
+	if all := latest(records, 0); len(all) != 3 || all[2].Key != "a" {
+		t.Fatalf("latest() with limit 0 = %+v, want all records", all)
+	}
+}
+
+func TestPrintTable(t *testing.T) {
+	t.Parallel()
+
+	records := []history.Record{
+		{
+			TaskID:    "app",
+			StartedAt: time.Date(2024, 3, 8, 0, 25, 0, 0, time.Local),
+			Duration:  65.4,
+			Status:    "failed",
+			Size:      2048,
+			Errors:    []string{"上传失败", "network error"},
+		},
+	}
+
+	var output bytes.Buffer
+	if err := printTable(&output, records); err != nil {
+		t.Fatalf("printTable() error = %v", err)
+	}
+
+	got := output.String()
+	for _, want := range []string{"STARTED", "2024-03-08 00:25:00", "app", "failed", "1m5s", "2.0 KB", "上传失败; network error"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("printTable() output missing %q:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package restore
+
+import (
+	"backupgo/config"
+	"backupgo/encrypt"
+	"backupgo/exporter"
+	"backupgo/manifest"
+	"backupgo/notice"
+	"backupgo/oss"
+	"backupgo/snapshot"
+	"backupgo/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v3"
+)
+
+const defaultRestoreDir = "./restore"
+
+type restoreOptions struct {
+	list       bool
+	key        string
+	targetDir  string
+	load       bool
+	nameSuffix string
+	// storage 是下载备份的存储，默认使用任务的主存储
+	storage string
+
+	// manifest 为 true 时只输出备份的清单，不下载备份
+	manifest bool
+}
+
+type backupObject struct {
+	Key  string
+	Size int64
+	Time time.Time
+	// Modified 用于区分同一天的多个备份，例如当天修改了 archive.format
+	Modified time.Time
+}
+
+func RestoreCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "restore",
+		Usage:     "Download and unpack a backup, optionally loading it back into the data source",
+		ArgsUsage: "<backup-id>",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:    "list",
+				Aliases: []string{"l"},
+				Usage:   "List available backups without restoring",
+			},
+			&cli.StringFlag{
+				Name:    "key",
+				Aliases: []string{"k"},
+				Usage:   "Object key of the backup to restore (default: latest)",
+			},
+			&cli.StringFlag{
+				Name:    "target",
+				Aliases: []string{"t"},
+				Value:   defaultRestoreDir,
+				Usage:   "Directory to unpack the backup into",
+			},
+			&cli.BoolFlag{
+				Name:  "load",
+				Usage: "Load the data back with pg_restore, mongorestore, mysql, sqlite3 or a helper container",
+			},
+			&cli.StringFlag{
+				Name:  "name-suffix",
+				Usage: "Suffix appended to restored database / volume names when loading",
+			},
+			&cli.StringFlag{
+				Name:  "storage",
+				Usage: "Storage to restore from when the task has several destinations (default: the first one)",
+			},
+			&cli.BoolFlag{
+				Name:  "manifest",
+				Usage: "Print the manifest of the backup without downloading it",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			args := cmd.Args()
+			if args.Len() == 0 {
+				return fmt.Errorf("missing required argument: backup-id\nSee 'backupgo restore --help' for more information")
+			}
+			return runRestore(ctx, os.Stdout, args.First(), restoreOptions{
+				list:       cmd.Bool("list"),
+				key:        strings.TrimSpace(cmd.String("key")),
+				targetDir:  cmd.String("target"),
+				load:       cmd.Bool("load"),
+				nameSuffix: cmd.String("name-suffix"),
+				storage:    strings.TrimSpace(cmd.String("storage")),
+				manifest:   cmd.Bool("manifest"),
+			})
+		},
+	}
+}
+
+func runRestore(ctx context.Context, output io.Writer, backupID string, opts restoreOptions) error {
+	config.InitConfig()
+
+	conf, ok := config.Config.FindBackupByID(backupID)
+	if !ok {
+		return fmt.Errorf("backup task not found: %s", backupID)
+	}
+
+	storageName := opts.storage
+	if storageName == "" {
+		storageName = conf.GetStorage()
+	} else if !slices.Contains(conf.GetStorageNames(), storageName) {
+		return fmt.Errorf("backup %s does not upload to storage %s", conf.GetID(), storageName)
+	}
+
+	storage, err := oss.NewRegistry(config.Config).Get(storageName)
+	if err != nil {
+		return err
+	}
+
+	names, err := conf.FileNameProcessor()
+	if err != nil {
+		return err
+	}
+
+	backups, err := listBackups(ctx, storage, names)
+	if err != nil {
+		return fmt.Errorf("list backups failed: %w", err)
+	}
+
+	if opts.list {
+		printBackups(output, backups)
+		return nil
+	}
+
+	key := opts.key
+	if key == "" {
+		if len(backups) == 0 {
+			return fmt.Errorf("no backups found for task %s in %s", conf.GetID(), storage.BucketName())
+		}
+		key = backups[0].Key
+	}
+
+	if opts.manifest {
+		return printManifest(ctx, output, storage, key)
+	}
+
+	if opts.load && conf.GetType() == config.BackupTypePath {
+		return fmt.Errorf("backup type %s can only be unpacked, --load is not supported", conf.GetType())
+	}
+
+	tempDir, err := os.MkdirTemp("", "backupgo-restore-")
+	if err != nil {
+		return fmt.Errorf("create temp dir failed: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	archiveFile := filepath.Join(tempDir, filepath.Base(key))
+	fmt.Fprintf(output, "Downloading %s from %s\n", key, storage.BucketName())
+	if err := storage.Download(ctx, key, archiveFile); err != nil {
+		return fmt.Errorf("download %s failed: %w", key, err)
+	}
+	if err := verifyChecksum(ctx, output, storage, key, archiveFile); err != nil {
+		return err
+	}
+
+	if encrypt.IsEncrypted(archiveFile) {
+		if conf.Encryption == nil {
+			return fmt.Errorf("backup %s is encrypted but task %s has no encryption config", key, conf.GetID())
+		}
+
+		decryptedFile := encrypt.TrimExtension(archiveFile)
+		fmt.Fprintf(output, "Decrypting %s\n", key)
+		if err := encrypt.DecryptFile(*conf.Encryption, archiveFile, decryptedFile); err != nil {
+			return fmt.Errorf("decrypt %s failed: %w", key, err)
+		}
+		archiveFile = decryptedFile
+	}
+
+	targetDir, err := filepath.Abs(opts.targetDir)
+	if err != nil {
+		return fmt.Errorf("resolve target dir failed: %w", err)
+	}
+
+	logger := slog.Default().With("component", "restore", "task_id", conf.GetID())
+	if snapshot.IsManifestKey(key) {
+		snapshotManifest, err := snapshot.ReadManifest(archiveFile)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(output, "Restoring snapshot (%d files) to %s\n", len(snapshotManifest.Files), targetDir)
+		if err := snapshot.Restore(ctx, storage, snapshotManifest, targetDir, conf.Encryption, logger); err != nil {
+			return fmt.Errorf("restore snapshot failed: %w", err)
+		}
+	} else {
+		fmt.Fprintf(output, "Unpacking to %s\n", targetDir)
+		if err := utils.ExtractArchive(archiveFile, targetDir); err != nil {
+			return err
+		}
+	}
+
+	if opts.load {
+		fmt.Fprintf(output, "Loading %s data\n", conf.GetType())
+		if err := exporter.Restore(ctx, conf.GetID(), conf, targetDir, exporter.RestoreOptions{NameSuffix: opts.nameSuffix}, logger); err != nil {
+			return fmt.Errorf("load backup data failed: %w", err)
+		}
+	}
+
+	fmt.Fprintf(output, "Restore completed: %s\n", key)
+	return nil
+}
+
+// printManifest 输出备份 key 的清单。
+func printManifest(ctx context.Context, output io.Writer, storage oss.Storage, key string) error {
+	m, err := manifest.Read(ctx, storage, key)
+	if errors.Is(err, manifest.ErrNotFound) {
+		return fmt.Errorf("backup %s has no manifest", key)
+	}
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode manifest failed: %w", err)
+	}
+	fmt.Fprintln(output, string(data))
+	return nil
+}
+
+// verifyChecksum 用清单中的摘要校验下载的备份，没有清单的旧备份跳过校验。
+func verifyChecksum(ctx context.Context, output io.Writer, storage oss.Storage, key string, archiveFile string) error {
+	m, err := manifest.Read(ctx, storage, key)
+	if errors.Is(err, manifest.ErrNotFound) {
+		fmt.Fprintf(output, "No manifest found for %s, skipping checksum verification\n", key)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	size, sum, err := manifest.HashFile(archiveFile)
+	if err != nil {
+		return err
+	}
+	if size != m.Size || sum != m.SHA256 {
+		return fmt.Errorf("checksum mismatch for %s: expected %s (%d bytes), got %s (%d bytes)", key, m.SHA256, m.Size, sum, size)
+	}
+	fmt.Fprintf(output, "Checksum verified: %s\n", sum)
+	return nil
+}
+
+// listBackups 按任务的 key 模板列出本机上传的全部备份文件，按备份时间从新到旧排序。
+func listBackups(ctx context.Context, storage oss.Storage, names *utils.FileNameProcessor) ([]backupObject, error) {
+	objects, err := storage.ListObjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []backupObject
+	for _, obj := range objects {
+		// 扩展名可能包含多段，清单 key 也能按模板解析，需要先排除
+		if manifest.IsKey(obj.Key) {
+			continue
+		}
+		result, err := names.Parse(obj.Key)
+		if err != nil {
+			continue
+		}
+
+		backups = append(backups, backupObject{
+			Key:      obj.Key,
+			Size:     obj.Size,
+			Time:     result.ToTime(),
+			Modified: obj.LastModified,
+		})
+	}
+
+	sort.SliceStable(backups, func(i, j int) bool {
+		if !backups[i].Time.Equal(backups[j].Time) {
+			return backups[i].Time.After(backups[j].Time)
+		}
+		if !backups[i].Modified.Equal(backups[j].Modified) {
+			return backups[i].Modified.After(backups[j].Modified)
+		}
+		return backups[i].Key > backups[j].Key
+	})
+
+	return backups, nil
+}
+
+func printBackups(output io.Writer, backups []backupObject) {
+	if len(backups) == 0 {
+		fmt.Fprintln(output, "No backups found")
+		return
+	}
+
+	// 按 key 模板生成的 key 可能带目录，长度不固定，用 tabwriter 对齐
+	writer := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "KEY\tDATE\tSIZE")
+	for _, backup := range backups {
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", backup.Key, backup.Time.Format(time.DateOnly), notice.FormatBytes(backup.Size))
+	}
+	_ = writer.Flush()
+}
@@ -0,0 +1,157 @@
+package restore
+
+import (
+	"backupgo/config"
+	"backupgo/manifest"
+	"backupgo/oss"
+	"backupgo/utils"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type stubStorage struct {
+	objects []oss.ObjectInfo
+}
+
+func (s *stubStorage) BucketName() string { return "stub" }
+
+func (s *stubStorage) Upload(ctx context.Context, objKey, filePath string) (oss.UploadResult, error) {
+	return oss.UploadResult{Bucket: "stub", Key: objKey, Mode: oss.NORMAL}, nil
+}
+
+func (s *stubStorage) UploadStream(ctx context.Context, objKey string, r io.Reader, opts oss.StreamOptions) (oss.UploadResult, error) {
+	return oss.UploadResult{Bucket: "stub", Key: objKey, Mode: oss.NORMAL}, nil
+}
+
+func (s *stubStorage) Download(ctx context.Context, objKey, filePath string) error { return nil }
+
+func (s *stubStorage) ListObjects(ctx context.Context) ([]oss.ObjectInfo, error) {
+	return s.objects, nil
+}
+
+func (s *stubStorage) DeleteObjects(ctx context.Context, keys []string) ([]string, error) {
+	return keys, nil
+}
+
+func testNames(t *testing.T, template string) *utils.FileNameProcessor {
+	t.Helper()
+	names, err := utils.NewFileNameProcessor(template, "app", "web-1")
+	if err != nil {
+		t.Fatalf("compile key template: %v", err)
+	}
+	return names
+}
+
+func TestListBackupsFiltersAndSortsByDate(t *testing.T) {
+	storage := &stubStorage{objects: []oss.ObjectInfo{
+		{Key: "app_2024_03_08.zip", Size: 10},
+		{Key: "other_2024_03_10.zip", Size: 20},
+		{Key: "app_2024_03_10.zip", Size: 30},
+		{Key: "app_2024_03_10.zip.manifest.json", Size: 2},
+		{Key: "readme.txt", Size: 1},
+		{Key: "app_2024_03_09.zip", Size: 40},
+		{Key: "app_2024_03_10.tar.zst", Size: 50, LastModified: time.Date(2024, 3, 10, 2, 0, 0, 0, time.UTC)},
+	}}
+
+	backups, err := listBackups(context.Background(), storage, testNames(t, utils.DefaultKeyTemplate))
+	if err != nil {
+		t.Fatalf("listBackups returned error: %v", err)
+	}
+
+	var keys []string
+	for _, backup := range backups {
+		keys = append(keys, backup.Key)
+	}
+	want := []string{"app_2024_03_10.tar.zst", "app_2024_03_10.zip", "app_2024_03_09.zip", "app_2024_03_08.zip"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Fatalf("unexpected backups: %#v", keys)
+	}
+}
+
+func TestPrintBackups(t *testing.T) {
+	var output bytes.Buffer
+	printBackups(&output, []backupObject{})
+	if !strings.Contains(output.String(), "No backups found") {
+		t.Fatalf("unexpected output: %s", output.String())
+	}
+
+	storage := &stubStorage{objects: []oss.ObjectInfo{{Key: "app_2024_03_08.zip", Size: 2048}}}
+	backups, _ := listBackups(context.Background(), storage, testNames(t, utils.DefaultKeyTemplate))
+
+	output.Reset()
+	printBackups(&output, backups)
+	for _, want := range []string{"app_2024_03_08.zip", "2024-03-08", "2.0 KB"} {
+		if !strings.Contains(output.String(), want) {
+			t.Fatalf("output missing %q: %s", want, output.String())
+		}
+	}
+}
+
+func TestListBackupsWithKeyTemplate(t *testing.T) {
+	storage := &stubStorage{objects: []oss.ObjectInfo{
+		{Key: "web-1/app/app_2024_03_10_020000.zip"},
+		{Key: "web-1/app/app_2024_03_10_140000.zip"},
+		{Key: "web-2/app/app_2024_03_11_020000.zip"},
+		{Key: "app_2024_03_12.zip"},
+	}}
+
+	backups, err := listBackups(context.Background(), storage, testNames(t, "{host}/{id}/{id}_{timestamp}.{ext}"))
+	if err != nil {
+		t.Fatalf("listBackups returned error: %v", err)
+	}
+
+	var keys []string
+	for _, backup := range backups {
+		keys = append(keys, backup.Key)
+	}
+	want := []string{"web-1/app/app_2024_03_10_140000.zip", "web-1/app/app_2024_03_10_020000.zip"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Fatalf("unexpected backups: %#v", keys)
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	storage, err := oss.NewLocalStorage(config.LocalStorageConfig{Path: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewLocalStorage returned error: %v", err)
+	}
+	archiveFile := filepath.Join(t.TempDir(), "app_2024_03_10.zip")
+	if err := os.WriteFile(archiveFile, []byte("backupgo"), 0644); err != nil {
+		t.Fatalf("write archive: %v", err)
+	}
+
+	var output bytes.Buffer
+	if err := verifyChecksum(context.Background(), &output, storage, "app_2024_03_10.zip", archiveFile); err != nil {
+		t.Fatalf("verifyChecksum without manifest returned error: %v", err)
+	}
+	if !strings.Contains(output.String(), "skipping checksum verification") {
+		t.Fatalf("unexpected output: %s", output.String())
+	}
+
+	size, sum, err := manifest.HashFile(archiveFile)
+	if err != nil {
+		t.Fatalf("HashFile returned error: %v", err)
+	}
+	m := manifest.Manifest{Version: 1, Key: "app_2024_03_10.zip", Size: size, SHA256: sum}
+	if err := manifest.Upload(context.Background(), storage, m); err != nil {
+		t.Fatalf("Upload returned error: %v", err)
+	}
+	if err := verifyChecksum(context.Background(), &output, storage, "app_2024_03_10.zip", archiveFile); err != nil {
+		t.Fatalf("verifyChecksum returned error: %v", err)
+	}
+
+	if err := os.WriteFile(archiveFile, []byte("corrupted"), 0644); err != nil {
+		t.Fatalf("write archive: %v", err)
+	}
+	err = verifyChecksum(context.Background(), &output, storage, "app_2024_03_10.zip", archiveFile)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected checksum mismatch, got %v", err)
+	}
+}
@@ -7,20 +7,29 @@ import (
 	"github.com/urfave/cli/v3"
 
 	"backupgo/cmd/backup"
+	"backupgo/cmd/check"
+	"backupgo/cmd/history"
+	"backupgo/cmd/restore"
 	"backupgo/cmd/scheduler"
 	"backupgo/cmd/status"
+	"backupgo/pkg/consts"
 )
 
 func Run(args []string) error {
 	rootCmd := &cli.Command{
-		Name:  "backupgo",
-		Usage: "Backup management tool",
+		Name:    "backupgo",
+		Usage:   "Backup management tool",
+		Version: consts.Version,
 		Commands: []*cli.Command{
 			scheduler.StartCommand(),
 			scheduler.StopCommand(),
+			scheduler.ReloadCommand(),
 			status.StatusCommand(),
 			logs.LogsCommand(),
+			history.HistoryCommand(),
 			backup.BackupCommand(),
+			restore.RestoreCommand(),
+			check.CheckCommand(),
 		},
 	}
 
@@ -0,0 +1,51 @@
+package scheduler
+
+import (
+	"backupgo/config"
+	"backupgo/pkg/procutil"
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v3"
+)
+
+func ReloadCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "reload",
+		Usage: "Reload config.yml in the running scheduler",
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return runReload()
+		},
+	}
+}
+
+func runReload() error {
+	// 先在本地校验一次，配置有误时直接报错，不必去调度器日志里找原因
+	if _, err := config.LoadConfig(); err != nil {
+		return fmt.Errorf("config is invalid, scheduler not reloaded: %w", err)
+	}
+
+	pid, err := readPID()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("backupgo is not running")
+		}
+		return err
+	}
+
+	running, err := procutil.IsRunning(pid)
+	if err != nil {
+		return fmt.Errorf("check process %d failed: %w", pid, err)
+	}
+	if !running {
+		return fmt.Errorf("backupgo is not running (stale PID file: %d)", pid)
+	}
+
+	if err := procutil.Reload(pid); err != nil {
+		return fmt.Errorf("reload process %d failed: %w", pid, err)
+	}
+
+	fmt.Printf("reload signal sent to backupgo (PID %d)\n", pid)
+	return nil
+}
@@ -2,11 +2,9 @@ package scheduler
 
 import (
 	"backupgo/config"
-	"backupgo/notice"
-	"backupgo/oss"
+	"backupgo/monitor"
 	"backupgo/pkg/consts"
 	"backupgo/pkg/procutil"
-	"backupgo/task"
 	"context"
 	"fmt"
 	"log"
@@ -19,12 +17,9 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/robfig/cron/v3"
 	"github.com/urfave/cli/v3"
 )
 
-var cronScheduler *cron.Cron
-
 func StartCommand() *cli.Command {
 	return &cli.Command{
 		Name:  "start",
@@ -58,48 +53,82 @@ func runForeground() error {
 
 	config.InitConfig()
 
-	ossClient := oss.CreateOSSClient(config.Config.OSS)
-	noticeManager := notice.NewManagerFromConfig(config.Config)
-
-	cronScheduler = cron.New(cron.WithParser(cron.NewParser(
-		cron.Second|cron.Minute|cron.Hour|cron.Dom|cron.Month|cron.DowOptional|cron.Descriptor,
-	)), cron.WithChain(cron.SkipIfStillRunning(cron.DefaultLogger)))
-
-	for _, conf := range config.Config.BackupConf {
-		backupTaskCron := conf.BackupTask
-		if backupTaskCron == "" {
-			backupTaskCron = "0 25 0 * * ?"
-		}
-		_, err := cronScheduler.AddFunc(backupTaskCron, func() {
-			holder := task.NewTaskHolder(conf, ossClient, noticeManager)
-			holder.BackupTask()
-		})
-		if err != nil {
-			return err
-		}
-
-		log.Printf("task %s added to scheduler", conf.GetID())
+	tasks := newTaskScheduler()
+	if err := tasks.apply(config.Config); err != nil {
+		return err
 	}
 
-	cronScheduler.Start()
+	tasks.cron.Start()
 	if err := writePID(); err != nil {
 		return err
 	}
 	defer removePID()
 
+	var server *monitor.Server
+	if config.Config.HTTP != nil {
+		server = monitor.NewServer(config.Config.HTTP.Listen, tasks.cron, tasks.monitorTasks())
+		if err := server.Start(); err != nil {
+			tasks.cron.Stop()
+			return err
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = server.Shutdown(ctx)
+		}()
+	}
+
 	log.Println("backupgo scheduler started")
 
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGTERM, syscall.SIGINT)
+	signal.Notify(sigChan, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP)
 	defer signal.Stop(sigChan)
-	<-sigChan
+	for sig := range sigChan {
+		if sig == syscall.SIGHUP {
+			reloadConfig(tasks, server)
+			continue
+		}
+		break
+	}
 
 	log.Println("shutting down...")
-	cronScheduler.Stop()
+	tasks.shutdown(tasks.config().GetShutdownTimeout(), sigChan)
 
 	return nil
 }
 
+// reloadConfig 重新读取配置并更新调度任务，新配置无效时继续使用当前配置。
+func reloadConfig(tasks *taskScheduler, server *monitor.Server) {
+	log.Println("reloading config...")
+
+	conf, err := config.LoadConfig()
+	if err != nil {
+		log.Printf("reload config failed, keep running config: %v", err)
+		return
+	}
+	previous := tasks.config()
+	if err := tasks.apply(conf); err != nil {
+		log.Printf("reload config failed, keep running config: %v", err)
+		return
+	}
+
+	if httpListen(conf) != httpListen(previous) {
+		log.Println("http.listen changed, restart the scheduler to apply it")
+	}
+	if server != nil {
+		server.SetTasks(tasks.monitorTasks())
+	}
+
+	log.Println("config reloaded")
+}
+
+func httpListen(conf config.GlobalConfig) string {
+	if conf.HTTP == nil {
+		return ""
+	}
+	return conf.HTTP.Listen
+}
+
 func runDetached() error {
 	if err := ensureNotRunning(); err != nil {
 		return err
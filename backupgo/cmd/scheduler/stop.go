@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"backupgo/config"
 	"backupgo/pkg/procutil"
 	"context"
 	"fmt"
@@ -46,7 +47,9 @@ func runStop() error {
 		return fmt.Errorf("stop process %d failed: %w", pid, err)
 	}
 
-	deadline := time.Now().Add(10 * time.Second)
+	// 调度器会等待正在运行的任务结束，停止命令需要等得更久
+	timeout := stopTimeout()
+	deadline := time.Now().Add(timeout)
 	for time.Now().Before(deadline) {
 		running, err = procutil.IsRunning(pid)
 		if err != nil {
@@ -62,5 +65,13 @@ func runStop() error {
 		time.Sleep(200 * time.Millisecond)
 	}
 
-	return fmt.Errorf("process %d did not stop within 10 seconds", pid)
+	return fmt.Errorf("process %d did not stop within %s", pid, timeout)
+}
+
+func stopTimeout() time.Duration {
+	shutdownTimeout := config.DefaultShutdownTimeout
+	if conf, err := config.LoadConfig(); err == nil {
+		shutdownTimeout = conf.GetShutdownTimeout()
+	}
+	return shutdownTimeout + shutdownGracePeriod + 10*time.Second
 }
@@ -0,0 +1,412 @@
+package scheduler
+
+import (
+	"backupgo/config"
+	"backupgo/monitor"
+	"backupgo/notice"
+	"backupgo/oss"
+	"backupgo/state"
+	"backupgo/task"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+const defaultBackupTaskCron = "0 25 0 * * ?"
+
+// shutdownGracePeriod 是任务被取消后留给它清理临时文件、中止分片上传的时间
+const shutdownGracePeriod = time.Minute
+
+var errShuttingDown = errors.New("scheduler is shutting down")
+
+var cronParser = cron.NewParser(
+	cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.DowOptional | cron.Descriptor,
+)
+
+// scheduledTask 是已注册到 cron 的任务
+type scheduledTask struct {
+	schedule string
+	entryID  cron.EntryID
+}
+
+// taskScheduler 管理 cron 中的备份任务。任务运行时才读取当前配置，
+// 重新加载后修改的配置从下一次运行开始生效，正在运行的任务不受影响。
+type taskScheduler struct {
+	cron *cron.Cron
+	// ctx 传给每次运行的任务，停止调度器超时后取消
+	ctx    context.Context
+	cancel context.CancelFunc
+	// waitCtx 用于排队等待依赖任务、空闲名额和锁，停止调度器时立即取消
+	waitCtx     context.Context
+	stopWaiting context.CancelFunc
+
+	mu            sync.Mutex
+	conf          config.GlobalConfig
+	storages      *oss.Registry
+	noticeManager *notice.NoticeManager
+	entries       map[string]scheduledTask
+	// running 记录正在运行（包括排队中）的任务，重新调度后也不会让同一任务并发执行
+	running map[string]bool
+	// active 和 locks 记录正在执行的任务占用的名额和锁
+	active int
+	locks  map[string]bool
+	// changed 在 running、active、locks 或配置变化时关闭并替换，用于唤醒排队的任务
+	changed chan struct{}
+}
+
+func newTaskScheduler() *taskScheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	waitCtx, stopWaiting := context.WithCancel(context.Background())
+	return &taskScheduler{
+		cron:        cron.New(cron.WithParser(cronParser)),
+		ctx:         ctx,
+		cancel:      cancel,
+		waitCtx:     waitCtx,
+		stopWaiting: stopWaiting,
+		entries:     make(map[string]scheduledTask),
+		running:     make(map[string]bool),
+		locks:       make(map[string]bool),
+		changed:     make(chan struct{}),
+	}
+}
+
+// apply 校验新配置并与已注册的任务对比：新增的任务加入 cron，删除的任务移出 cron，
+// cron 表达式变化的任务重新调度。校验失败时保持当前配置不变。
+func (s *taskScheduler) apply(conf config.GlobalConfig) error {
+	schedules := make(map[string]cron.Schedule, len(conf.BackupConf))
+	storages := oss.NewRegistry(conf)
+	for _, backupConf := range conf.BackupConf {
+		schedule, err := cronParser.Parse(backupTaskCron(backupConf))
+		if err != nil {
+			return fmt.Errorf("backup %s backup_task is invalid: %w", backupConf.GetID(), err)
+		}
+		if _, err := task.NewDestinations(backupConf, storages); err != nil {
+			return err
+		}
+		schedules[backupConf.GetID()] = schedule
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.conf = conf
+	s.storages = storages
+	s.noticeManager = notice.NewManagerFromConfig(conf)
+	// max_concurrent 可能变大，让排队的任务重新检查
+	s.notifyChanged()
+
+	for id, entry := range s.entries {
+		if _, ok := schedules[id]; ok {
+			continue
+		}
+		s.cron.Remove(entry.entryID)
+		delete(s.entries, id)
+		log.Printf("task %s removed from scheduler", id)
+	}
+
+	for _, backupConf := range conf.BackupConf {
+		id := backupConf.GetID()
+		spec := backupTaskCron(backupConf)
+
+		entry, exists := s.entries[id]
+		if exists && entry.schedule == spec {
+			continue
+		}
+		if exists {
+			s.cron.Remove(entry.entryID)
+		}
+
+		entryID := s.cron.Schedule(schedules[id], cron.FuncJob(func() { s.runTask(id) }))
+		s.entries[id] = scheduledTask{schedule: spec, entryID: entryID}
+		if exists {
+			log.Printf("task %s rescheduled: %s -> %s", id, entry.schedule, spec)
+		} else {
+			log.Printf("task %s added to scheduler", id)
+		}
+	}
+
+	return nil
+}
+
+// runTask 使用当前配置执行一次备份，任务需要的配置在这里一次取出，不读取全局配置；上一次运行还没结束时跳过本次触发。
+// 执行前先等待正在运行的依赖任务结束并检查依赖是否成功，再等待空闲名额和锁。
+func (s *taskScheduler) runTask(id string) {
+	firedAt := time.Now()
+	s.mu.Lock()
+	if s.running[id] {
+		s.mu.Unlock()
+		log.Printf("task %s is still running, skip", id)
+		return
+	}
+	conf, ok := s.conf.FindBackupByID(id)
+	depSchedules := s.dependencySchedules(conf.GetDependsOn())
+	storages := s.storages
+	noticeManager := s.noticeManager
+	historyKeep := s.conf.GetHistoryKeep()
+	if ok {
+		s.running[id] = true
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		return
+	}
+	defer func() {
+		s.mu.Lock()
+		delete(s.running, id)
+		s.notifyChanged()
+		s.mu.Unlock()
+	}()
+
+	destinations, err := task.NewDestinations(conf, storages)
+	if err != nil {
+		log.Printf("task %s skipped: %v", id, err)
+		return
+	}
+
+	holder := task.NewTaskHolder(conf, destinations, noticeManager, historyKeep)
+
+	if deps := conf.GetDependsOn(); len(deps) > 0 {
+		err := s.waitUntil(id, fmt.Sprintf("dependencies %v", deps), func() bool {
+			return !s.anyRunning(deps)
+		})
+		if err != nil {
+			log.Printf("task %s skipped: %v", id, err)
+			return
+		}
+		// apply 已经校验过 cron 表达式
+		schedule, _ := cronParser.Parse(backupTaskCron(conf))
+		if reason := unmetDependency(deps, schedule, depSchedules, state.GetState().Tasks(), firedAt); reason != "" {
+			holder.SkipTask(reason)
+			return
+		}
+	}
+
+	locks := conf.GetLocks()
+	if err := s.waitUntil(id, "a free slot", func() bool { return s.tryAcquire(locks) }); err != nil {
+		log.Printf("task %s skipped: %v", id, err)
+		return
+	}
+	defer s.release(locks)
+
+	holder.BackupTask(s.ctx)
+}
+
+// waitUntil 在持有 s.mu 时调用 try，直到它返回 true；停止调度器时返回 errShuttingDown。
+func (s *taskScheduler) waitUntil(id string, target string, try func() bool) error {
+	logged := false
+	for {
+		s.mu.Lock()
+		if try() {
+			s.mu.Unlock()
+			return nil
+		}
+		changed := s.changed
+		s.mu.Unlock()
+
+		if !logged {
+			log.Printf("task %s waiting for %s", id, target)
+			logged = true
+		}
+		select {
+		case <-s.waitCtx.Done():
+			return errShuttingDown
+		case <-changed:
+		}
+	}
+}
+
+// notifyChanged 唤醒排队的任务，调用方需要持有 s.mu。
+func (s *taskScheduler) notifyChanged() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func (s *taskScheduler) anyRunning(ids []string) bool {
+	for _, id := range ids {
+		if s.running[id] {
+			return true
+		}
+	}
+	return false
+}
+
+// tryAcquire 在名额和全部锁都空闲时占用它们，调用方需要持有 s.mu。
+func (s *taskScheduler) tryAcquire(locks []string) bool {
+	if limit := s.conf.MaxConcurrent; limit > 0 && s.active >= limit {
+		return false
+	}
+	for _, lock := range locks {
+		if s.locks[lock] {
+			return false
+		}
+	}
+
+	s.active++
+	for _, lock := range locks {
+		s.locks[lock] = true
+	}
+	return true
+}
+
+func (s *taskScheduler) release(locks []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.active--
+	for _, lock := range locks {
+		delete(s.locks, lock)
+	}
+	s.notifyChanged()
+}
+
+// dependencySchedules 返回依赖任务的 cron 计划，调用方需要持有 s.mu。
+func (s *taskScheduler) dependencySchedules(deps []string) map[string]cron.Schedule {
+	schedules := make(map[string]cron.Schedule, len(deps))
+	for _, dep := range deps {
+		depConf, ok := s.conf.FindBackupByID(dep)
+		if !ok {
+			continue
+		}
+		if schedule, err := cronParser.Parse(backupTaskCron(depConf)); err == nil {
+			schedules[dep] = schedule
+		}
+	}
+	return schedules
+}
+
+// unmetDependency 返回第一个没有满足的依赖的原因。firedAt 是本任务这次触发的时间，schedule 是本任务的 cron。
+// 依赖任务本轮的计划运行取它的 cron 在 firedAt 前后最近的一次触发：
+//   - 最近的一次在 firedAt 之后、并且早于本任务下一次触发时，依赖任务安排在本任务之后，本轮还没有运行；
+//   - 否则取 firedAt 之前最近的一次，依赖任务最近一次运行必须成功，并且成功时间不早于这次计划运行。
+func unmetDependency(deps []string, schedule cron.Schedule, schedules map[string]cron.Schedule, tasks map[string]state.TaskState, firedAt time.Time) string {
+	for _, dep := range deps {
+		taskState, ok := tasks[dep]
+		if !ok || taskState.LastStatus != state.StatusSuccess {
+			return fmt.Sprintf("依赖任务 %s 最近一次运行没有成功", dep)
+		}
+
+		depSchedule, ok := schedules[dep]
+		if !ok {
+			continue
+		}
+		previous := previousFire(depSchedule, firedAt)
+		next := depSchedule.Next(firedAt)
+		if schedule != nil && next.Before(schedule.Next(firedAt)) && (previous.IsZero() || next.Sub(firedAt) < firedAt.Sub(previous)) {
+			return fmt.Sprintf("依赖任务 %s 本轮计划在 %s 运行，晚于本任务", dep, next.Format("2006-01-02 15:04:05"))
+		}
+		if taskState.LastSuccess.Before(previous) {
+			return fmt.Sprintf("依赖任务 %s 在本轮计划运行（%s）之后没有成功运行", dep, previous.Format("2006-01-02 15:04:05"))
+		}
+	}
+	return ""
+}
+
+// previousFire 返回 schedule 在 t 之前（含 t）最近一次触发的时间。cron 只能向后计算，
+// 从较短的时间窗口开始向前找，避免高频 cron 逐个遍历一整年；一年内没有触发时返回零值。
+func previousFire(schedule cron.Schedule, t time.Time) time.Time {
+	for _, window := range []time.Duration{time.Hour, 25 * time.Hour, 8 * 24 * time.Hour, 32 * 24 * time.Hour, 367 * 24 * time.Hour} {
+		var previous time.Time
+		for next := schedule.Next(t.Add(-window)); !next.IsZero() && !next.After(t); next = schedule.Next(next) {
+			previous = next
+		}
+		if !previous.IsZero() {
+			return previous
+		}
+	}
+	return time.Time{}
+}
+
+// shutdown 停止调度并等待正在运行的任务结束。超过 timeout 或再次收到停止信号时取消任务，
+// 再最多等待 shutdownGracePeriod 让任务完成清理。
+func (s *taskScheduler) shutdown(timeout time.Duration, signals <-chan os.Signal) {
+	defer s.cancel()
+	// 还在排队的任务不再开始执行
+	s.stopWaiting()
+
+	stopped := s.cron.Stop()
+	if running := s.runningTasks(); len(running) > 0 {
+		log.Printf("waiting up to %s for running tasks: %v", timeout, running)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+wait:
+	for {
+		select {
+		case <-stopped.Done():
+			return
+		case <-timer.C:
+			log.Printf("running tasks did not finish within %s, cancelling", timeout)
+			break wait
+		case sig := <-signals:
+			if sig == syscall.SIGHUP {
+				continue
+			}
+			log.Printf("received %s again, cancelling running tasks", sig)
+			break wait
+		}
+	}
+
+	s.cancel()
+	select {
+	case <-stopped.Done():
+	case <-time.After(shutdownGracePeriod):
+		log.Printf("running tasks did not exit within %s after cancellation", shutdownGracePeriod)
+	}
+}
+
+func (s *taskScheduler) runningTasks() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]string, 0, len(s.running))
+	for id := range s.running {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// monitorTasks 返回 HTTP 接口展示的任务列表
+func (s *taskScheduler) monitorTasks() []monitor.Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tasks := make([]monitor.Task, 0, len(s.conf.BackupConf))
+	for _, backupConf := range s.conf.BackupConf {
+		entry := s.entries[backupConf.GetID()]
+		tasks = append(tasks, monitor.Task{
+			ID:       backupConf.GetID(),
+			Type:     backupConf.GetType(),
+			Storage:  strings.Join(backupConf.GetStorageNames(), ","),
+			Schedule: entry.schedule,
+			EntryID:  entry.entryID,
+		})
+	}
+	return tasks
+}
+
+// config 返回当前生效的配置。重新加载只替换 s.conf，不修改全局的 config.Config，避免与正在运行的任务竞争。
+func (s *taskScheduler) config() config.GlobalConfig {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conf
+}
+
+func backupTaskCron(conf config.BackupConfig) string {
+	if conf.BackupTask == "" {
+		return defaultBackupTaskCron
+	}
+	return conf.BackupTask
+}
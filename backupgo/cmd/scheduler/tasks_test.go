@@ -0,0 +1,325 @@
+package scheduler
+
+import (
+	"backupgo/config"
+	"backupgo/state"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+func parseTestConfig(t *testing.T, dir string, backups string) config.GlobalConfig {
+	t.Helper()
+
+	conf, err := config.ParseConfig([]byte(fmt.Sprintf(`
+storages:
+  - name: 'nas'
+    type: 'local'
+    local:
+      path: '%s'
+backup:
+%s`, dir, backups)))
+	if err != nil {
+		t.Fatalf("ParseConfig returned error: %v", err)
+	}
+	return conf
+}
+
+func TestTaskSchedulerApplyDiffsTasks(t *testing.T) {
+	dir := t.TempDir()
+	tasks := newTaskScheduler()
+
+	if err := tasks.apply(parseTestConfig(t, dir, `
+  - id: 'app'
+    backup_path: './app'
+    storage: 'nas'
+    backup_task: '0 0 1 * * ?'
+  - id: 'logs'
+    backup_path: './logs'
+    storage: 'nas'
+`)); err != nil {
+		t.Fatalf("apply returned error: %v", err)
+	}
+	app := tasks.entries["app"]
+	logs := tasks.entries["logs"]
+	if len(tasks.cron.Entries()) != 2 || logs.schedule != defaultBackupTaskCron {
+		t.Fatalf("unexpected entries: %+v", tasks.entries)
+	}
+
+	if err := tasks.apply(parseTestConfig(t, dir, `
+  - id: 'app'
+    backup_path: './app-v2'
+    storage: 'nas'
+    backup_task: '0 0 1 * * ?'
+  - id: 'logs'
+    backup_path: './logs'
+    storage: 'nas'
+    backup_task: '0 30 2 * * ?'
+  - id: 'db'
+    backup_path: './db'
+    storage: 'nas'
+`)); err != nil {
+		t.Fatalf("apply returned error: %v", err)
+	}
+
+	if tasks.entries["app"].entryID != app.entryID {
+		t.Fatal("expected task with unchanged schedule to keep its cron entry")
+	}
+	if tasks.entries["logs"].entryID == logs.entryID || tasks.entries["logs"].schedule != "0 30 2 * * ?" {
+		t.Fatalf("expected logs to be rescheduled, got %+v", tasks.entries["logs"])
+	}
+	if _, ok := tasks.entries["db"]; !ok || len(tasks.cron.Entries()) != 3 {
+		t.Fatalf("expected db to be added, got %+v", tasks.entries)
+	}
+	if conf, _ := tasks.conf.FindBackupByID("app"); conf.BackupPath != "./app-v2" {
+		t.Fatalf("expected running config to be replaced, got %+v", conf)
+	}
+
+	if err := tasks.apply(parseTestConfig(t, dir, `
+  - id: 'db'
+    backup_path: './db'
+    storage: 'nas'
+`)); err != nil {
+		t.Fatalf("apply returned error: %v", err)
+	}
+	if len(tasks.entries) != 1 || len(tasks.cron.Entries()) != 1 {
+		t.Fatalf("expected removed tasks to leave the scheduler, got %+v", tasks.entries)
+	}
+}
+
+func TestTaskSchedulerApplyKeepsConfigOnInvalidSchedule(t *testing.T) {
+	dir := t.TempDir()
+	tasks := newTaskScheduler()
+
+	if err := tasks.apply(parseTestConfig(t, dir, `
+  - id: 'app'
+    backup_path: './app'
+    storage: 'nas'
+`)); err != nil {
+		t.Fatalf("apply returned error: %v", err)
+	}
+	before := tasks.entries["app"]
+
+	if err := tasks.apply(parseTestConfig(t, dir, `
+  - id: 'app'
+    backup_path: './app'
+    storage: 'nas'
+    backup_task: 'not a cron'
+  - id: 'db'
+    backup_path: './db'
+    storage: 'nas'
+`)); err == nil {
+		t.Fatal("expected apply to fail for invalid backup_task")
+	}
+
+	if len(tasks.entries) != 1 || tasks.entries["app"] != before {
+		t.Fatalf("expected running tasks to be kept, got %+v", tasks.entries)
+	}
+	if len(tasks.conf.BackupConf) != 1 {
+		t.Fatalf("expected running config to be kept, got %+v", tasks.conf.BackupConf)
+	}
+}
+
+func TestTaskSchedulerShutdownCancelsRunningTasks(t *testing.T) {
+	tasks := newTaskScheduler()
+	started := make(chan struct{})
+	var once sync.Once
+	if _, err := tasks.cron.AddFunc("* * * * * ?", func() {
+		once.Do(func() { close(started) })
+		<-tasks.ctx.Done()
+	}); err != nil {
+		t.Fatalf("AddFunc returned error: %v", err)
+	}
+	tasks.cron.Start()
+
+	select {
+	case <-started:
+	case <-time.After(3 * time.Second):
+		t.Fatal("job did not start")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		tasks.shutdown(10*time.Millisecond, nil)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("shutdown did not return after cancelling the running job")
+	}
+	if tasks.ctx.Err() == nil {
+		t.Fatal("expected task context to be cancelled")
+	}
+}
+
+func TestTaskSchedulerLimitsConcurrencyAndLocks(t *testing.T) {
+	tasks := newTaskScheduler()
+	tasks.conf.MaxConcurrent = 2
+
+	tasks.mu.Lock()
+	first := tasks.tryAcquire([]string{"docker-1"})
+	sameLock := tasks.tryAcquire([]string{"docker-1"})
+	second := tasks.tryAcquire(nil)
+	overLimit := tasks.tryAcquire(nil)
+	tasks.mu.Unlock()
+
+	if !first || sameLock || !second || overLimit {
+		t.Fatalf("unexpected acquire results: first %v, same lock %v, second %v, over limit %v", first, sameLock, second, overLimit)
+	}
+
+	acquired := make(chan error, 1)
+	go func() {
+		acquired <- tasks.waitUntil("volumes", "a free slot", func() bool { return tasks.tryAcquire([]string{"docker-1"}) })
+	}()
+
+	select {
+	case err := <-acquired:
+		t.Fatalf("waitUntil returned before the lock was released: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	tasks.release([]string{"docker-1"})
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Fatalf("waitUntil returned error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waitUntil did not wake up after release")
+	}
+
+	go func() {
+		acquired <- tasks.waitUntil("logs", "a free slot", func() bool { return tasks.tryAcquire(nil) })
+	}()
+	tasks.stopWaiting()
+	if err := <-acquired; err != errShuttingDown {
+		t.Fatalf("expected errShuttingDown, got %v", err)
+	}
+}
+
+func TestUnmetDependency(t *testing.T) {
+	// 本任务每天 03:00 触发
+	firedAt := time.Date(2024, 3, 8, 3, 0, 0, 0, time.UTC)
+	parse := func(spec string) cron.Schedule {
+		t.Helper()
+		schedule, err := cronParser.Parse(spec)
+		if err != nil {
+			t.Fatalf("parse %s: %v", spec, err)
+		}
+		return schedule
+	}
+	schedule := parse("0 0 3 * * ?")
+	schedules := map[string]cron.Schedule{
+		"pg":      parse("0 0 1 * * ?"),
+		"mysql":   parse("0 0 1 * * ?"),
+		"weekly":  parse("0 0 1 * * 0"),
+		"evening": parse("0 30 23 * * ?"),
+		"missed":  parse("0 0 2 * * ?"),
+		"later":   parse("0 0 5 * * ?"),
+	}
+	tasks := map[string]state.TaskState{
+		"pg":    {LastStatus: state.StatusSuccess, LastSuccess: firedAt.Add(-time.Hour)},
+		"mongo": {LastStatus: state.StatusFailed, LastSuccess: firedAt.Add(-time.Hour)},
+		// 成功时间在 24 小时之内，但早于今天 01:00 的计划运行
+		"mysql": {LastStatus: state.StatusSuccess, LastSuccess: firedAt.Add(-23 * time.Hour)},
+		// 每周日运行，2024-03-03 的成功仍然是本轮的结果
+		"weekly": {LastStatus: state.StatusSuccess, LastSuccess: time.Date(2024, 3, 3, 1, 5, 0, 0, time.UTC)},
+		// 前一天晚上运行，跨过零点仍然属于本轮
+		"evening": {LastStatus: state.StatusSuccess, LastSuccess: time.Date(2024, 3, 7, 23, 35, 0, 0, time.UTC)},
+		// 今天 02:00 的计划运行没有成功（例如没有执行），前一天的成功不算数
+		"missed": {LastStatus: state.StatusSuccess, LastSuccess: time.Date(2024, 3, 7, 2, 5, 0, 0, time.UTC)},
+		// 安排在本任务之后，本轮还没有运行，前一天的成功不算数
+		"later": {LastStatus: state.StatusSuccess, LastSuccess: time.Date(2024, 3, 7, 5, 5, 0, 0, time.UTC)},
+	}
+
+	for _, dep := range []string{"pg", "weekly", "evening"} {
+		if reason := unmetDependency([]string{dep}, schedule, schedules, tasks, firedAt); reason != "" {
+			t.Fatalf("expected %s dependency to be met, got %q", dep, reason)
+		}
+	}
+	for _, dep := range []string{"mongo", "mysql", "missed", "later", "redis"} {
+		if reason := unmetDependency([]string{"pg", dep}, schedule, schedules, tasks, firedAt); !strings.Contains(reason, dep) {
+			t.Fatalf("expected %s dependency to be unmet, got %q", dep, reason)
+		}
+	}
+	if reason := unmetDependency([]string{"later"}, schedule, schedules, tasks, firedAt); !strings.Contains(reason, "晚于本任务") {
+		t.Fatalf("unexpected reason: %q", reason)
+	}
+}
+
+func TestPreviousFire(t *testing.T) {
+	at := time.Date(2024, 3, 8, 3, 0, 0, 0, time.UTC)
+	for spec, want := range map[string]time.Time{
+		"0 0 1 * * ?":   time.Date(2024, 3, 8, 1, 0, 0, 0, time.UTC),
+		"0 0 3 * * ?":   at,
+		"0 0 4 * * ?":   time.Date(2024, 3, 7, 4, 0, 0, 0, time.UTC),
+		"*/3 * * * * ?": at,
+		"0 0 0 1 * ?":   time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+	} {
+		schedule, err := cronParser.Parse(spec)
+		if err != nil {
+			t.Fatalf("parse %s: %v", spec, err)
+		}
+		if got := previousFire(schedule, at); !got.Equal(want) {
+			t.Fatalf("previousFire(%s) = %s, want %s", spec, got, want)
+		}
+	}
+}
+
+func TestReloadConfigDoesNotReplaceGlobalConfig(t *testing.T) {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd returned error: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Chdir returned error: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	original := config.Config
+	t.Cleanup(func() { config.Config = original })
+	config.Config = parseTestConfig(t, dir, `
+  - id: 'app'
+    backup_path: './app'
+    storage: 'nas'
+`)
+
+	tasks := newTaskScheduler()
+	if err := tasks.apply(config.Config); err != nil {
+		t.Fatalf("apply returned error: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "config.yml"), []byte(fmt.Sprintf(`
+history:
+  keep: 5
+storages:
+  - name: 'nas'
+    type: 'local'
+    local:
+      path: '%s'
+backup:
+  - id: 'app'
+    backup_path: './app-v2'
+    storage: 'nas'
+`, dir)), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	reloadConfig(tasks, nil)
+
+	// 正在运行的任务只使用创建时取出的配置，重新加载只替换调度器持有的配置
+	if got := tasks.config().GetHistoryKeep(); got != 5 {
+		t.Fatalf("expected reloaded history keep, got %d", got)
+	}
+	if conf, _ := config.Config.FindBackupByID("app"); conf.BackupPath != "./app" || config.Config.History != nil {
+		t.Fatalf("expected global config to be untouched, got %+v", conf)
+	}
+}
@@ -2,14 +2,20 @@ package status
 
 import (
 	"backupgo/config"
+	"backupgo/manifest"
+	"backupgo/notice"
+	"backupgo/oss"
 	"backupgo/pkg/consts"
 	"backupgo/pkg/procutil"
 	"backupgo/state"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/urfave/cli/v3"
@@ -17,9 +23,13 @@ import (
 
 func StatusCommand() *cli.Command {
 	return &cli.Command{
-		Name:  "status",
-		Usage: "Show scheduler status and list all backup tasks",
+		Name:      "status",
+		Usage:     "Show scheduler status and list all backup tasks, or the latest backup of a specific task",
+		ArgsUsage: "[backup-id]",
 		Action: func(ctx context.Context, cmd *cli.Command) error {
+			if backupID := cmd.Args().First(); backupID != "" {
+				return showLatestBackup(ctx, os.Stdout, backupID)
+			}
 			return runStatus()
 		},
 	}
@@ -114,3 +124,55 @@ func listTasks() {
 		fmt.Printf(format, conf.GetID(), conf.GetType(), cronExpr, lastRun)
 	}
 }
+
+// showLatestBackup 读取任务主存储中最新备份的清单，不下载备份本身。
+func showLatestBackup(ctx context.Context, output io.Writer, backupID string) error {
+	config.InitConfig()
+
+	conf, ok := config.Config.FindBackupByID(backupID)
+	if !ok {
+		return fmt.Errorf("backup task not found: %s", backupID)
+	}
+
+	storage, err := oss.NewRegistry(config.Config).Get(conf.GetStorage())
+	if err != nil {
+		return err
+	}
+
+	names, err := conf.FileNameProcessor()
+	if err != nil {
+		return err
+	}
+
+	m, err := manifest.Latest(ctx, storage, names)
+	if errors.Is(err, manifest.ErrNotFound) {
+		fmt.Fprintf(output, "No backup manifest found for task %s in %s\n", conf.GetID(), storage.BucketName())
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read latest manifest failed: %w", err)
+	}
+
+	printManifest(output, storage.BucketName(), m)
+	return nil
+}
+
+func printManifest(output io.Writer, bucket string, m *manifest.Manifest) {
+	writer := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(writer, "Task:\t%s (%s)\n", m.TaskID, m.Type)
+	fmt.Fprintf(writer, "Latest backup:\t%s\n", m.Key)
+	fmt.Fprintf(writer, "Storage:\t%s\n", bucket)
+	fmt.Fprintf(writer, "Finished at:\t%s (took %s)\n", m.FinishedAt.Format(time.RFC3339), m.FinishedAt.Sub(m.StartedAt).Round(time.Second))
+	fmt.Fprintf(writer, "Size:\t%s\n", notice.FormatBytes(m.Size))
+	fmt.Fprintf(writer, "SHA-256:\t%s\n", m.SHA256)
+	if m.Encryption != "" {
+		fmt.Fprintf(writer, "Encryption:\t%s\n", m.Encryption)
+	}
+	if len(m.Databases) > 0 {
+		fmt.Fprintf(writer, "Databases:\t%s\n", strings.Join(m.Databases, ", "))
+	}
+	fmt.Fprintf(writer, "Files:\t%d\n", len(m.Files))
+	fmt.Fprintf(writer, "Host:\t%s\n", m.Host)
+	fmt.Fprintf(writer, "backupgo version:\t%s\n", m.BackupgoVersion)
+	_ = writer.Flush()
+}
@@ -1,10 +1,15 @@
 package config
 
 import (
+	"backupgo/utils"
 	"errors"
 	"fmt"
+	"net"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/goccy/go-yaml"
 )
@@ -14,84 +19,376 @@ const (
 	BackupTypePostgres     = "postgres"
 	BackupTypeMongoDB      = "mongodb"
 	BackupTypeDockerVolume = "docker_volume"
+	BackupTypeMySQL        = "mysql"
+	BackupTypeRedis        = "redis"
+	BackupTypeSQLite       = "sqlite"
+	// BackupTypeComposite 表示配置了 sources 的任务，多个备份源写入同一个备份文件
+	BackupTypeComposite = "composite"
 
 	ExecModeLocal  = "local"
 	ExecModeDocker = "docker"
+
+	MySQLDumpExecutable   = "mysqldump"
+	MariaDBDumpExecutable = "mariadb-dump"
+
+	StorageTypeOSS   = "oss"
+	StorageTypeS3    = "s3"
+	StorageTypeLocal = "local"
+	StorageTypeSFTP  = "sftp"
+
+	EncryptionTypeAge    = "age"
+	EncryptionTypeAESGCM = "aes-gcm"
+
+	VerifyModeFull  = "full"
+	VerifyModeQuick = "quick"
+
+	NoticeOnAlways  = "always"
+	NoticeOnFailure = "failure"
+	NoticeOnSuccess = "success"
+
+	NoticeChannelMail     = "mail"
+	NoticeChannelTelegram = "telegram"
+	NoticeChannelWebhook  = "webhook"
+	NoticeChannelSlack    = "slack"
+	NoticeChannelDiscord  = "discord"
+	NoticeChannelNtfy     = "ntfy"
+	NoticeChannelGotify   = "gotify"
+
+	DefaultNtfyServer = "https://ntfy.sh"
+
+	DefaultStreamPartSizeMB  = 16
+	MinStreamPartSizeMB      = 5
+	DefaultStreamPartRetries = 3
+	DefaultPackSizeMB        = 64
+	DefaultHistoryKeep       = 100
+	DefaultRetryBackoff      = 30 * time.Second
+
+	// DefaultShutdownTimeout 是停止调度器时等待正在运行的任务结束的默认时间
+	DefaultShutdownTimeout = 10 * time.Minute
+
+	// DefaultStorageName 是顶层 oss 配置对应的存储名称，未指定 storage 的任务使用它。
+	DefaultStorageName = "oss"
 )
 
 type (
 	// GlobalConfig base config
 	GlobalConfig struct {
-		OSS        OssConfig      `yaml:"oss"`
-		Notice     *NoticeConfig  `yaml:"notice"`
-		BackupConf []BackupConfig `yaml:"backup"`
+		OSS        OssConfig       `yaml:"oss"`
+		Storages   []StorageConfig `yaml:"storages"`
+		Notice     *NoticeConfig   `yaml:"notice"`
+		HTTP       *HTTPConfig     `yaml:"http"`
+		History    *HistoryConfig  `yaml:"history"`
+		BackupConf []BackupConfig  `yaml:"backup"`
+		// ShutdownTimeout 停止调度器时等待正在运行的任务结束的时间，超时后取消任务，例如 "30m"
+		ShutdownTimeout string `yaml:"shutdown_timeout"`
+		// MaxConcurrent 调度器同时运行的任务数上限，0 表示不限制
+		MaxConcurrent int `yaml:"max_concurrent"`
+		// KeyTemplate 是没有配置 key_template 的任务使用的对象 key 模板，增量备份不使用
+		KeyTemplate string `yaml:"key_template"`
+	}
+
+	// HTTPConfig 调度进程的状态接口和 Prometheus 指标，未配置时不监听端口
+	HTTPConfig struct {
+		Listen string `yaml:"listen"`
+	}
+
+	// HistoryConfig 运行历史的保留条数，按任务分别计算
+	HistoryConfig struct {
+		Keep int `yaml:"keep"`
 	}
 
 	NoticeConfig struct {
-		Mail     *MailConfig     `yaml:"mail"`
-		Telegram *TelegramConfig `yaml:"telegram"`
+		Mail     *MailConfig             `yaml:"mail"`
+		Telegram *TelegramConfig         `yaml:"telegram"`
+		Webhooks []WebhookConfig         `yaml:"webhooks"`
+		Slack    []IncomingWebhookConfig `yaml:"slack"`
+		Discord  []IncomingWebhookConfig `yaml:"discord"`
+		Ntfy     []NtfyConfig            `yaml:"ntfy"`
+		Gotify   []GotifyConfig          `yaml:"gotify"`
+	}
+
+	// NoticeChannel 通知渠道的公共字段：Name 供任务的 notice.channels 引用，On 控制哪些结果发送到该渠道
+	NoticeChannel struct {
+		Name string `yaml:"name"`
+		On   string `yaml:"on"`
+	}
+
+	// WebhookConfig 以 JSON 格式把任务报告 POST 到 URL
+	WebhookConfig struct {
+		NoticeChannel `yaml:",inline"`
+		URL           string            `yaml:"url"`
+		Headers       map[string]string `yaml:"headers"`
+	}
+
+	// IncomingWebhookConfig Slack / Discord 的 incoming webhook
+	IncomingWebhookConfig struct {
+		NoticeChannel  `yaml:",inline"`
+		WebhookURL     string `yaml:"webhook_url"`
+		WebhookURLFile string `yaml:"webhook_url_file"`
+	}
+
+	NtfyConfig struct {
+		NoticeChannel `yaml:",inline"`
+		Server        string `yaml:"server"`
+		Topic         string `yaml:"topic"`
+		Token         string `yaml:"token"`
+		TokenFile     string `yaml:"token_file"`
+	}
+
+	GotifyConfig struct {
+		NoticeChannel `yaml:",inline"`
+		Server        string `yaml:"server"`
+		Token         string `yaml:"token"`
+		TokenFile     string `yaml:"token_file"`
+	}
+
+	// TaskNoticeConfig 任务级别的通知路由
+	TaskNoticeConfig struct {
+		On       string   `yaml:"on"`
+		Channels []string `yaml:"channels"`
 	}
 
 	BackupConfig struct {
-		ID           string                    `yaml:"id"`
-		Type         string                    `yaml:"type"`
-		BeforeCmd    string                    `yaml:"before_command"`
-		BackupPath   string                    `yaml:"backup_path"`
-		AfterCmd     string                    `yaml:"after_command"`
-		BackupTask   string                    `yaml:"backup_task"`
+		ID          string             `yaml:"id"`
+		Type        string             `yaml:"type"`
+		BeforeCmd   string             `yaml:"before_command"`
+		BackupPath  string             `yaml:"backup_path"`
+		AfterCmd    string             `yaml:"after_command"`
+		BackupTask  string             `yaml:"backup_task"`
+		Storage     string             `yaml:"storage"`
+		Retention   *RetentionConfig   `yaml:"retention"`
+		Encryption  *EncryptionConfig  `yaml:"encryption"`
+		Archive     *ArchiveConfig     `yaml:"archive"`
+		Streaming   *StreamingConfig   `yaml:"streaming"`
+		Incremental *IncrementalConfig `yaml:"incremental"`
+		Verify      *VerifyConfig      `yaml:"verify"`
+		Timeout     *TimeoutConfig     `yaml:"timeout"`
+		Retry       *RetryConfig       `yaml:"retry"`
+		// Destinations 同时上传到多个存储，每个存储可以有各自的保留规则，不能与 Storage 同时配置
+		Destinations []DestinationConfig `yaml:"destinations"`
+		// KeyTemplate 是备份对象 key 的模板，占位符见 utils.FileNameProcessor
+		KeyTemplate string `yaml:"key_template"`
+		// Include 和 Exclude 是 path 类型的文件过滤规则，语法见 utils.PathFilter
+		Include []string `yaml:"include"`
+		Exclude []string `yaml:"exclude"`
+		// Locks 持有相同锁名的任务不会同时运行，例如同一台 docker 主机上的任务
+		Locks []string `yaml:"locks"`
+		// DependsOn 列出的任务最近一次运行成功后，本任务才会执行
+		DependsOn    []string                  `yaml:"depends_on"`
+		Notice       *TaskNoticeConfig         `yaml:"notice"`
 		Postgres     *PostgresBackupConfig     `yaml:"postgres"`
 		MongoDB      *MongoBackupConfig        `yaml:"mongodb"`
+		MySQL        *MySQLBackupConfig        `yaml:"mysql"`
+		Redis        *RedisBackupConfig        `yaml:"redis"`
+		SQLite       *SQLiteBackupConfig       `yaml:"sqlite"`
 		DockerVolume *DockerVolumeBackupConfig `yaml:"docker_volume"`
+		// Sources 组合多个备份源，每个备份源导出到备份中 <id>/<name> 目录
+		Sources []SourceConfig `yaml:"sources"`
+	}
+
+	DestinationConfig struct {
+		Storage string `yaml:"storage"`
+		// Retention 未配置时使用任务的 retention
+		Retention *RetentionConfig `yaml:"retention"`
 	}
 
 	PostgresBackupConfig struct {
-		Mode      string   `yaml:"mode"`
-		Container string   `yaml:"container"`
-		Host      string   `yaml:"host"`
-		Port      int      `yaml:"port"`
-		User      string   `yaml:"user"`
-		Password  string   `yaml:"password"`
-		Databases []string `yaml:"databases"`
-		ExtraArgs []string `yaml:"extra_args"`
+		Mode         string   `yaml:"mode"`
+		Container    string   `yaml:"container"`
+		Host         string   `yaml:"host"`
+		Port         int      `yaml:"port"`
+		User         string   `yaml:"user"`
+		Password     string   `yaml:"password"`
+		PasswordFile string   `yaml:"password_file"`
+		Databases    []string `yaml:"databases"`
+		ExtraArgs    []string `yaml:"extra_args"`
 	}
 
 	MongoBackupConfig struct {
 		Mode         string   `yaml:"mode"`
 		Container    string   `yaml:"container"`
 		URI          string   `yaml:"uri"`
+		URIFile      string   `yaml:"uri_file"`
 		Host         string   `yaml:"host"`
 		Port         int      `yaml:"port"`
 		Username     string   `yaml:"username"`
 		Password     string   `yaml:"password"`
+		PasswordFile string   `yaml:"password_file"`
 		AuthDatabase string   `yaml:"auth_database"`
 		Databases    []string `yaml:"databases"`
 		Gzip         bool     `yaml:"gzip"`
 		ExtraArgs    []string `yaml:"extra_args"`
 	}
 
+	MySQLBackupConfig struct {
+		Mode         string   `yaml:"mode"`
+		Container    string   `yaml:"container"`
+		Executable   string   `yaml:"executable"`
+		Host         string   `yaml:"host"`
+		Port         int      `yaml:"port"`
+		User         string   `yaml:"user"`
+		Password     string   `yaml:"password"`
+		PasswordFile string   `yaml:"password_file"`
+		Databases    []string `yaml:"databases"`
+		ExtraArgs    []string `yaml:"extra_args"`
+	}
+
+	RedisBackupConfig struct {
+		Mode         string   `yaml:"mode"`
+		Container    string   `yaml:"container"`
+		Host         string   `yaml:"host"`
+		Port         int      `yaml:"port"`
+		Username     string   `yaml:"username"`
+		Password     string   `yaml:"password"`
+		PasswordFile string   `yaml:"password_file"`
+		ExtraArgs    []string `yaml:"extra_args"`
+	}
+
+	SQLiteBackupConfig struct {
+		Mode      string   `yaml:"mode"`
+		Container string   `yaml:"container"`
+		Databases []string `yaml:"databases"`
+		Vacuum    bool     `yaml:"vacuum"`
+	}
+
 	DockerVolumeBackupConfig struct {
 		Volume string `yaml:"volume"`
 		Image  string `yaml:"image"`
 	}
 
+	// SourceConfig 是组合备份中的一个备份源，支持 path、postgres、mongodb 和 docker_volume
+	SourceConfig struct {
+		Name         string                    `yaml:"name"`
+		Type         string                    `yaml:"type"`
+		Path         string                    `yaml:"path"`
+		Include      []string                  `yaml:"include"`
+		Exclude      []string                  `yaml:"exclude"`
+		Postgres     *PostgresBackupConfig     `yaml:"postgres"`
+		MongoDB      *MongoBackupConfig        `yaml:"mongodb"`
+		DockerVolume *DockerVolumeBackupConfig `yaml:"docker_volume"`
+	}
+
 	OssConfig struct {
-		BucketName      string `yaml:"bucket_name"`
-		AccessKey       string `yaml:"access_key"`
-		AccessKeySecret string `yaml:"access_key_secret"`
-		Region          string `yaml:"region"`
+		BucketName          string `yaml:"bucket_name"`
+		AccessKey           string `yaml:"access_key"`
+		AccessKeySecret     string `yaml:"access_key_secret"`
+		AccessKeyFile       string `yaml:"access_key_file"`
+		AccessKeySecretFile string `yaml:"access_key_secret_file"`
+		Region              string `yaml:"region"`
+	}
+
+	// RetentionConfig 备份保留规则，多条规则之间是“或”的关系
+	RetentionConfig struct {
+		KeepLast    int `yaml:"keep_last"`
+		KeepDays    int `yaml:"keep_days"`
+		KeepDaily   int `yaml:"keep_daily"`
+		KeepWeekly  int `yaml:"keep_weekly"`
+		KeepMonthly int `yaml:"keep_monthly"`
+		KeepYearly  int `yaml:"keep_yearly"`
+	}
+
+	// EncryptionConfig 上传前对备份文件做客户端加密
+	EncryptionConfig struct {
+		Type           string   `yaml:"type"`
+		Passphrase     string   `yaml:"passphrase"`
+		PassphraseFile string   `yaml:"passphrase_file"`
+		Recipients     []string `yaml:"recipients"`
+		IdentityFile   string   `yaml:"identity_file"`
+	}
+
+	// ArchiveConfig 备份文件的格式和压缩级别。tar 格式保留文件权限、属主和符号链接
+	ArchiveConfig struct {
+		Format string `yaml:"format"`
+		Level  int    `yaml:"level"`
+	}
+
+	// StreamingConfig 流式模式下备份数据边压缩边分片上传，不在本地生成 zip 文件
+	StreamingConfig struct {
+		Enabled     bool `yaml:"enabled"`
+		PartSizeMB  int  `yaml:"part_size_mb"`
+		PartRetries int  `yaml:"part_retries"`
+	}
+
+	// IncrementalConfig 增量备份只上传新增或变化的文件内容，仅支持 path 类型
+	IncrementalConfig struct {
+		Enabled    bool `yaml:"enabled"`
+		PackSizeMB int  `yaml:"pack_size_mb"`
+	}
+
+	// VerifyConfig 上传完成后校验备份是否可以恢复
+	VerifyConfig struct {
+		Enabled bool   `yaml:"enabled"`
+		Mode    string `yaml:"mode"`
+	}
+
+	// TimeoutConfig 各阶段单次尝试的超时时间，例如 "30m"，未配置时不限制
+	TimeoutConfig struct {
+		Command  string `yaml:"command"`
+		Export   string `yaml:"export"`
+		Compress string `yaml:"compress"`
+		Upload   string `yaml:"upload"`
+	}
+
+	// RetryConfig 阶段失败后的重试策略，attempts 包含第一次尝试，backoff 每次重试后翻倍
+	RetryConfig struct {
+		Attempts int    `yaml:"attempts"`
+		Backoff  string `yaml:"backoff"`
+	}
+
+	StorageConfig struct {
+		Name  string              `yaml:"name"`
+		Type  string              `yaml:"type"`
+		OSS   *OssConfig          `yaml:"oss"`
+		S3    *S3Config           `yaml:"s3"`
+		Local *LocalStorageConfig `yaml:"local"`
+		SFTP  *SFTPConfig         `yaml:"sftp"`
+	}
+
+	S3Config struct {
+		Endpoint            string `yaml:"endpoint"`
+		Region              string `yaml:"region"`
+		BucketName          string `yaml:"bucket_name"`
+		AccessKey           string `yaml:"access_key"`
+		AccessKeySecret     string `yaml:"access_key_secret"`
+		AccessKeyFile       string `yaml:"access_key_file"`
+		AccessKeySecretFile string `yaml:"access_key_secret_file"`
+		Insecure            bool   `yaml:"insecure"`
+		PathStyle           bool   `yaml:"path_style"`
+	}
+
+	LocalStorageConfig struct {
+		Path string `yaml:"path"`
+	}
+
+	SFTPConfig struct {
+		Host           string `yaml:"host"`
+		Port           int    `yaml:"port"`
+		User           string `yaml:"user"`
+		Password       string `yaml:"password"`
+		PasswordFile   string `yaml:"password_file"`
+		PrivateKey     string `yaml:"private_key"`
+		KnownHostsFile string `yaml:"known_hosts"`
+		Path           string `yaml:"path"`
+
+		// InsecureIgnoreHostKey 为 true 时不校验服务端主机密钥，只应在测试环境使用
+		InsecureIgnoreHostKey bool `yaml:"insecure_ignore_host_key"`
 	}
 
 	TelegramConfig struct {
-		BotToken string `yaml:"bot_token"`
-		ChatID   string `yaml:"chat_id"`
+		NoticeChannel `yaml:",inline"`
+		BotToken      string `yaml:"bot_token"`
+		BotTokenFile  string `yaml:"bot_token_file"`
+		ChatID        string `yaml:"chat_id"`
 	}
 
 	MailConfig struct {
-		Smtp     string   `yaml:"smtp"`
-		Port     int      `yaml:"port"`
-		User     string   `yaml:"user"`
-		Password string   `yaml:"password"`
-		To       []string `yaml:"to"`
+		NoticeChannel `yaml:",inline"`
+		Smtp          string   `yaml:"smtp"`
+		Port          int      `yaml:"port"`
+		User          string   `yaml:"user"`
+		Password      string   `yaml:"password"`
+		PasswordFile  string   `yaml:"password_file"`
+		To            []string `yaml:"to"`
 	}
 )
 
@@ -103,6 +400,11 @@ func (c BackupConfig) GetID() string {
 	return strings.TrimSpace(c.ID)
 }
 
+// IsEmpty 判断是否完全没有配置 oss 节点。
+func (c OssConfig) IsEmpty() bool {
+	return c == OssConfig{}
+}
+
 func (c OssConfig) Validate() error {
 	if strings.TrimSpace(c.BucketName) == "" {
 		return errors.New("oss.bucket_name can not be empty")
@@ -120,10 +422,87 @@ func (c OssConfig) Validate() error {
 	return nil
 }
 
+// GetStorage 返回任务使用的存储名称，未配置时使用顶层 oss。配置了 destinations 时返回第一个存储。
+func (c BackupConfig) GetStorage() string {
+	if len(c.Destinations) > 0 {
+		return strings.TrimSpace(c.Destinations[0].Storage)
+	}
+	if name := strings.TrimSpace(c.Storage); name != "" {
+		return name
+	}
+	return DefaultStorageName
+}
+
+// GetDestinations 返回任务的全部上传目标，未配置 destinations 时只有 storage 一个目标。
+// 没有单独配置 retention 的目标使用任务的 retention。
+func (c BackupConfig) GetDestinations() []DestinationConfig {
+	if len(c.Destinations) == 0 {
+		return []DestinationConfig{{Storage: c.GetStorage(), Retention: c.Retention}}
+	}
+
+	destinations := make([]DestinationConfig, 0, len(c.Destinations))
+	for _, destination := range c.Destinations {
+		destination.Storage = strings.TrimSpace(destination.Storage)
+		if destination.Retention == nil {
+			destination.Retention = c.Retention
+		}
+		destinations = append(destinations, destination)
+	}
+	return destinations
+}
+
+// GetStorageNames 返回全部上传目标的存储名称。
+func (c BackupConfig) GetStorageNames() []string {
+	destinations := c.GetDestinations()
+	names := make([]string, 0, len(destinations))
+	for _, destination := range destinations {
+		names = append(names, destination.Storage)
+	}
+	return names
+}
+
+// GetKeyTemplate 返回对象 key 模板，未配置时为 <id>_YYYY_MM_DD.<ext>
+func (c BackupConfig) GetKeyTemplate() string {
+	if c.KeyTemplate == "" {
+		return utils.DefaultKeyTemplate
+	}
+	return c.KeyTemplate
+}
+
+// FileNameProcessor 返回按 key 模板生成和解析本机备份对象 key 的处理器，{host} 取本机主机名。
+func (c BackupConfig) FileNameProcessor() (*utils.FileNameProcessor, error) {
+	host, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("get hostname failed: %w", err)
+	}
+	return utils.NewFileNameProcessor(c.GetKeyTemplate(), c.GetID(), host)
+}
+
+// GetLocks 返回去掉空白后的锁名。
+func (c BackupConfig) GetLocks() []string {
+	return trimmedNames(c.Locks)
+}
+
+// GetDependsOn 返回去掉空白后的依赖任务 ID。
+func (c BackupConfig) GetDependsOn() []string {
+	return trimmedNames(c.DependsOn)
+}
+
+func trimmedNames(values []string) []string {
+	names := make([]string, 0, len(values))
+	for _, value := range values {
+		names = append(names, strings.TrimSpace(value))
+	}
+	return names
+}
+
 func (c BackupConfig) GetType() string {
 	if normalized := strings.ToLower(strings.TrimSpace(c.Type)); normalized != "" {
 		return normalized
 	}
+	if len(c.Sources) > 0 {
+		return BackupTypeComposite
+	}
 	if c.Postgres != nil {
 		return BackupTypePostgres
 	}
@@ -133,6 +512,15 @@ func (c BackupConfig) GetType() string {
 	if c.DockerVolume != nil {
 		return BackupTypeDockerVolume
 	}
+	if c.MySQL != nil {
+		return BackupTypeMySQL
+	}
+	if c.Redis != nil {
+		return BackupTypeRedis
+	}
+	if c.SQLite != nil {
+		return BackupTypeSQLite
+	}
 	return BackupTypePath
 }
 
@@ -155,12 +543,118 @@ func (c BackupConfig) Validate() error {
 	if c.DockerVolume != nil {
 		sourceCount++
 	}
+	if c.MySQL != nil {
+		sourceCount++
+	}
+	if c.Redis != nil {
+		sourceCount++
+	}
+	if c.SQLite != nil {
+		sourceCount++
+	}
+	if len(c.Sources) > 0 {
+		sourceCount++
+	}
 
 	if sourceCount == 0 {
 		return fmt.Errorf("backup %s must configure one source", taskID)
 	}
 	if sourceCount > 1 {
-		return fmt.Errorf("backup %s only supports one source at a time", taskID)
+		return fmt.Errorf("backup %s only supports one source at a time, use sources to combine several", taskID)
+	}
+
+	if c.Retention != nil {
+		if err := c.Retention.Validate(taskID); err != nil {
+			return err
+		}
+	}
+	if len(c.Destinations) > 0 {
+		if err := c.validateDestinations(); err != nil {
+			return err
+		}
+	}
+	if c.Encryption != nil {
+		if err := c.Encryption.Validate(taskID); err != nil {
+			return err
+		}
+	}
+
+	if c.Archive != nil {
+		if err := c.Archive.Validate(taskID); err != nil {
+			return err
+		}
+		if c.Incremental.IsEnabled() {
+			return fmt.Errorf("backup %s archive can not be combined with incremental", taskID)
+		}
+	}
+
+	if c.Streaming != nil {
+		if err := c.Streaming.Validate(taskID); err != nil {
+			return err
+		}
+	}
+
+	if c.KeyTemplate != "" {
+		if _, err := utils.NewFileNameProcessor(c.KeyTemplate, taskID, "host"); err != nil {
+			return fmt.Errorf("backup %s key_template is invalid: %w", taskID, err)
+		}
+		if c.Incremental.IsEnabled() {
+			return fmt.Errorf("backup %s key_template can not be combined with incremental", taskID)
+		}
+	}
+
+	if c.Incremental != nil {
+		if err := c.Incremental.Validate(taskID); err != nil {
+			return err
+		}
+		if c.Incremental.IsEnabled() && c.GetType() != BackupTypePath {
+			return fmt.Errorf("backup %s incremental is only supported by type %s", taskID, BackupTypePath)
+		}
+		if c.Incremental.IsEnabled() && c.Streaming.IsEnabled() {
+			return fmt.Errorf("backup %s incremental can not be combined with streaming", taskID)
+		}
+	}
+
+	if c.Verify != nil {
+		if err := c.Verify.Validate(taskID); err != nil {
+			return err
+		}
+	}
+
+	if c.Timeout != nil {
+		if err := c.Timeout.Validate(taskID); err != nil {
+			return err
+		}
+	}
+
+	if c.Retry != nil {
+		if err := c.Retry.Validate(taskID); err != nil {
+			return err
+		}
+	}
+
+	for _, lock := range c.GetLocks() {
+		if lock == "" {
+			return fmt.Errorf("backup %s locks can not contain empty names", taskID)
+		}
+	}
+
+	if c.Notice != nil && !isNoticeOn(c.Notice.GetOn()) {
+		return fmt.Errorf("backup %s notice.on must be one of %q, %q or %q", taskID, NoticeOnAlways, NoticeOnFailure, NoticeOnSuccess)
+	}
+
+	if (len(c.Include) > 0 || len(c.Exclude) > 0) && c.GetType() != BackupTypePath {
+		return fmt.Errorf("backup %s include and exclude are only supported by type %s", taskID, BackupTypePath)
+	}
+	for _, field := range []struct {
+		name     string
+		patterns []string
+	}{{"include", c.Include}, {"exclude", c.Exclude}} {
+		for _, pattern := range field.patterns {
+			if err := utils.ValidatePattern(pattern); err != nil {
+				return fmt.Errorf("backup %s %s pattern %q is invalid: %w", taskID, field.name, pattern, err)
+			}
+		}
 	}
 
 	switch c.GetType() {
@@ -168,7 +662,7 @@ func (c BackupConfig) Validate() error {
 		if strings.TrimSpace(c.BackupPath) == "" {
 			return fmt.Errorf("backup %s backup_path can not be empty", taskID)
 		}
-		if c.Postgres != nil || c.MongoDB != nil || c.DockerVolume != nil {
+		if c.Postgres != nil || c.MongoDB != nil || c.DockerVolume != nil || c.MySQL != nil || c.Redis != nil || c.SQLite != nil {
 			return fmt.Errorf("backup %s path source can not be combined with another source", taskID)
 		}
 	case BackupTypePostgres:
@@ -185,6 +679,27 @@ func (c BackupConfig) Validate() error {
 		if err := c.MongoDB.Validate(taskID); err != nil {
 			return err
 		}
+	case BackupTypeMySQL:
+		if c.MySQL == nil {
+			return fmt.Errorf("backup %s mysql config can not be empty", taskID)
+		}
+		if err := c.MySQL.Validate(taskID); err != nil {
+			return err
+		}
+	case BackupTypeRedis:
+		if c.Redis == nil {
+			return fmt.Errorf("backup %s redis config can not be empty", taskID)
+		}
+		if err := c.Redis.Validate(taskID); err != nil {
+			return err
+		}
+	case BackupTypeSQLite:
+		if c.SQLite == nil {
+			return fmt.Errorf("backup %s sqlite config can not be empty", taskID)
+		}
+		if err := c.SQLite.Validate(taskID); err != nil {
+			return err
+		}
 	case BackupTypeDockerVolume:
 		if c.DockerVolume == nil {
 			return fmt.Errorf("backup %s docker_volume config can not be empty", taskID)
@@ -192,6 +707,10 @@ func (c BackupConfig) Validate() error {
 		if err := c.DockerVolume.Validate(taskID); err != nil {
 			return err
 		}
+	case BackupTypeComposite:
+		if err := c.validateSources(); err != nil {
+			return err
+		}
 	default:
 		return fmt.Errorf("backup %s has unsupported type %q", taskID, c.Type)
 	}
@@ -199,60 +718,866 @@ func (c BackupConfig) Validate() error {
 	return nil
 }
 
-func (g GlobalConfig) FindBackupByID(id string) (BackupConfig, bool) {
-	targetID := strings.TrimSpace(id)
-	for _, conf := range g.BackupConf {
-		if conf.GetID() == targetID {
-			return conf, true
+func (c BackupConfig) validateDestinations() error {
+	taskID := c.GetID()
+	if strings.TrimSpace(c.Storage) != "" {
+		return fmt.Errorf("backup %s storage and destinations can not be used together", taskID)
+	}
+
+	seen := make(map[string]bool, len(c.Destinations))
+	for _, destination := range c.Destinations {
+		name := strings.TrimSpace(destination.Storage)
+		if name == "" {
+			return fmt.Errorf("backup %s destinations storage can not be empty", taskID)
+		}
+		if seen[name] {
+			return fmt.Errorf("backup %s destinations contain duplicate storage: %s", taskID, name)
+		}
+		seen[name] = true
+
+		if destination.Retention != nil {
+			if err := destination.Retention.Validate(fmt.Sprintf("%s destinations[%s]", taskID, name)); err != nil {
+				return err
+			}
 		}
 	}
-	return BackupConfig{}, false
-}
 
-func (g GlobalConfig) BackupIDs() []string {
-	ids := make([]string, 0, len(g.BackupConf))
-	for _, conf := range g.BackupConf {
-		ids = append(ids, conf.GetID())
+	// 流式和增量备份的数据只能读取一次，暂不支持同时上传到多个存储
+	if len(c.Destinations) > 1 {
+		if c.Streaming.IsEnabled() {
+			return fmt.Errorf("backup %s streaming only supports one destination", taskID)
+		}
+		if c.Incremental.IsEnabled() {
+			return fmt.Errorf("backup %s incremental only supports one destination", taskID)
+		}
 	}
-	return ids
+	return nil
 }
 
-func (c PostgresBackupConfig) Validate(taskID string) error {
-	if len(c.Databases) == 0 {
-		return fmt.Errorf("backup %s postgres.databases can not be empty", taskID)
-	}
-	mode := c.GetMode()
-	if mode != ExecModeLocal && mode != ExecModeDocker {
-		return fmt.Errorf("backup %s postgres.mode must be one of %q or %q", taskID, ExecModeLocal, ExecModeDocker)
-	}
-	if mode == ExecModeDocker && strings.TrimSpace(c.Container) == "" {
-		return fmt.Errorf("backup %s postgres.container can not be empty when mode is docker", taskID)
+func (c BackupConfig) validateSources() error {
+	taskID := c.GetID()
+	if len(c.Sources) == 0 {
+		return fmt.Errorf("backup %s sources can not be empty", taskID)
 	}
 
-	return nil
-}
+	names := make(map[string]bool, len(c.Sources))
+	for i, source := range c.Sources {
+		name := source.GetName()
+		if !sourceNamePattern.MatchString(name) || strings.Trim(name, ".") == "" {
+			return fmt.Errorf("backup %s sources[%d] name %q must only contain letters, digits, '.', '_' or '-'", taskID, i, name)
+		}
+		if names[name] {
+			return fmt.Errorf("backup %s sources name %q is duplicated", taskID, name)
+		}
+		names[name] = true
 
-func (c PostgresBackupConfig) GetMode() string {
-	mode := strings.ToLower(strings.TrimSpace(c.Mode))
-	if mode == "" {
-		return ExecModeLocal
+		if err := source.Validate(fmt.Sprintf("%s sources[%s]", taskID, name)); err != nil {
+			return err
+		}
 	}
-	return mode
+	return nil
 }
 
-func (c MongoBackupConfig) Validate(taskID string) error {
-	if len(c.Databases) == 0 {
-		return fmt.Errorf("backup %s mongodb.databases can not be empty", taskID)
+// sourceNamePattern 限制备份源名称，名称会作为备份中的目录名
+var sourceNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+func (c SourceConfig) GetType() string {
+	if normalized := strings.ToLower(strings.TrimSpace(c.Type)); normalized != "" {
+		return normalized
 	}
-	mode := c.GetMode()
-	if mode != ExecModeLocal && mode != ExecModeDocker {
-		return fmt.Errorf("backup %s mongodb.mode must be one of %q or %q", taskID, ExecModeLocal, ExecModeDocker)
+	if c.Postgres != nil {
+		return BackupTypePostgres
 	}
-	if mode == ExecModeDocker && strings.TrimSpace(c.Container) == "" {
-		return fmt.Errorf("backup %s mongodb.container can not be empty when mode is docker", taskID)
+	if c.MongoDB != nil {
+		return BackupTypeMongoDB
 	}
-	if strings.TrimSpace(c.URI) == "" && strings.TrimSpace(c.Username) != "" && c.Password == "" {
-		return fmt.Errorf("backup %s mongodb.password can not be empty when username is set", taskID)
+	if c.DockerVolume != nil {
+		return BackupTypeDockerVolume
+	}
+	return BackupTypePath
+}
+
+// GetName 返回备份源在备份中的目录名，未配置时 path 使用目录名，docker_volume 使用 volume 名，其他使用类型名。
+func (c SourceConfig) GetName() string {
+	if name := strings.TrimSpace(c.Name); name != "" {
+		return name
+	}
+	switch c.GetType() {
+	case BackupTypePath:
+		if path := strings.TrimSpace(c.Path); path != "" {
+			return filepath.Base(path)
+		}
+	case BackupTypeDockerVolume:
+		if c.DockerVolume != nil && strings.TrimSpace(c.DockerVolume.Volume) != "" {
+			return strings.TrimSpace(c.DockerVolume.Volume)
+		}
+	}
+	return c.GetType()
+}
+
+// BackupConfig 把备份源转换成单一备份源的任务配置，用于复用各类型的导出和恢复逻辑。
+func (c SourceConfig) BackupConfig(taskID string) BackupConfig {
+	return BackupConfig{
+		ID:           taskID,
+		Type:         c.GetType(),
+		BackupPath:   c.Path,
+		Include:      c.Include,
+		Exclude:      c.Exclude,
+		Postgres:     c.Postgres,
+		MongoDB:      c.MongoDB,
+		DockerVolume: c.DockerVolume,
+	}
+}
+
+// Validate 校验备份源，label 用于错误信息，例如 "app sources[db]"。
+func (c SourceConfig) Validate(label string) error {
+	switch c.GetType() {
+	case BackupTypePath, BackupTypePostgres, BackupTypeMongoDB, BackupTypeDockerVolume:
+	default:
+		return fmt.Errorf("backup %s type must be one of %q, %q, %q or %q", label, BackupTypePath, BackupTypePostgres, BackupTypeMongoDB, BackupTypeDockerVolume)
+	}
+	if c.GetType() != BackupTypePath && strings.TrimSpace(c.Path) != "" {
+		return fmt.Errorf("backup %s path can not be combined with another source", label)
+	}
+	if c.GetType() == BackupTypePath && strings.TrimSpace(c.Path) == "" {
+		return fmt.Errorf("backup %s path can not be empty", label)
+	}
+	return c.BackupConfig(label).Validate()
+}
+
+func (g GlobalConfig) FindBackupByID(id string) (BackupConfig, bool) {
+	targetID := strings.TrimSpace(id)
+	for _, conf := range g.BackupConf {
+		if conf.GetID() == targetID {
+			return conf, true
+		}
+	}
+	return BackupConfig{}, false
+}
+
+func (g GlobalConfig) BackupIDs() []string {
+	ids := make([]string, 0, len(g.BackupConf))
+	for _, conf := range g.BackupConf {
+		ids = append(ids, conf.GetID())
+	}
+	return ids
+}
+
+// GetRetention 返回任务的保留规则，未配置时保留最近 7 天的备份。
+func (c BackupConfig) GetRetention() RetentionConfig {
+	return retentionOrDefault(c.Retention)
+}
+
+// GetRetention 返回上传目标的保留规则，默认值与任务相同。
+func (c DestinationConfig) GetRetention() RetentionConfig {
+	return retentionOrDefault(c.Retention)
+}
+
+func retentionOrDefault(retention *RetentionConfig) RetentionConfig {
+	if retention == nil {
+		return RetentionConfig{KeepDays: 7}
+	}
+	return *retention
+}
+
+func (c RetentionConfig) Validate(taskID string) error {
+	values := []int{c.KeepLast, c.KeepDays, c.KeepDaily, c.KeepWeekly, c.KeepMonthly, c.KeepYearly}
+
+	hasRule := false
+	for _, value := range values {
+		if value < 0 {
+			return fmt.Errorf("backup %s retention values can not be negative", taskID)
+		}
+		if value > 0 {
+			hasRule = true
+		}
+	}
+	if !hasRule {
+		return fmt.Errorf("backup %s retention must enable at least one rule", taskID)
+	}
+
+	return nil
+}
+
+func (c EncryptionConfig) GetType() string {
+	encryptionType := strings.ToLower(strings.TrimSpace(c.Type))
+	if encryptionType == "" {
+		return EncryptionTypeAge
+	}
+	return encryptionType
+}
+
+func (c EncryptionConfig) Validate(taskID string) error {
+	switch c.GetType() {
+	case EncryptionTypeAge:
+		if c.Passphrase == "" && len(c.Recipients) == 0 {
+			return fmt.Errorf("backup %s encryption requires passphrase or recipients", taskID)
+		}
+		if c.Passphrase != "" && len(c.Recipients) > 0 {
+			return fmt.Errorf("backup %s encryption can not combine passphrase with recipients", taskID)
+		}
+	case EncryptionTypeAESGCM:
+		if c.Passphrase == "" {
+			return fmt.Errorf("backup %s encryption.passphrase can not be empty when type is %s", taskID, EncryptionTypeAESGCM)
+		}
+		if len(c.Recipients) > 0 {
+			return fmt.Errorf("backup %s encryption.recipients are only supported by %s", taskID, EncryptionTypeAge)
+		}
+	default:
+		return fmt.Errorf("backup %s encryption.type must be one of %q or %q", taskID, EncryptionTypeAge, EncryptionTypeAESGCM)
+	}
+
+	return nil
+}
+
+func (c *StreamingConfig) IsEnabled() bool {
+	return c != nil && c.Enabled
+}
+
+// GetPartSize 返回分片大小（字节），默认 16MB。
+func (c StreamingConfig) GetPartSize() int64 {
+	if c.PartSizeMB <= 0 {
+		return DefaultStreamPartSizeMB << 20
+	}
+	return int64(c.PartSizeMB) << 20
+}
+
+// GetPartRetries 返回单个分片的重试次数，默认 3 次。
+func (c StreamingConfig) GetPartRetries() int {
+	if c.PartRetries <= 0 {
+		return DefaultStreamPartRetries
+	}
+	return c.PartRetries
+}
+
+func (c StreamingConfig) Validate(taskID string) error {
+	if c.PartSizeMB < 0 || c.PartRetries < 0 {
+		return fmt.Errorf("backup %s streaming values can not be negative", taskID)
+	}
+	// S3 和 OSS 要求除最后一个分片外每个分片至少 5MB
+	if c.PartSizeMB > 0 && c.PartSizeMB < MinStreamPartSizeMB {
+		return fmt.Errorf("backup %s streaming.part_size_mb must be at least %d", taskID, MinStreamPartSizeMB)
+	}
+
+	return nil
+}
+
+func (c BackupConfig) GetArchive() ArchiveConfig {
+	if c.Archive == nil {
+		return ArchiveConfig{}
+	}
+	return *c.Archive
+}
+
+// GetFormat 返回备份文件格式，默认 zip。
+func (c ArchiveConfig) GetFormat() string {
+	if strings.TrimSpace(c.Format) == "" {
+		return utils.ArchiveFormatZip
+	}
+	return strings.ToLower(strings.TrimSpace(c.Format))
+}
+
+// maxLevel 返回格式支持的最高压缩级别，zstd 为 22，zip 和 gzip 为 9。
+func (c ArchiveConfig) maxLevel() int {
+	if c.GetFormat() == utils.ArchiveFormatTarZst {
+		return 22
+	}
+	return 9
+}
+
+func (c ArchiveConfig) Validate(taskID string) error {
+	switch c.GetFormat() {
+	case utils.ArchiveFormatZip, utils.ArchiveFormatTarGz, utils.ArchiveFormatTarZst:
+	default:
+		return fmt.Errorf("backup %s archive.format must be one of %q, %q or %q", taskID, utils.ArchiveFormatZip, utils.ArchiveFormatTarGz, utils.ArchiveFormatTarZst)
+	}
+
+	// level 为 0 时使用格式的默认级别
+	if c.Level < 0 || c.Level > c.maxLevel() {
+		return fmt.Errorf("backup %s archive.level must be 0 (default) or 1..%d for %s", taskID, c.maxLevel(), c.GetFormat())
+	}
+	return nil
+}
+
+func (c *IncrementalConfig) IsEnabled() bool {
+	return c != nil && c.Enabled
+}
+
+// GetPackSize 返回单个数据包的目标大小（字节），默认 64MB。
+func (c IncrementalConfig) GetPackSize() int64 {
+	if c.PackSizeMB <= 0 {
+		return DefaultPackSizeMB << 20
+	}
+	return int64(c.PackSizeMB) << 20
+}
+
+func (c IncrementalConfig) Validate(taskID string) error {
+	if c.PackSizeMB < 0 {
+		return fmt.Errorf("backup %s incremental.pack_size_mb can not be negative", taskID)
+	}
+	return nil
+}
+
+func (c *VerifyConfig) IsEnabled() bool {
+	return c != nil && c.Enabled
+}
+
+// GetMode 返回校验方式，默认 full：下载并解压备份，数据库备份再用对应的恢复工具检查。
+func (c VerifyConfig) GetMode() string {
+	if strings.TrimSpace(c.Mode) == "" {
+		return VerifyModeFull
+	}
+	return strings.ToLower(strings.TrimSpace(c.Mode))
+}
+
+func (c VerifyConfig) Validate(taskID string) error {
+	switch c.GetMode() {
+	case VerifyModeFull, VerifyModeQuick:
+		return nil
+	default:
+		return fmt.Errorf("backup %s verify.mode must be one of %q or %q", taskID, VerifyModeFull, VerifyModeQuick)
+	}
+}
+
+func (c BackupConfig) GetTimeout() TimeoutConfig {
+	if c.Timeout == nil {
+		return TimeoutConfig{}
+	}
+	return *c.Timeout
+}
+
+// GetCommand 返回前置/后置命令的超时时间，0 表示不限制，下同。
+func (c TimeoutConfig) GetCommand() time.Duration {
+	return durationOrZero(c.Command)
+}
+
+func (c TimeoutConfig) GetExport() time.Duration {
+	return durationOrZero(c.Export)
+}
+
+func (c TimeoutConfig) GetCompress() time.Duration {
+	return durationOrZero(c.Compress)
+}
+
+func (c TimeoutConfig) GetUpload() time.Duration {
+	return durationOrZero(c.Upload)
+}
+
+func (c TimeoutConfig) Validate(taskID string) error {
+	fields := []struct {
+		name  string
+		value string
+	}{
+		{"command", c.Command},
+		{"export", c.Export},
+		{"compress", c.Compress},
+		{"upload", c.Upload},
+	}
+	for _, field := range fields {
+		if err := validateDuration(fmt.Sprintf("backup %s timeout.%s", taskID, field.name), field.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c BackupConfig) GetRetry() RetryConfig {
+	if c.Retry == nil {
+		return RetryConfig{}
+	}
+	return *c.Retry
+}
+
+// GetAttempts 返回每个阶段最多尝试的次数，默认 1 次，即不重试。
+func (c RetryConfig) GetAttempts() int {
+	if c.Attempts <= 0 {
+		return 1
+	}
+	return c.Attempts
+}
+
+// GetBackoff 返回第一次重试前的等待时间，默认 30 秒。
+func (c RetryConfig) GetBackoff() time.Duration {
+	if strings.TrimSpace(c.Backoff) == "" {
+		return DefaultRetryBackoff
+	}
+	return durationOrZero(c.Backoff)
+}
+
+func (c RetryConfig) Validate(taskID string) error {
+	if c.Attempts < 0 {
+		return fmt.Errorf("backup %s retry.attempts can not be negative", taskID)
+	}
+	return validateDuration(fmt.Sprintf("backup %s retry.backoff", taskID), c.Backoff)
+}
+
+// FindStorage 按名称查找存储配置，DefaultStorageName 对应顶层 oss 节点。
+func (g GlobalConfig) FindStorage(name string) (StorageConfig, bool) {
+	targetName := strings.TrimSpace(name)
+	if targetName == DefaultStorageName {
+		if g.OSS.IsEmpty() {
+			return StorageConfig{}, false
+		}
+		oss := g.OSS
+		return StorageConfig{Name: DefaultStorageName, Type: StorageTypeOSS, OSS: &oss}, true
+	}
+
+	for _, storage := range g.Storages {
+		if storage.GetName() == targetName {
+			return storage, true
+		}
+	}
+	return StorageConfig{}, false
+}
+
+// GetOn 返回发送条件，默认 always。
+func (c TaskNoticeConfig) GetOn() string {
+	return normalizeNoticeOn(c.On)
+}
+
+func (c NoticeChannel) GetOn() string {
+	return normalizeNoticeOn(c.On)
+}
+
+// GetName 返回渠道名称，未填写时使用渠道类型。
+func (c NoticeChannel) GetName(channelType string) string {
+	if name := strings.TrimSpace(c.Name); name != "" {
+		return name
+	}
+	return channelType
+}
+
+func normalizeNoticeOn(on string) string {
+	on = strings.ToLower(strings.TrimSpace(on))
+	if on == "" {
+		return NoticeOnAlways
+	}
+	return on
+}
+
+func isNoticeOn(on string) bool {
+	return on == NoticeOnAlways || on == NoticeOnFailure || on == NoticeOnSuccess
+}
+
+// GetServer 返回 ntfy 服务地址，默认使用公共服务 ntfy.sh。
+func (c NtfyConfig) GetServer() string {
+	if server := strings.TrimSpace(c.Server); server != "" {
+		return strings.TrimRight(server, "/")
+	}
+	return DefaultNtfyServer
+}
+
+// ChannelNames 返回全部已配置通知渠道的名称。
+func (c NoticeConfig) ChannelNames() map[string]bool {
+	names := make(map[string]bool)
+	c.eachChannel(func(name string, _ NoticeChannel, _ string) {
+		names[name] = true
+	})
+	return names
+}
+
+// eachChannel 依次访问每个通知渠道，fn 的参数为渠道名称、公共字段和渠道类型。
+func (c NoticeConfig) eachChannel(fn func(name string, channel NoticeChannel, channelType string)) {
+	if c.Mail != nil {
+		fn(c.Mail.GetName(NoticeChannelMail), c.Mail.NoticeChannel, NoticeChannelMail)
+	}
+	if c.Telegram != nil {
+		fn(c.Telegram.GetName(NoticeChannelTelegram), c.Telegram.NoticeChannel, NoticeChannelTelegram)
+	}
+	for _, webhook := range c.Webhooks {
+		fn(webhook.GetName(NoticeChannelWebhook), webhook.NoticeChannel, NoticeChannelWebhook)
+	}
+	for _, slack := range c.Slack {
+		fn(slack.GetName(NoticeChannelSlack), slack.NoticeChannel, NoticeChannelSlack)
+	}
+	for _, discord := range c.Discord {
+		fn(discord.GetName(NoticeChannelDiscord), discord.NoticeChannel, NoticeChannelDiscord)
+	}
+	for _, ntfy := range c.Ntfy {
+		fn(ntfy.GetName(NoticeChannelNtfy), ntfy.NoticeChannel, NoticeChannelNtfy)
+	}
+	for _, gotify := range c.Gotify {
+		fn(gotify.GetName(NoticeChannelGotify), gotify.NoticeChannel, NoticeChannelGotify)
+	}
+}
+
+func (c NoticeConfig) Validate() error {
+	var err error
+	seen := make(map[string]bool)
+	c.eachChannel(func(name string, channel NoticeChannel, channelType string) {
+		if err != nil {
+			return
+		}
+		if seen[name] {
+			err = fmt.Errorf("duplicate notice channel name: %s", name)
+			return
+		}
+		seen[name] = true
+		if !isNoticeOn(channel.GetOn()) {
+			err = fmt.Errorf("notice %s on must be one of %q, %q or %q", name, NoticeOnAlways, NoticeOnFailure, NoticeOnSuccess)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, webhook := range c.Webhooks {
+		if strings.TrimSpace(webhook.URL) == "" {
+			return fmt.Errorf("notice %s url can not be empty", webhook.GetName(NoticeChannelWebhook))
+		}
+	}
+	for _, slack := range c.Slack {
+		if strings.TrimSpace(slack.WebhookURL) == "" {
+			return fmt.Errorf("notice %s webhook_url can not be empty", slack.GetName(NoticeChannelSlack))
+		}
+	}
+	for _, discord := range c.Discord {
+		if strings.TrimSpace(discord.WebhookURL) == "" {
+			return fmt.Errorf("notice %s webhook_url can not be empty", discord.GetName(NoticeChannelDiscord))
+		}
+	}
+	for _, ntfy := range c.Ntfy {
+		if strings.TrimSpace(ntfy.Topic) == "" {
+			return fmt.Errorf("notice %s topic can not be empty", ntfy.GetName(NoticeChannelNtfy))
+		}
+	}
+	for _, gotify := range c.Gotify {
+		if strings.TrimSpace(gotify.Server) == "" || strings.TrimSpace(gotify.Token) == "" {
+			return fmt.Errorf("notice %s server and token can not be empty", gotify.GetName(NoticeChannelGotify))
+		}
+	}
+
+	return nil
+}
+
+func (c HTTPConfig) Validate() error {
+	if strings.TrimSpace(c.Listen) == "" {
+		return errors.New("http.listen can not be empty")
+	}
+	if _, _, err := net.SplitHostPort(strings.TrimSpace(c.Listen)); err != nil {
+		return fmt.Errorf("http.listen is invalid: %w", err)
+	}
+	return nil
+}
+
+func (c HistoryConfig) Validate() error {
+	if c.Keep < 0 {
+		return errors.New("history.keep can not be negative")
+	}
+	return nil
+}
+
+// GetShutdownTimeout 返回停止调度器时等待任务结束的时间，未配置时为 DefaultShutdownTimeout。
+// 配置为 0 时不等待，立即取消正在运行的任务。
+func (c GlobalConfig) GetShutdownTimeout() time.Duration {
+	if strings.TrimSpace(c.ShutdownTimeout) == "" {
+		return DefaultShutdownTimeout
+	}
+	return durationOrZero(c.ShutdownTimeout)
+}
+
+// validateDependencies 检查 depends_on 引用的任务存在，并且依赖之间没有环。
+func validateDependencies(backups []BackupConfig) error {
+	dependsOn := make(map[string][]string, len(backups))
+	for _, backup := range backups {
+		dependsOn[backup.GetID()] = backup.GetDependsOn()
+	}
+
+	for _, backup := range backups {
+		id := backup.GetID()
+		for _, dep := range dependsOn[id] {
+			if dep == id {
+				return fmt.Errorf("backup %s can not depend on itself", id)
+			}
+			if _, ok := dependsOn[dep]; !ok {
+				return fmt.Errorf("backup %s depends_on references unknown backup: %s", id, dep)
+			}
+		}
+	}
+
+	// 0 未访问，1 访问中，2 已完成
+	visited := make(map[string]int, len(backups))
+	var visit func(id string) error
+	visit = func(id string) error {
+		switch visited[id] {
+		case 1:
+			return fmt.Errorf("backup %s depends_on forms a cycle", id)
+		case 2:
+			return nil
+		}
+		visited[id] = 1
+		for _, dep := range dependsOn[id] {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		visited[id] = 2
+		return nil
+	}
+	for _, backup := range backups {
+		if err := visit(backup.GetID()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// durationOrZero 解析已经校验过的时长配置，空值返回 0。
+func durationOrZero(value string) time.Duration {
+	duration, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		return 0
+	}
+	return duration
+}
+
+func validateDuration(field string, value string) error {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	duration, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		return fmt.Errorf("%s is invalid: %w", field, err)
+	}
+	if duration < 0 {
+		return fmt.Errorf("%s can not be negative", field)
+	}
+	return nil
+}
+
+// GetHistoryKeep 返回每个任务保留的历史记录条数，未配置时为 DefaultHistoryKeep。
+func (c GlobalConfig) GetHistoryKeep() int {
+	if c.History == nil || c.History.Keep <= 0 {
+		return DefaultHistoryKeep
+	}
+	return c.History.Keep
+}
+
+func (c StorageConfig) GetName() string {
+	return strings.TrimSpace(c.Name)
+}
+
+func (c StorageConfig) GetType() string {
+	return strings.ToLower(strings.TrimSpace(c.Type))
+}
+
+func (c StorageConfig) Validate() error {
+	name := c.GetName()
+	if name == "" {
+		return errors.New("storages.name can not be empty")
+	}
+	if name == DefaultStorageName {
+		return fmt.Errorf("storage name %q is reserved for the top-level oss config", DefaultStorageName)
+	}
+
+	switch c.GetType() {
+	case StorageTypeOSS:
+		if c.OSS == nil {
+			return fmt.Errorf("storage %s oss config can not be empty", name)
+		}
+		if err := c.OSS.Validate(); err != nil {
+			return fmt.Errorf("storage %s: %w", name, err)
+		}
+	case StorageTypeS3:
+		if c.S3 == nil {
+			return fmt.Errorf("storage %s s3 config can not be empty", name)
+		}
+		if err := c.S3.Validate(name); err != nil {
+			return err
+		}
+	case StorageTypeLocal:
+		if c.Local == nil || strings.TrimSpace(c.Local.Path) == "" {
+			return fmt.Errorf("storage %s local.path can not be empty", name)
+		}
+	case StorageTypeSFTP:
+		if c.SFTP == nil {
+			return fmt.Errorf("storage %s sftp config can not be empty", name)
+		}
+		if err := c.SFTP.Validate(name); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("storage %s has unsupported type %q", name, c.Type)
+	}
+
+	return nil
+}
+
+func (c S3Config) Validate(name string) error {
+	if strings.TrimSpace(c.Endpoint) == "" {
+		return fmt.Errorf("storage %s s3.endpoint can not be empty", name)
+	}
+	if strings.TrimSpace(c.BucketName) == "" {
+		return fmt.Errorf("storage %s s3.bucket_name can not be empty", name)
+	}
+	if strings.TrimSpace(c.AccessKey) == "" {
+		return fmt.Errorf("storage %s s3.access_key can not be empty", name)
+	}
+	if strings.TrimSpace(c.AccessKeySecret) == "" {
+		return fmt.Errorf("storage %s s3.access_key_secret can not be empty", name)
+	}
+
+	return nil
+}
+
+func (c SFTPConfig) Validate(name string) error {
+	if strings.TrimSpace(c.Host) == "" {
+		return fmt.Errorf("storage %s sftp.host can not be empty", name)
+	}
+	if strings.TrimSpace(c.User) == "" {
+		return fmt.Errorf("storage %s sftp.user can not be empty", name)
+	}
+	if c.Password == "" && strings.TrimSpace(c.PrivateKey) == "" {
+		return fmt.Errorf("storage %s sftp requires password or private_key", name)
+	}
+	if strings.TrimSpace(c.Path) == "" {
+		return fmt.Errorf("storage %s sftp.path can not be empty", name)
+	}
+	if c.InsecureIgnoreHostKey && strings.TrimSpace(c.KnownHostsFile) != "" {
+		return fmt.Errorf("storage %s sftp.known_hosts can not be combined with insecure_ignore_host_key", name)
+	}
+
+	return nil
+}
+
+// GetKnownHostsFile 返回校验主机密钥使用的 known_hosts 文件，默认 ~/.ssh/known_hosts。
+func (c SFTPConfig) GetKnownHostsFile() string {
+	if knownHostsFile := strings.TrimSpace(c.KnownHostsFile); knownHostsFile != "" {
+		return knownHostsFile
+	}
+	return "~/.ssh/known_hosts"
+}
+
+func (c SFTPConfig) GetPort() int {
+	if c.Port <= 0 {
+		return 22
+	}
+	return c.Port
+}
+
+func (c PostgresBackupConfig) Validate(taskID string) error {
+	if len(c.Databases) == 0 {
+		return fmt.Errorf("backup %s postgres.databases can not be empty", taskID)
+	}
+	mode := c.GetMode()
+	if mode != ExecModeLocal && mode != ExecModeDocker {
+		return fmt.Errorf("backup %s postgres.mode must be one of %q or %q", taskID, ExecModeLocal, ExecModeDocker)
+	}
+	if mode == ExecModeDocker && strings.TrimSpace(c.Container) == "" {
+		return fmt.Errorf("backup %s postgres.container can not be empty when mode is docker", taskID)
+	}
+
+	return nil
+}
+
+func (c PostgresBackupConfig) GetMode() string {
+	mode := strings.ToLower(strings.TrimSpace(c.Mode))
+	if mode == "" {
+		return ExecModeLocal
+	}
+	return mode
+}
+
+func (c MySQLBackupConfig) Validate(taskID string) error {
+	if len(c.Databases) == 0 {
+		return fmt.Errorf("backup %s mysql.databases can not be empty", taskID)
+	}
+	mode := c.GetMode()
+	if mode != ExecModeLocal && mode != ExecModeDocker {
+		return fmt.Errorf("backup %s mysql.mode must be one of %q or %q", taskID, ExecModeLocal, ExecModeDocker)
+	}
+	if mode == ExecModeDocker && strings.TrimSpace(c.Container) == "" {
+		return fmt.Errorf("backup %s mysql.container can not be empty when mode is docker", taskID)
+	}
+	executable := c.GetExecutable()
+	if executable != MySQLDumpExecutable && executable != MariaDBDumpExecutable {
+		return fmt.Errorf("backup %s mysql.executable must be one of %q or %q", taskID, MySQLDumpExecutable, MariaDBDumpExecutable)
+	}
+
+	return nil
+}
+
+func (c MySQLBackupConfig) GetMode() string {
+	mode := strings.ToLower(strings.TrimSpace(c.Mode))
+	if mode == "" {
+		return ExecModeLocal
+	}
+	return mode
+}
+
+// GetExecutable 返回导出命令，默认 mysqldump，MariaDB 11 之后的镜像只提供 mariadb-dump。
+func (c MySQLBackupConfig) GetExecutable() string {
+	executable := strings.TrimSpace(c.Executable)
+	if executable == "" {
+		return MySQLDumpExecutable
+	}
+	return executable
+}
+
+// GetClientExecutable 返回恢复时使用的客户端命令，与导出命令配套。
+func (c MySQLBackupConfig) GetClientExecutable() string {
+	if c.GetExecutable() == MariaDBDumpExecutable {
+		return "mariadb"
+	}
+	return "mysql"
+}
+
+func (c RedisBackupConfig) Validate(taskID string) error {
+	mode := c.GetMode()
+	if mode != ExecModeLocal && mode != ExecModeDocker {
+		return fmt.Errorf("backup %s redis.mode must be one of %q or %q", taskID, ExecModeLocal, ExecModeDocker)
+	}
+	if mode == ExecModeDocker && strings.TrimSpace(c.Container) == "" {
+		return fmt.Errorf("backup %s redis.container can not be empty when mode is docker", taskID)
+	}
+
+	return nil
+}
+
+func (c RedisBackupConfig) GetMode() string {
+	mode := strings.ToLower(strings.TrimSpace(c.Mode))
+	if mode == "" {
+		return ExecModeLocal
+	}
+	return mode
+}
+
+func (c SQLiteBackupConfig) Validate(taskID string) error {
+	if len(c.Databases) == 0 {
+		return fmt.Errorf("backup %s sqlite.databases can not be empty", taskID)
+	}
+	mode := c.GetMode()
+	if mode != ExecModeLocal && mode != ExecModeDocker {
+		return fmt.Errorf("backup %s sqlite.mode must be one of %q or %q", taskID, ExecModeLocal, ExecModeDocker)
+	}
+	if mode == ExecModeDocker && strings.TrimSpace(c.Container) == "" {
+		return fmt.Errorf("backup %s sqlite.container can not be empty when mode is docker", taskID)
+	}
+
+	return nil
+}
+
+func (c SQLiteBackupConfig) GetMode() string {
+	mode := strings.ToLower(strings.TrimSpace(c.Mode))
+	if mode == "" {
+		return ExecModeLocal
+	}
+	return mode
+}
+
+func (c MongoBackupConfig) Validate(taskID string) error {
+	if len(c.Databases) == 0 {
+		return fmt.Errorf("backup %s mongodb.databases can not be empty", taskID)
+	}
+	mode := c.GetMode()
+	if mode != ExecModeLocal && mode != ExecModeDocker {
+		return fmt.Errorf("backup %s mongodb.mode must be one of %q or %q", taskID, ExecModeLocal, ExecModeDocker)
+	}
+	if mode == ExecModeDocker && strings.TrimSpace(c.Container) == "" {
+		return fmt.Errorf("backup %s mongodb.container can not be empty when mode is docker", taskID)
+	}
+	if strings.TrimSpace(c.URI) == "" && strings.TrimSpace(c.Username) != "" && c.Password == "" {
+		return fmt.Errorf("backup %s mongodb.password can not be empty when username is set", taskID)
 	}
 
 	return nil
@@ -284,20 +1609,25 @@ func (c DockerVolumeBackupConfig) GetImage() string {
 }
 
 func InitConfig() {
+	config, err := LoadConfig()
+	if err != nil {
+		panic(err)
+	}
+
+	Config = config
+}
+
+// LoadConfig 读取并校验当前目录下的 config.yml（或 config.yaml），不修改 Config。
+func LoadConfig() (GlobalConfig, error) {
 	configBlob, err := os.ReadFile("config.yml")
 	if err != nil {
 		configBlob, err = os.ReadFile("config.yaml")
 		if err != nil {
-			panic(err)
+			return GlobalConfig{}, err
 		}
 	}
 
-	config, err := ParseConfig(configBlob)
-	if err != nil {
-		panic(err)
-	}
-
-	Config = config
+	return ParseConfig(configBlob)
 }
 
 func ParseConfig(configBlob []byte) (GlobalConfig, error) {
@@ -306,11 +1636,46 @@ func ParseConfig(configBlob []byte) (GlobalConfig, error) {
 		return GlobalConfig{}, err
 	}
 
+	if err := config.resolveSecrets(); err != nil {
+		return GlobalConfig{}, err
+	}
+
 	if len(config.BackupConf) <= 0 {
 		return GlobalConfig{}, errors.New("config can not be empty")
 	}
-	if err := config.OSS.Validate(); err != nil {
-		return GlobalConfig{}, err
+
+	seenStorages := make(map[string]struct{}, len(config.Storages))
+	for _, storage := range config.Storages {
+		if err := storage.Validate(); err != nil {
+			return GlobalConfig{}, err
+		}
+
+		name := storage.GetName()
+		if _, exists := seenStorages[name]; exists {
+			return GlobalConfig{}, fmt.Errorf("duplicate storage name: %s", name)
+		}
+		seenStorages[name] = struct{}{}
+	}
+
+	var channels map[string]bool
+	if config.Notice != nil {
+		if err := config.Notice.Validate(); err != nil {
+			return GlobalConfig{}, err
+		}
+		channels = config.Notice.ChannelNames()
+	}
+
+	if config.KeyTemplate != "" {
+		if _, err := utils.NewFileNameProcessor(config.KeyTemplate, "id", "host"); err != nil {
+			return GlobalConfig{}, fmt.Errorf("key_template is invalid: %w", err)
+		}
+		// 增量备份的快照按日期命名，不使用全局模板
+		for i := range config.BackupConf {
+			backup := &config.BackupConf[i]
+			if backup.KeyTemplate == "" && !backup.Incremental.IsEnabled() {
+				backup.KeyTemplate = config.KeyTemplate
+			}
+		}
 	}
 
 	seenIDs := make(map[string]struct{}, len(config.BackupConf))
@@ -318,12 +1683,61 @@ func ParseConfig(configBlob []byte) (GlobalConfig, error) {
 		if err := v.Validate(); err != nil {
 			return GlobalConfig{}, err
 		}
+		if v.Notice != nil {
+			for _, channel := range v.Notice.Channels {
+				if !channels[strings.TrimSpace(channel)] {
+					return GlobalConfig{}, fmt.Errorf("backup %s references unknown notice channel: %s", v.GetID(), channel)
+				}
+			}
+		}
 
 		id := v.GetID()
 		if _, exists := seenIDs[id]; exists {
 			return GlobalConfig{}, fmt.Errorf("duplicate backup id: %s", id)
 		}
 		seenIDs[id] = struct{}{}
+
+		for _, storageName := range v.GetStorageNames() {
+			if storageName == DefaultStorageName {
+				if err := config.OSS.Validate(); err != nil {
+					return GlobalConfig{}, fmt.Errorf("backup %s uses the top-level oss storage: %w", id, err)
+				}
+				continue
+			}
+			if _, exists := seenStorages[storageName]; !exists {
+				return GlobalConfig{}, fmt.Errorf("backup %s references unknown storage: %s", id, storageName)
+			}
+		}
+	}
+
+	if err := validateDependencies(config.BackupConf); err != nil {
+		return GlobalConfig{}, err
+	}
+
+	if config.MaxConcurrent < 0 {
+		return GlobalConfig{}, errors.New("max_concurrent can not be negative")
+	}
+
+	if !config.OSS.IsEmpty() {
+		if err := config.OSS.Validate(); err != nil {
+			return GlobalConfig{}, err
+		}
+	}
+
+	if config.HTTP != nil {
+		if err := config.HTTP.Validate(); err != nil {
+			return GlobalConfig{}, err
+		}
+	}
+
+	if config.History != nil {
+		if err := config.History.Validate(); err != nil {
+			return GlobalConfig{}, err
+		}
+	}
+
+	if err := validateDuration("shutdown_timeout", config.ShutdownTimeout); err != nil {
+		return GlobalConfig{}, err
 	}
 
 	return config, nil
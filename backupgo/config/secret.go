@@ -0,0 +1,207 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// envRefPattern 匹配 ${ENV_VAR}；不支持 $ENV_VAR 写法，避免误伤密码中的 $ 字符
+var envRefPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// resolveSecret 展开凭据字段中的 ${ENV_VAR}，或在配置了对应的 *_file 字段时从文件读取凭据。
+// field 是字段在配置中的位置，例如 "backup pg postgres.password"，用于错误信息。
+func resolveSecret(field string, value *string, file string) error {
+	if strings.TrimSpace(file) == "" {
+		expanded, err := expandEnv(field, *value)
+		if err != nil {
+			return err
+		}
+		*value = expanded
+		return nil
+	}
+
+	if strings.TrimSpace(*value) != "" {
+		return fmt.Errorf("%s_file can not be used together with an inline value", field)
+	}
+
+	path, err := expandEnv(field+"_file", strings.TrimSpace(file))
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("%s_file read failed: %w", field, err)
+	}
+
+	// secret 文件通常以换行结尾，凭据本身不应包含结尾换行
+	*value = strings.TrimRight(string(data), "\r\n")
+	return nil
+}
+
+func expandEnv(field string, value string) (string, error) {
+	var missing string
+	expanded := envRefPattern.ReplaceAllStringFunc(value, func(ref string) string {
+		name := envRefPattern.FindStringSubmatch(ref)[1]
+		envValue, ok := os.LookupEnv(name)
+		if !ok && missing == "" {
+			missing = name
+		}
+		return envValue
+	})
+	if missing != "" {
+		return "", fmt.Errorf("%s references undefined environment variable %s", field, missing)
+	}
+
+	return expanded, nil
+}
+
+// resolveSecrets 在校验配置之前解析全部凭据字段。
+func (c *GlobalConfig) resolveSecrets() error {
+	if err := c.OSS.resolveSecrets("oss"); err != nil {
+		return err
+	}
+
+	for i := range c.Storages {
+		if err := c.Storages[i].resolveSecrets(); err != nil {
+			return err
+		}
+	}
+
+	if c.Notice != nil {
+		if err := c.Notice.resolveSecrets(); err != nil {
+			return err
+		}
+	}
+
+	for i := range c.BackupConf {
+		if err := c.BackupConf[i].resolveSecrets(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *OssConfig) resolveSecrets(prefix string) error {
+	if err := resolveSecret(prefix+".access_key", &c.AccessKey, c.AccessKeyFile); err != nil {
+		return err
+	}
+	return resolveSecret(prefix+".access_key_secret", &c.AccessKeySecret, c.AccessKeySecretFile)
+}
+
+func (c *StorageConfig) resolveSecrets() error {
+	prefix := "storage " + c.GetName()
+	if c.OSS != nil {
+		if err := c.OSS.resolveSecrets(prefix + " oss"); err != nil {
+			return err
+		}
+	}
+	if c.S3 != nil {
+		if err := resolveSecret(prefix+" s3.access_key", &c.S3.AccessKey, c.S3.AccessKeyFile); err != nil {
+			return err
+		}
+		if err := resolveSecret(prefix+" s3.access_key_secret", &c.S3.AccessKeySecret, c.S3.AccessKeySecretFile); err != nil {
+			return err
+		}
+	}
+	if c.SFTP != nil {
+		if err := resolveSecret(prefix+" sftp.password", &c.SFTP.Password, c.SFTP.PasswordFile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *NoticeConfig) resolveSecrets() error {
+	if c.Mail != nil {
+		if err := resolveSecret("notice mail password", &c.Mail.Password, c.Mail.PasswordFile); err != nil {
+			return err
+		}
+	}
+	if c.Telegram != nil {
+		if err := resolveSecret("notice telegram bot_token", &c.Telegram.BotToken, c.Telegram.BotTokenFile); err != nil {
+			return err
+		}
+	}
+	for i := range c.Webhooks {
+		webhook := &c.Webhooks[i]
+		prefix := "notice " + webhook.GetName(NoticeChannelWebhook)
+		if err := resolveSecret(prefix+" url", &webhook.URL, ""); err != nil {
+			return err
+		}
+		// headers 没有 *_file 写法，值中的 ${ENV_VAR} 照常展开，例如 Authorization: 'Bearer ${TOKEN}'
+		for name, value := range webhook.Headers {
+			if err := resolveSecret(prefix+" headers."+name, &value, ""); err != nil {
+				return err
+			}
+			webhook.Headers[name] = value
+		}
+	}
+	for i := range c.Slack {
+		slack := &c.Slack[i]
+		if err := resolveSecret("notice "+slack.GetName(NoticeChannelSlack)+" webhook_url", &slack.WebhookURL, slack.WebhookURLFile); err != nil {
+			return err
+		}
+	}
+	for i := range c.Discord {
+		discord := &c.Discord[i]
+		if err := resolveSecret("notice "+discord.GetName(NoticeChannelDiscord)+" webhook_url", &discord.WebhookURL, discord.WebhookURLFile); err != nil {
+			return err
+		}
+	}
+	for i := range c.Ntfy {
+		ntfy := &c.Ntfy[i]
+		if err := resolveSecret("notice "+ntfy.GetName(NoticeChannelNtfy)+" token", &ntfy.Token, ntfy.TokenFile); err != nil {
+			return err
+		}
+	}
+	for i := range c.Gotify {
+		gotify := &c.Gotify[i]
+		if err := resolveSecret("notice "+gotify.GetName(NoticeChannelGotify)+" token", &gotify.Token, gotify.TokenFile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *BackupConfig) resolveSecrets() error {
+	prefix := "backup " + c.GetID()
+	if c.Encryption != nil {
+		if err := resolveSecret(prefix+" encryption.passphrase", &c.Encryption.Passphrase, c.Encryption.PassphraseFile); err != nil {
+			return err
+		}
+	}
+	if c.Postgres != nil {
+		if err := resolveSecret(prefix+" postgres.password", &c.Postgres.Password, c.Postgres.PasswordFile); err != nil {
+			return err
+		}
+	}
+	if c.MongoDB != nil {
+		if err := resolveSecret(prefix+" mongodb.uri", &c.MongoDB.URI, c.MongoDB.URIFile); err != nil {
+			return err
+		}
+		if err := resolveSecret(prefix+" mongodb.password", &c.MongoDB.Password, c.MongoDB.PasswordFile); err != nil {
+			return err
+		}
+	}
+	if c.MySQL != nil {
+		if err := resolveSecret(prefix+" mysql.password", &c.MySQL.Password, c.MySQL.PasswordFile); err != nil {
+			return err
+		}
+	}
+	if c.Redis != nil {
+		if err := resolveSecret(prefix+" redis.password", &c.Redis.Password, c.Redis.PasswordFile); err != nil {
+			return err
+		}
+	}
+	for _, source := range c.Sources {
+		// 转换后的配置与 source 共用各备份源的配置指针，解析结果会写回 source
+		sourceConf := source.BackupConfig(fmt.Sprintf("%s sources[%s]", c.GetID(), source.GetName()))
+		if err := sourceConf.resolveSecrets(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
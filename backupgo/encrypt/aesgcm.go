@@ -0,0 +1,201 @@
+package encrypt
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// AES-GCM 文件格式：
+//
+//	magic(8) | salt(16) | chunk...
+//
+// 密钥由 passphrase 经 scrypt 派生，每个文件使用随机 salt，因此可以用分块序号作为 nonce。
+// 明文按 64 KiB 分块加密，nonce 为 11 字节大端序号加 1 字节结束标记，
+// 最后一个分块的结束标记为 1，用于发现密文被截断。
+const (
+	aesGCMMagic     = "BKGOAES1"
+	aesGCMSaltSize  = 16
+	aesGCMChunkSize = 64 * 1024
+	aesGCMNonceSize = 12
+)
+
+type aesGCMWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	buf     []byte
+	counter uint64
+	closed  bool
+}
+
+func newAESGCMWriter(passphrase string, w io.Writer) (*aesGCMWriter, error) {
+	salt := make([]byte, aesGCMSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("generate salt failed: %w", err)
+	}
+
+	aead, err := newAESGCM(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(append([]byte(aesGCMMagic), salt...)); err != nil {
+		return nil, fmt.Errorf("write header failed: %w", err)
+	}
+
+	return &aesGCMWriter{
+		w:    w,
+		aead: aead,
+		buf:  make([]byte, 0, aesGCMChunkSize),
+	}, nil
+}
+
+func (e *aesGCMWriter) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, errors.New("write to closed encryptor")
+	}
+
+	written := 0
+	for len(p) > 0 {
+		// 缓冲区满了且还有后续数据时才写出，保证最后一个分块一定在 Close 中写出
+		if len(e.buf) == aesGCMChunkSize {
+			if err := e.flush(false); err != nil {
+				return written, err
+			}
+		}
+
+		n := copy(e.buf[len(e.buf):aesGCMChunkSize], p)
+		e.buf = e.buf[:len(e.buf)+n]
+		p = p[n:]
+		written += n
+	}
+
+	return written, nil
+}
+
+func (e *aesGCMWriter) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+
+	return e.flush(true)
+}
+
+func (e *aesGCMWriter) flush(last bool) error {
+	sealed := e.aead.Seal(nil, chunkNonce(e.counter, last), e.buf, nil)
+	if _, err := e.w.Write(sealed); err != nil {
+		return err
+	}
+
+	e.counter++
+	e.buf = e.buf[:0]
+	return nil
+}
+
+type aesGCMReader struct {
+	r       *bufio.Reader
+	aead    cipher.AEAD
+	buf     []byte
+	plain   []byte
+	counter uint64
+	done    bool
+}
+
+func newAESGCMReader(passphrase string, r io.Reader) (*aesGCMReader, error) {
+	header := make([]byte, len(aesGCMMagic)+aesGCMSaltSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("read header failed: %w", err)
+	}
+	if !bytes.Equal(header[:len(aesGCMMagic)], []byte(aesGCMMagic)) {
+		return nil, errors.New("not an aes-gcm encrypted backup")
+	}
+
+	aead, err := newAESGCM(passphrase, header[len(aesGCMMagic):])
+	if err != nil {
+		return nil, err
+	}
+
+	return &aesGCMReader{
+		r:    bufio.NewReader(r),
+		aead: aead,
+		buf:  make([]byte, aesGCMChunkSize+aead.Overhead()),
+	}, nil
+}
+
+func (d *aesGCMReader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.readChunk(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+func (d *aesGCMReader) readChunk() error {
+	n, err := io.ReadFull(d.r, d.buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		if errors.Is(err, io.EOF) {
+			return errors.New("encrypted backup is truncated")
+		}
+		return err
+	}
+
+	last := n < len(d.buf)
+	if !last {
+		if _, peekErr := d.r.Peek(1); errors.Is(peekErr, io.EOF) {
+			last = true
+		}
+	}
+
+	plain, err := d.aead.Open(d.buf[:0], chunkNonce(d.counter, last), d.buf[:n], nil)
+	if err != nil {
+		return errors.New("decrypt backup failed: wrong passphrase or corrupted data")
+	}
+
+	d.counter++
+	d.plain = plain
+	d.done = last
+	return nil
+}
+
+func newAESGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	if passphrase == "" {
+		return nil, errors.New("aes-gcm encryption requires passphrase")
+	}
+
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, fmt.Errorf("derive key failed: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func chunkNonce(counter uint64, last bool) []byte {
+	nonce := make([]byte, aesGCMNonceSize)
+	binary.BigEndian.PutUint64(nonce[3:11], counter)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
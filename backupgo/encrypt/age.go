@@ -0,0 +1,100 @@
+package encrypt
+
+import (
+	"backupgo/config"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/agessh"
+)
+
+func newAgeWriter(conf config.EncryptionConfig, w io.Writer) (io.WriteCloser, error) {
+	recipients, err := ageRecipients(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return age.Encrypt(w, recipients...)
+}
+
+func newAgeReader(conf config.EncryptionConfig, r io.Reader) (io.Reader, error) {
+	identities, err := ageIdentities(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return age.Decrypt(r, identities...)
+}
+
+// ageRecipients 支持 passphrase（scrypt）或 age1... / ssh-ed25519 / ssh-rsa 公钥，两者不能混用。
+func ageRecipients(conf config.EncryptionConfig) ([]age.Recipient, error) {
+	if conf.Passphrase != "" {
+		recipient, err := age.NewScryptRecipient(conf.Passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return []age.Recipient{recipient}, nil
+	}
+
+	recipients := make([]age.Recipient, 0, len(conf.Recipients))
+	for _, value := range conf.Recipients {
+		value = strings.TrimSpace(value)
+
+		var recipient age.Recipient
+		var err error
+		if strings.HasPrefix(value, "ssh-") {
+			recipient, err = agessh.ParseRecipient(value)
+		} else {
+			recipient, err = age.ParseX25519Recipient(value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse recipient %q failed: %w", value, err)
+		}
+		recipients = append(recipients, recipient)
+	}
+
+	if len(recipients) == 0 {
+		return nil, errors.New("age encryption requires passphrase or recipients")
+	}
+	return recipients, nil
+}
+
+// ageIdentities 解密时使用 passphrase 或 identity_file 中的私钥。
+func ageIdentities(conf config.EncryptionConfig) ([]age.Identity, error) {
+	if conf.Passphrase != "" {
+		identity, err := age.NewScryptIdentity(conf.Passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return []age.Identity{identity}, nil
+	}
+
+	identityFile := strings.TrimSpace(conf.IdentityFile)
+	if identityFile == "" {
+		return nil, errors.New("age decryption requires passphrase or identity_file")
+	}
+
+	data, err := os.ReadFile(identityFile)
+	if err != nil {
+		return nil, fmt.Errorf("read identity file failed: %w", err)
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("-----BEGIN")) {
+		identity, err := agessh.ParseIdentity(data)
+		if err != nil {
+			return nil, fmt.Errorf("parse ssh identity failed: %w", err)
+		}
+		return []age.Identity{identity}, nil
+	}
+
+	identities, err := age.ParseIdentities(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("parse identity file failed: %w", err)
+	}
+	return identities, nil
+}
@@ -0,0 +1,131 @@
+package encrypt
+
+import (
+	"backupgo/config"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	ageExtension    = ".age"
+	aesGCMExtension = ".enc"
+)
+
+// Extension 返回加密后文件追加的后缀。
+func Extension(conf config.EncryptionConfig) string {
+	if conf.GetType() == config.EncryptionTypeAESGCM {
+		return aesGCMExtension
+	}
+	return ageExtension
+}
+
+// IsEncrypted 根据文件后缀判断备份是否经过加密。
+func IsEncrypted(name string) bool {
+	return strings.HasSuffix(name, ageExtension) || strings.HasSuffix(name, aesGCMExtension)
+}
+
+// TrimExtension 去掉加密后缀，得到加密前的文件名。
+func TrimExtension(name string) string {
+	name = strings.TrimSuffix(name, ageExtension)
+	return strings.TrimSuffix(name, aesGCMExtension)
+}
+
+// ForName 按文件后缀（.age 或 .enc）选择解密方式，其余字段沿用 conf。
+// 任务切换加密方式后，旧备份仍按上传时的方式解密。
+func ForName(conf config.EncryptionConfig, name string) config.EncryptionConfig {
+	switch {
+	case strings.HasSuffix(name, aesGCMExtension):
+		conf.Type = config.EncryptionTypeAESGCM
+	case strings.HasSuffix(name, ageExtension):
+		conf.Type = config.EncryptionTypeAge
+	}
+	return conf
+}
+
+// NewWriter 返回一个加密写入器，写入的明文加密后写到 w；必须调用 Close 才会写出最后一个分块。
+func NewWriter(conf config.EncryptionConfig, w io.Writer) (io.WriteCloser, error) {
+	switch conf.GetType() {
+	case config.EncryptionTypeAge:
+		return newAgeWriter(conf, w)
+	case config.EncryptionTypeAESGCM:
+		return newAESGCMWriter(conf.Passphrase, w)
+	default:
+		return nil, fmt.Errorf("unsupported encryption type: %s", conf.Type)
+	}
+}
+
+// NewReader 返回一个解密读取器，从 r 读取密文并输出明文。
+func NewReader(conf config.EncryptionConfig, r io.Reader) (io.Reader, error) {
+	switch conf.GetType() {
+	case config.EncryptionTypeAge:
+		return newAgeReader(conf, r)
+	case config.EncryptionTypeAESGCM:
+		return newAESGCMReader(conf.Passphrase, r)
+	default:
+		return nil, fmt.Errorf("unsupported encryption type: %s", conf.Type)
+	}
+}
+
+// EncryptFile 把 src 加密写入 dst。
+func EncryptFile(conf config.EncryptionConfig, src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("open source file failed: %w", err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("create encrypted file failed: %w", err)
+	}
+
+	writer, err := NewWriter(conf, out)
+	if err != nil {
+		_ = out.Close()
+		_ = os.Remove(dst)
+		return err
+	}
+
+	if _, err := io.Copy(writer, in); err != nil {
+		_ = out.Close()
+		_ = os.Remove(dst)
+		return fmt.Errorf("encrypt file failed: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		_ = out.Close()
+		_ = os.Remove(dst)
+		return fmt.Errorf("finish encryption failed: %w", err)
+	}
+
+	return out.Close()
+}
+
+// DecryptFile 把 src 解密写入 dst，解密方式由 src 的后缀决定。
+func DecryptFile(conf config.EncryptionConfig, src string, dst string) error {
+	conf = ForName(conf, src)
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("open encrypted file failed: %w", err)
+	}
+	defer in.Close()
+
+	reader, err := NewReader(conf, in)
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("create decrypted file failed: %w", err)
+	}
+
+	if _, err := io.Copy(out, reader); err != nil {
+		_ = out.Close()
+		_ = os.Remove(dst)
+		return fmt.Errorf("decrypt file failed: %w", err)
+	}
+
+	return out.Close()
+}
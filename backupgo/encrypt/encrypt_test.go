@@ -0,0 +1,166 @@
+package encrypt
+
+import (
+	"backupgo/config"
+	"bytes"
+	"crypto/rand"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+)
+
+func roundTrip(t *testing.T, conf config.EncryptionConfig, plain []byte) []byte {
+	t.Helper()
+
+	var encrypted bytes.Buffer
+	writer, err := NewWriter(conf, &encrypted)
+	if err != nil {
+		t.Fatalf("NewWriter returned error: %v", err)
+	}
+	if _, err := writer.Write(plain); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if len(plain) > 0 && bytes.Contains(encrypted.Bytes(), plain) {
+		t.Fatal("expected encrypted output to not contain plaintext")
+	}
+
+	reader, err := NewReader(conf, bytes.NewReader(encrypted.Bytes()))
+	if err != nil {
+		t.Fatalf("NewReader returned error: %v", err)
+	}
+	decrypted, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("ReadAll returned error: %v", err)
+	}
+	return decrypted
+}
+
+func TestAESGCMRoundTrip(t *testing.T) {
+	conf := config.EncryptionConfig{Type: config.EncryptionTypeAESGCM, Passphrase: "secret"}
+
+	for _, size := range []int{0, 10, aesGCMChunkSize, aesGCMChunkSize + 1, 3*aesGCMChunkSize + 7} {
+		plain := make([]byte, size)
+		if _, err := rand.Read(plain); err != nil {
+			t.Fatalf("generate plaintext: %v", err)
+		}
+
+		if got := roundTrip(t, conf, plain); !bytes.Equal(got, plain) {
+			t.Fatalf("round trip mismatch for size %d", size)
+		}
+	}
+}
+
+func TestAESGCMRejectsWrongPassphraseAndTruncation(t *testing.T) {
+	var encrypted bytes.Buffer
+	writer, err := NewWriter(config.EncryptionConfig{Type: config.EncryptionTypeAESGCM, Passphrase: "secret"}, &encrypted)
+	if err != nil {
+		t.Fatalf("NewWriter returned error: %v", err)
+	}
+	if _, err := writer.Write(bytes.Repeat([]byte("backupgo"), aesGCMChunkSize/4)); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	reader, err := NewReader(config.EncryptionConfig{Type: config.EncryptionTypeAESGCM, Passphrase: "wrong"}, bytes.NewReader(encrypted.Bytes()))
+	if err != nil {
+		t.Fatalf("NewReader returned error: %v", err)
+	}
+	if _, err := io.ReadAll(reader); err == nil {
+		t.Fatal("expected wrong passphrase to fail")
+	}
+
+	truncated := encrypted.Bytes()[:len(aesGCMMagic)+aesGCMSaltSize+aesGCMChunkSize+16]
+	reader, err = NewReader(config.EncryptionConfig{Type: config.EncryptionTypeAESGCM, Passphrase: "secret"}, bytes.NewReader(truncated))
+	if err != nil {
+		t.Fatalf("NewReader returned error: %v", err)
+	}
+	if _, err := io.ReadAll(reader); err == nil {
+		t.Fatal("expected truncated ciphertext to fail")
+	}
+}
+
+func TestAgeRecipientsAndIdentityFile(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("generate identity: %v", err)
+	}
+
+	identityFile := filepath.Join(t.TempDir(), "key.txt")
+	if err := os.WriteFile(identityFile, []byte(identity.String()+"\n"), 0600); err != nil {
+		t.Fatalf("write identity file: %v", err)
+	}
+
+	conf := config.EncryptionConfig{
+		Type:         config.EncryptionTypeAge,
+		Recipients:   []string{identity.Recipient().String()},
+		IdentityFile: identityFile,
+	}
+
+	plain := []byte("pg_dump output")
+	if got := roundTrip(t, conf, plain); !bytes.Equal(got, plain) {
+		t.Fatalf("unexpected decrypted content: %q", got)
+	}
+}
+
+func TestEncryptFileAndExtension(t *testing.T) {
+	conf := config.EncryptionConfig{Type: config.EncryptionTypeAESGCM, Passphrase: "secret"}
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "app_2024_03_08.zip")
+	if err := os.WriteFile(src, []byte("zip content"), 0644); err != nil {
+		t.Fatalf("write source file: %v", err)
+	}
+
+	dst := src + Extension(conf)
+	if err := EncryptFile(conf, src, dst); err != nil {
+		t.Fatalf("EncryptFile returned error: %v", err)
+	}
+	if !IsEncrypted(dst) || TrimExtension(dst) != src {
+		t.Fatalf("unexpected encrypted file name: %s", dst)
+	}
+
+	decrypted := filepath.Join(dir, "decrypted.zip")
+	if err := DecryptFile(conf, dst, decrypted); err != nil {
+		t.Fatalf("DecryptFile returned error: %v", err)
+	}
+	content, err := os.ReadFile(decrypted)
+	if err != nil {
+		t.Fatalf("read decrypted file: %v", err)
+	}
+	if string(content) != "zip content" {
+		t.Fatalf("unexpected decrypted content: %q", content)
+	}
+}
+
+func TestDecryptFileUsesExtension(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "app.zip")
+	if err := os.WriteFile(src, []byte("backupgo"), 0644); err != nil {
+		t.Fatalf("write source file: %v", err)
+	}
+
+	// 旧备份用 age 加密，之后任务改成了 aes-gcm，口令不变
+	old := config.EncryptionConfig{Type: config.EncryptionTypeAge, Passphrase: "secret"}
+	dst := src + Extension(old)
+	if err := EncryptFile(old, src, dst); err != nil {
+		t.Fatalf("EncryptFile returned error: %v", err)
+	}
+
+	decrypted := filepath.Join(dir, "decrypted.zip")
+	current := config.EncryptionConfig{Type: config.EncryptionTypeAESGCM, Passphrase: "secret"}
+	if err := DecryptFile(current, dst, decrypted); err != nil {
+		t.Fatalf("DecryptFile returned error: %v", err)
+	}
+	content, err := os.ReadFile(decrypted)
+	if err != nil || string(content) != "backupgo" {
+		t.Fatalf("unexpected decrypted content %q: %v", content, err)
+	}
+}
@@ -0,0 +1,65 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+
+	"backupgo/config"
+)
+
+// checkObjectName 是检查连接时导出的表或集合名，不应在数据库中存在
+const checkObjectName = "backupgo_check_nonexistent"
+
+// CheckResult 是一项检查的结果，Err 为 nil 表示通过。
+type CheckResult struct {
+	Name string
+	Err  error
+}
+
+// Checker 由能在备份前检查依赖的备份源实现：外部命令是否可执行、数据源能否连接。检查过程不会写入数据源。
+type Checker interface {
+	CheckSource(ctx context.Context) []CheckResult
+}
+
+// Check 检查任务的备份源，备份源不支持检查时返回 nil。
+func Check(ctx context.Context, taskID string, conf config.BackupConfig, logger *slog.Logger) ([]CheckResult, error) {
+	source, err := New(taskID, conf, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	checker, ok := source.(Checker)
+	if !ok {
+		return nil, nil
+	}
+	return checker.CheckSource(ctx), nil
+}
+
+// checkExecutable 检查外部命令是否可执行。container 非空时在容器内执行 <executable> --version，
+// 同时能发现容器名写错或容器没有运行。
+func checkExecutable(ctx context.Context, container string, executable string) CheckResult {
+	if container == "" {
+		_, err := exec.LookPath(executable)
+		return CheckResult{Name: "executable " + executable, Err: err}
+	}
+
+	return CheckResult{
+		Name: fmt.Sprintf("executable %s in container %s", executable, container),
+		Err:  runCommand(ctx, dockerExecCommand(container, executable, nil, []string{"--version"})),
+	}
+}
+
+// checkDirectory 检查目录存在并且可以读取。
+func checkDirectory(name string, dir string) CheckResult {
+	info, err := os.Stat(dir)
+	if err == nil && !info.IsDir() {
+		err = fmt.Errorf("%s is not a directory", dir)
+	}
+	if err == nil {
+		_, err = os.ReadDir(dir)
+	}
+	return CheckResult{Name: name, Err: err}
+}
@@ -0,0 +1,48 @@
+package exporter
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"backupgo/config"
+)
+
+func TestCheckCompositePrefixesSourceNames(t *testing.T) {
+	dataDir := t.TempDir()
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	results, err := Check(context.Background(), "app", config.BackupConfig{
+		ID: "app",
+		Sources: []config.SourceConfig{
+			{Name: "data", Path: dataDir},
+			{Name: "config", Path: missing},
+		},
+	}, slog.Default())
+	if err != nil {
+		t.Fatalf("Check returned error: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if results[0].Name != "data: path "+dataDir || results[0].Err != nil {
+		t.Fatalf("unexpected data result: %+v", results[0])
+	}
+	if results[1].Name != "config: path "+missing || !os.IsNotExist(results[1].Err) {
+		t.Fatalf("unexpected config result: %+v", results[1])
+	}
+}
+
+func TestCheckDirectoryRejectsFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, []byte("x"), 0644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	if result := checkDirectory("path", file); result.Err == nil {
+		t.Fatal("expected error for a regular file")
+	}
+}
@@ -0,0 +1,180 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"backupgo/config"
+	"backupgo/utils"
+)
+
+// compositeSource 把多个备份源导出到同一个备份中，每个备份源位于 <taskID>/<name> 目录。
+type compositeSource struct {
+	taskID  string
+	logger  *slog.Logger
+	sources []namedSource
+}
+
+type namedSource struct {
+	name   string
+	source Source
+}
+
+func newCompositeSource(taskID string, conf config.BackupConfig, logger *slog.Logger) (Source, error) {
+	composite := compositeSource{taskID: taskID, logger: logger.With("backup_source", config.BackupTypeComposite)}
+	for _, sourceConf := range conf.Sources {
+		name := sourceConf.GetName()
+		source, err := New(name, sourceConf.BackupConfig(name), logger.With("source_name", name))
+		if err != nil {
+			return nil, fmt.Errorf("create source %s failed: %w", name, err)
+		}
+		composite.sources = append(composite.sources, namedSource{name: name, source: source})
+	}
+	return composite, nil
+}
+
+func (s compositeSource) PrepareData(ctx context.Context) (*PreparedData, error) {
+	return s.prepare(ctx, func(source Source) (*PreparedData, error) {
+		return source.PrepareData(ctx)
+	})
+}
+
+// PrepareStream 支持流式导出的备份源直接进入压缩流，其他备份源先导出到临时目录。
+func (s compositeSource) PrepareStream(ctx context.Context) (*PreparedData, error) {
+	return s.prepare(ctx, func(source Source) (*PreparedData, error) {
+		if streamSource, ok := source.(StreamSource); ok {
+			return streamSource.PrepareStream(ctx)
+		}
+		return source.PrepareData(ctx)
+	})
+}
+
+func (s compositeSource) prepare(ctx context.Context, prepareSource func(Source) (*PreparedData, error)) (*PreparedData, error) {
+	prepared, err := newPreparedData(s.taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("composite export started", "sources", len(s.sources))
+	for _, source := range s.sources {
+		if err := ctx.Err(); err != nil {
+			_ = prepared.Cleanup()
+			return nil, err
+		}
+
+		data, err := prepareSource(source.source)
+		if err != nil {
+			_ = prepared.Cleanup()
+			s.logger.Error("composite source export failed", "source_name", source.name, "error", err)
+			return nil, fmt.Errorf("prepare source %s failed: %w", source.name, err)
+		}
+		if err := s.merge(prepared, source.name, data); err != nil {
+			_ = data.Cleanup()
+			_ = prepared.Cleanup()
+			return nil, err
+		}
+	}
+
+	s.logger.Info("composite export completed", "sources", len(s.sources))
+	return prepared, nil
+}
+
+// merge 把单个备份源的产物并入 prepared 的 name 子目录。
+func (s compositeSource) merge(prepared *PreparedData, name string, data *PreparedData) error {
+	prefix := path.Join(filepath.Base(prepared.Path), name)
+
+	if data.Path != "" {
+		if data.cleanup == nil {
+			// path 类型直接读取源目录，不复制到临时目录
+			prepared.Dirs = append(prepared.Dirs, utils.SourceDir{Name: prefix, Path: data.Path, Filter: data.Filter})
+		} else {
+			if err := os.Rename(data.Path, filepath.Join(prepared.Path, name)); err != nil {
+				return fmt.Errorf("move source %s export failed: %w", name, err)
+			}
+			if err := data.Cleanup(); err != nil {
+				s.logger.Warn("composite source cleanup failed", "source_name", name, "error", err)
+			}
+		}
+	}
+
+	for _, stream := range data.Streams {
+		stream.Name = path.Join(prefix, trimFirstElement(stream.Name))
+		prepared.Streams = append(prepared.Streams, stream)
+	}
+	return nil
+}
+
+// trimFirstElement 去掉条目路径的第一级目录，单一备份源的条目都以 <taskID>/ 开头
+func trimFirstElement(name string) string {
+	if _, rest, ok := strings.Cut(name, "/"); ok {
+		return rest
+	}
+	return name
+}
+
+// RestoreData 依次把支持导回的备份源导回，path 类型只解压，不需要导回。
+func (s compositeSource) RestoreData(ctx context.Context, dataDir string, opts RestoreOptions) error {
+	restored := 0
+	for _, source := range s.sources {
+		restorer, ok := source.source.(Restorer)
+		if !ok {
+			s.logger.Info("composite source does not support loading, skipped", "source_name", source.name)
+			continue
+		}
+		if err := restorer.RestoreData(ctx, filepath.Join(dataDir, source.name), opts); err != nil {
+			return fmt.Errorf("restore source %s failed: %w", source.name, err)
+		}
+		restored++
+	}
+
+	if restored == 0 {
+		return errors.New("none of the sources support loading data back")
+	}
+	return nil
+}
+
+// VerifyData 依次检查支持检查的备份源。
+func (s compositeSource) VerifyData(ctx context.Context, dataDir string) error {
+	for _, source := range s.sources {
+		verifier, ok := source.source.(Verifier)
+		if !ok {
+			continue
+		}
+		if err := verifier.VerifyData(ctx, filepath.Join(dataDir, source.name)); err != nil {
+			return fmt.Errorf("verify source %s failed: %w", source.name, err)
+		}
+	}
+	return nil
+}
+
+// canVerify 判断是否至少有一个备份源支持检查
+func (s compositeSource) canVerify() bool {
+	for _, source := range s.sources {
+		if _, ok := source.source.(Verifier); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckSource 依次检查每个备份源，结果名称以备份源名称开头。
+func (s compositeSource) CheckSource(ctx context.Context) []CheckResult {
+	var results []CheckResult
+	for _, source := range s.sources {
+		checker, ok := source.source.(Checker)
+		if !ok {
+			continue
+		}
+		for _, result := range checker.CheckSource(ctx) {
+			result.Name = source.name + ": " + result.Name
+			results = append(results, result)
+		}
+	}
+	return results
+}
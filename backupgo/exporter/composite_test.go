@@ -0,0 +1,116 @@
+package exporter
+
+import (
+	"backupgo/config"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// fileSource 模拟导出到临时目录的备份源
+type fileSource struct {
+	taskID string
+	err    error
+}
+
+func (s fileSource) PrepareData(ctx context.Context) (*PreparedData, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	prepared, err := newPreparedData(s.taskID)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(prepared.Path, "data.dump"), []byte("dump"), 0644); err != nil {
+		return nil, err
+	}
+	return prepared, nil
+}
+
+func TestCompositePrepareDataLayout(t *testing.T) {
+	uploads := t.TempDir()
+	source, err := New("app", config.BackupConfig{
+		ID: "app",
+		Sources: []config.SourceConfig{
+			{Name: "uploads", Path: uploads, Exclude: []string{"*.tmp"}},
+		},
+	}, slog.Default())
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+
+	composite := source.(compositeSource)
+	composite.sources = append(composite.sources, namedSource{name: "db", source: fileSource{taskID: "db"}})
+
+	prepared, err := composite.PrepareData(context.Background())
+	if err != nil {
+		t.Fatalf("PrepareData returned error: %v", err)
+	}
+	defer prepared.Cleanup()
+
+	content, err := os.ReadFile(filepath.Join(prepared.Path, "db", "data.dump"))
+	if err != nil || string(content) != "dump" {
+		t.Fatalf("unexpected moved export %q, err: %v", content, err)
+	}
+
+	dirs := prepared.ArchiveDirs()
+	if len(dirs) != 2 || dirs[0].Name != "app" || dirs[1].Name != "app/uploads" || dirs[1].Path != uploads || dirs[1].Filter == nil {
+		t.Fatalf("unexpected archive dirs: %+v", dirs)
+	}
+
+	if err := prepared.Cleanup(); err != nil {
+		t.Fatalf("Cleanup returned error: %v", err)
+	}
+	if _, err := os.Stat(uploads); err != nil {
+		t.Fatalf("path source must not be removed by cleanup: %v", err)
+	}
+}
+
+func TestCompositePrepareStreamRenamesStreams(t *testing.T) {
+	source, err := New("app", config.BackupConfig{
+		ID: "app",
+		Sources: []config.SourceConfig{
+			{Name: "pg", Postgres: &config.PostgresBackupConfig{Databases: []string{"main", "audit"}}},
+			{Name: "conf", Path: t.TempDir()},
+		},
+	}, slog.Default())
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+
+	prepared, err := source.(StreamSource).PrepareStream(context.Background())
+	if err != nil {
+		t.Fatalf("PrepareStream returned error: %v", err)
+	}
+	defer prepared.Cleanup()
+
+	var names []string
+	for _, stream := range prepared.Streams {
+		names = append(names, stream.Name)
+	}
+	if want := []string{"app/pg/main.dump", "app/pg/audit.dump"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("stream names = %v, want %v", names, want)
+	}
+	if dirs := prepared.ArchiveDirs(); len(dirs) != 2 || dirs[1].Name != "app/conf" {
+		t.Fatalf("unexpected archive dirs: %+v", dirs)
+	}
+}
+
+func TestCompositePrepareDataCleansUpOnFailure(t *testing.T) {
+	composite := compositeSource{
+		taskID: "app",
+		logger: slog.Default(),
+		sources: []namedSource{
+			{name: "db", source: fileSource{taskID: "db"}},
+			{name: "broken", source: fileSource{err: errors.New("export failed")}},
+		},
+	}
+
+	if _, err := composite.PrepareData(context.Background()); err == nil {
+		t.Fatal("expected PrepareData to fail when a source fails")
+	}
+}
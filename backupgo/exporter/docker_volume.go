@@ -1,11 +1,13 @@
 package exporter
 
 import (
+	"context"
 	"log/slog"
 	"os"
 	"path/filepath"
 
 	"backupgo/config"
+	"backupgo/utils"
 )
 
 type dockerVolumeSource struct {
@@ -14,7 +16,7 @@ type dockerVolumeSource struct {
 	conf   config.DockerVolumeBackupConfig
 }
 
-func (s dockerVolumeSource) PrepareData() (*PreparedData, error) {
+func (s dockerVolumeSource) PrepareData(ctx context.Context) (*PreparedData, error) {
 	prepared, err := newPreparedData(s.taskID)
 	if err != nil {
 		return nil, err
@@ -22,7 +24,7 @@ func (s dockerVolumeSource) PrepareData() (*PreparedData, error) {
 
 	s.logger.Info("docker volume export started", "volume", s.conf.Volume)
 	s.logger.Info("docker volume inspect started", "volume", s.conf.Volume)
-	if err := runCommand(buildDockerVolumeInspectCommand(s.conf.Volume)); err != nil {
+	if err := runCommand(ctx, buildDockerVolumeInspectCommand(s.conf.Volume)); err != nil {
 		_ = prepared.Cleanup()
 		s.logger.Error("docker volume inspect failed", "volume", s.conf.Volume, "error", err)
 		return nil, err
@@ -32,7 +34,7 @@ func (s dockerVolumeSource) PrepareData() (*PreparedData, error) {
 	s.logger.Info("docker volume backup started", "volume", s.conf.Volume, "target_file", targetFile)
 	s.logger.Info("docker volume helper image selected", "image", s.conf.GetImage())
 
-	if err := runCommand(buildDockerVolumeBackupCommand(s.conf, prepared.Path)); err != nil {
+	if err := runCommand(ctx, buildDockerVolumeBackupCommand(s.conf, prepared.Path)); err != nil {
 		_ = os.Remove(targetFile)
 		_ = prepared.Cleanup()
 		s.logger.Error("docker volume backup failed", "volume", s.conf.Volume, "error", err)
@@ -43,6 +45,42 @@ func (s dockerVolumeSource) PrepareData() (*PreparedData, error) {
 	return prepared, nil
 }
 
+func (s dockerVolumeSource) PrepareStream(ctx context.Context) (*PreparedData, error) {
+	s.logger.Info("docker volume inspect started", "volume", s.conf.Volume)
+	if err := runCommand(ctx, buildDockerVolumeInspectCommand(s.conf.Volume)); err != nil {
+		s.logger.Error("docker volume inspect failed", "volume", s.conf.Volume, "error", err)
+		return nil, err
+	}
+
+	s.logger.Info("docker volume stream prepared", "volume", s.conf.Volume, "image", s.conf.GetImage())
+	return &PreparedData{
+		Streams: []utils.StreamFile{
+			newCommandStream(s.taskID, dockerVolumeArchiveFileName(s.conf.Volume), buildDockerVolumeStreamCommand(s.conf)),
+		},
+	}, nil
+}
+
+func (s dockerVolumeSource) RestoreData(ctx context.Context, dataDir string, opts RestoreOptions) error {
+	volume := s.conf.Volume + opts.NameSuffix
+	s.logger.Info("docker volume restore started", "volume", volume, "source_dir", dataDir)
+
+	if err := runCommand(ctx, buildDockerVolumeRestoreCommand(s.conf, volume, dataDir)); err != nil {
+		s.logger.Error("docker volume restore failed", "volume", volume, "error", err)
+		return err
+	}
+
+	s.logger.Info("docker volume restore completed", "volume", volume)
+	return nil
+}
+
+// CheckSource 检查 docker 是否可执行以及 volume 是否存在。
+func (s dockerVolumeSource) CheckSource(ctx context.Context) []CheckResult {
+	return []CheckResult{
+		checkExecutable(ctx, "", "docker"),
+		{Name: "volume " + s.conf.Volume, Err: runCommand(ctx, buildDockerVolumeInspectCommand(s.conf.Volume))},
+	}
+}
+
 func buildDockerVolumeInspectCommand(volume string) commandSpec {
 	return commandSpec{
 		Name: "docker",
@@ -69,6 +107,42 @@ func buildDockerVolumeBackupCommand(conf config.DockerVolumeBackupConfig, output
 	}
 }
 
+// buildDockerVolumeStreamCommand 让 helper 容器把 tar 写到标准输出，不需要挂载本地目录。
+func buildDockerVolumeStreamCommand(conf config.DockerVolumeBackupConfig) commandSpec {
+	return commandSpec{
+		Name: "docker",
+		Args: []string{
+			"run",
+			"--rm",
+			"--mount", "type=volume,src=" + conf.Volume + ",dst=/source,readonly",
+			conf.GetImage(),
+			"tar",
+			"-cf", "-",
+			"-C", "/source",
+			".",
+		},
+	}
+}
+
+// buildDockerVolumeRestoreCommand 通过 helper 容器把 tar 解压到目标 volume，volume 不存在时由 docker 自动创建。
+func buildDockerVolumeRestoreCommand(conf config.DockerVolumeBackupConfig, volume string, sourceDir string) commandSpec {
+	archiveFile := dockerVolumeArchiveFileName(conf.Volume)
+
+	return commandSpec{
+		Name: "docker",
+		Args: []string{
+			"run",
+			"--rm",
+			"--mount", "type=volume,src=" + volume + ",dst=/target",
+			"--mount", "type=bind,src=" + sourceDir + ",dst=/backup,readonly",
+			conf.GetImage(),
+			"tar",
+			"-xf", filepath.ToSlash(filepath.Join("/backup", archiveFile)),
+			"-C", "/target",
+		},
+	}
+}
+
 func dockerVolumeArchiveFileName(volume string) string {
 	return sanitizeDumpFileName(volume) + ".tar"
 }
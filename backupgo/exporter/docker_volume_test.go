@@ -2,6 +2,7 @@ package exporter
 
 import (
 	"backupgo/config"
+	"context"
 	"log/slog"
 	"reflect"
 	"testing"
@@ -74,3 +75,50 @@ func TestBuildDockerVolumeBackupCommandUsesDefaultImage(t *testing.T) {
 		t.Fatalf("unexpected archive path: %s", got)
 	}
 }
+
+func TestBuildDockerVolumeRestoreCommand(t *testing.T) {
+	spec := buildDockerVolumeRestoreCommand(config.DockerVolumeBackupConfig{
+		Volume: "app-data",
+	}, "app-data_restore", "/tmp/restore/task")
+
+	wantArgs := []string{
+		"run",
+		"--rm",
+		"--mount", "type=volume,src=app-data_restore,dst=/target",
+		"--mount", "type=bind,src=/tmp/restore/task,dst=/backup,readonly",
+		"busybox:latest",
+		"tar",
+		"-xf", "/backup/app-data.tar",
+		"-C", "/target",
+	}
+	if !reflect.DeepEqual(spec.Args, wantArgs) {
+		t.Fatalf("unexpected args: %#v", spec.Args)
+	}
+}
+
+func TestRestoreRejectsPathSource(t *testing.T) {
+	err := Restore(context.Background(), "task", config.BackupConfig{BackupPath: "./export"}, t.TempDir(), RestoreOptions{}, slog.Default())
+	if err == nil {
+		t.Fatal("expected Restore to reject path source")
+	}
+}
+
+func TestBuildDockerVolumeStreamCommand(t *testing.T) {
+	spec := buildDockerVolumeStreamCommand(config.DockerVolumeBackupConfig{
+		Volume: "app-data",
+	})
+
+	wantArgs := []string{
+		"run",
+		"--rm",
+		"--mount", "type=volume,src=app-data,dst=/source,readonly",
+		"busybox:latest",
+		"tar",
+		"-cf", "-",
+		"-C", "/source",
+		".",
+	}
+	if !reflect.DeepEqual(spec.Args, wantArgs) {
+		t.Fatalf("unexpected args: %#v", spec.Args)
+	}
+}
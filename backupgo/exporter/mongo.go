@@ -1,6 +1,7 @@
 package exporter
 
 import (
+	"context"
 	"log/slog"
 	"path/filepath"
 
@@ -13,7 +14,7 @@ type mongoBackupSource struct {
 	conf   config.MongoBackupConfig
 }
 
-func (s mongoBackupSource) PrepareData() (*PreparedData, error) {
+func (s mongoBackupSource) PrepareData(ctx context.Context) (*PreparedData, error) {
 	prepared, err := newPreparedData(s.taskID)
 	if err != nil {
 		return nil, err
@@ -26,7 +27,7 @@ func (s mongoBackupSource) PrepareData() (*PreparedData, error) {
 		s.logger.Info("mongodb database export started", "database", db, "target_file", targetFile)
 
 		spec := buildMongoDumpCommand(s.conf, db)
-		if err := runCommandToFile(spec, targetFile); err != nil {
+		if err := runCommandToFile(ctx, spec, targetFile); err != nil {
 			_ = prepared.Cleanup()
 			s.logger.Error("mongodb database export failed", "database", db, "error", err)
 			return nil, err
@@ -37,28 +38,130 @@ func (s mongoBackupSource) PrepareData() (*PreparedData, error) {
 	return prepared, nil
 }
 
+func (s mongoBackupSource) PrepareStream(ctx context.Context) (*PreparedData, error) {
+	prepared := &PreparedData{}
+	for _, db := range s.conf.Databases {
+		s.logger.Info("mongodb database stream prepared", "database", db)
+		prepared.Streams = append(prepared.Streams, newCommandStream(s.taskID, mongoArchiveFileName(db, s.conf.Gzip), buildMongoDumpCommand(s.conf, db)))
+	}
+
+	return prepared, nil
+}
+
+func (s mongoBackupSource) RestoreData(ctx context.Context, dataDir string, opts RestoreOptions) error {
+	for _, db := range s.conf.Databases {
+		sourceFile := filepath.Join(dataDir, mongoArchiveFileName(db, s.conf.Gzip))
+		s.logger.Info("mongodb database restore started", "database", db+opts.NameSuffix, "source_file", sourceFile)
+
+		if err := runCommandFromFile(ctx, buildMongoRestoreCommand(s.conf, db, opts.NameSuffix), sourceFile); err != nil {
+			s.logger.Error("mongodb database restore failed", "database", db+opts.NameSuffix, "error", err)
+			return err
+		}
+	}
+
+	s.logger.Info("mongodb restore completed")
+	return nil
+}
+
+// VerifyData 用 mongorestore --dryRun 完整读取每个 archive，不会写入数据库。
+func (s mongoBackupSource) VerifyData(ctx context.Context, dataDir string) error {
+	for _, db := range s.conf.Databases {
+		sourceFile := filepath.Join(dataDir, mongoArchiveFileName(db, s.conf.Gzip))
+		s.logger.Info("mongodb database verify started", "database", db, "source_file", sourceFile)
+
+		if err := runCommandFromFile(ctx, buildMongoVerifyCommand(s.conf, db), sourceFile); err != nil {
+			s.logger.Error("mongodb database verify failed", "database", db, "error", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CheckSource 检查 mongodump 是否可执行，并对每个库导出一个不存在的集合，确认连接和认证都没有问题。
+func (s mongoBackupSource) CheckSource(ctx context.Context) []CheckResult {
+	results := []CheckResult{checkExecutable(ctx, mongoContainer(s.conf), "mongodump")}
+	for _, db := range s.conf.Databases {
+		results = append(results, CheckResult{
+			Name: "connect database " + db,
+			Err:  runCommand(ctx, buildMongoCheckCommand(s.conf, db)),
+		})
+	}
+	return results
+}
+
 func buildMongoDumpCommand(conf config.MongoBackupConfig, database string) commandSpec {
 	mongoArgs := []string{"--archive"}
 	if conf.Gzip {
 		mongoArgs = append(mongoArgs, "--gzip")
 	}
-	if conf.URI != "" {
-		mongoArgs = appendStringOption(mongoArgs, "--uri", conf.URI)
-	} else {
-		mongoArgs = appendStringOption(mongoArgs, "--host", conf.Host)
-		mongoArgs = appendIntOption(mongoArgs, "--port", conf.Port)
-		mongoArgs = appendStringOption(mongoArgs, "--username", conf.Username)
-		mongoArgs = appendStringOption(mongoArgs, "--password", conf.Password)
-		mongoArgs = appendStringOption(mongoArgs, "--authenticationDatabase", conf.AuthDatabase)
-	}
+	mongoArgs = appendMongoConnectionArgs(mongoArgs, conf)
 	mongoArgs = append(mongoArgs, conf.ExtraArgs...)
 	mongoArgs = append(mongoArgs, "--db", database)
 
+	return mongoCommand(conf, "mongodump", mongoArgs)
+}
+
+// buildMongoRestoreCommand 构造从标准输入读取 archive 的 mongorestore 命令，nameSuffix 非空时恢复到新库。
+func buildMongoRestoreCommand(conf config.MongoBackupConfig, database string, nameSuffix string) commandSpec {
+	mongoArgs := []string{"--archive", "--drop"}
+	if conf.Gzip {
+		mongoArgs = append(mongoArgs, "--gzip")
+	}
+	mongoArgs = appendMongoConnectionArgs(mongoArgs, conf)
+	if nameSuffix != "" {
+		mongoArgs = append(mongoArgs, "--nsFrom", database+".*", "--nsTo", database+nameSuffix+".*")
+	} else {
+		mongoArgs = append(mongoArgs, "--nsInclude", database+".*")
+	}
+
+	return mongoCommand(conf, "mongorestore", mongoArgs)
+}
+
+func buildMongoVerifyCommand(conf config.MongoBackupConfig, database string) commandSpec {
+	mongoArgs := []string{"--archive", "--dryRun"}
+	if conf.Gzip {
+		mongoArgs = append(mongoArgs, "--gzip")
+	}
+	mongoArgs = appendMongoConnectionArgs(mongoArgs, conf)
+	mongoArgs = append(mongoArgs, "--nsInclude", database+".*")
+
+	return mongoCommand(conf, "mongorestore", mongoArgs)
+}
+
+// buildMongoCheckCommand 构造只导出一个不存在的集合的 mongodump 命令，用于检查连接。
+func buildMongoCheckCommand(conf config.MongoBackupConfig, database string) commandSpec {
+	mongoArgs := appendMongoConnectionArgs([]string{"--archive"}, conf)
+	mongoArgs = append(mongoArgs, "--db", database, "--collection", checkObjectName)
+
+	return mongoCommand(conf, "mongodump", mongoArgs)
+}
+
+func appendMongoConnectionArgs(args []string, conf config.MongoBackupConfig) []string {
+	if conf.URI != "" {
+		return appendStringOption(args, "--uri", conf.URI)
+	}
+
+	args = appendStringOption(args, "--host", conf.Host)
+	args = appendIntOption(args, "--port", conf.Port)
+	args = appendStringOption(args, "--username", conf.Username)
+	args = appendStringOption(args, "--password", conf.Password)
+	return appendStringOption(args, "--authenticationDatabase", conf.AuthDatabase)
+}
+
+func mongoCommand(conf config.MongoBackupConfig, executable string, args []string) commandSpec {
 	if conf.GetMode() == config.ExecModeDocker {
-		return dockerExecCommand(conf.Container, "mongodump", nil, mongoArgs)
+		return dockerExecCommand(conf.Container, executable, nil, args)
 	}
 
-	return commandSpec{Name: "mongodump", Args: mongoArgs}
+	return commandSpec{Name: executable, Args: args}
+}
+
+func mongoContainer(conf config.MongoBackupConfig) string {
+	if conf.GetMode() == config.ExecModeDocker {
+		return conf.Container
+	}
+	return ""
 }
 
 func mongoArchiveFileName(database string, gzip bool) string {
@@ -0,0 +1,149 @@
+package exporter
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"backupgo/config"
+)
+
+type mysqlBackupSource struct {
+	taskID string
+	logger *slog.Logger
+	conf   config.MySQLBackupConfig
+}
+
+func (s mysqlBackupSource) PrepareData(ctx context.Context) (*PreparedData, error) {
+	prepared, err := newPreparedData(s.taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("mysql export started", "executable", s.conf.GetExecutable())
+
+	for _, db := range s.conf.Databases {
+		targetFile := filepath.Join(prepared.Path, mysqlDumpFileName(db))
+		s.logger.Info("mysql database export started", "database", db, "target_file", targetFile)
+
+		spec := buildMySQLDumpCommand(s.conf, db)
+		if err := runCommandToFile(ctx, spec, targetFile); err != nil {
+			_ = prepared.Cleanup()
+			s.logger.Error("mysql database export failed", "database", db, "error", err)
+			return nil, err
+		}
+	}
+
+	s.logger.Info("mysql export completed")
+	return prepared, nil
+}
+
+func (s mysqlBackupSource) PrepareStream(ctx context.Context) (*PreparedData, error) {
+	prepared := &PreparedData{}
+	for _, db := range s.conf.Databases {
+		s.logger.Info("mysql database stream prepared", "database", db)
+		prepared.Streams = append(prepared.Streams, newCommandStream(s.taskID, mysqlDumpFileName(db), buildMySQLDumpCommand(s.conf, db)))
+	}
+
+	return prepared, nil
+}
+
+func (s mysqlBackupSource) RestoreData(ctx context.Context, dataDir string, opts RestoreOptions) error {
+	for _, db := range s.conf.Databases {
+		sourceFile := filepath.Join(dataDir, mysqlDumpFileName(db))
+		targetDB := db + opts.NameSuffix
+		s.logger.Info("mysql database restore started", "database", targetDB, "source_file", sourceFile)
+
+		if err := runCommand(ctx, buildMySQLCreateDatabaseCommand(s.conf, targetDB)); err != nil {
+			s.logger.Error("mysql create database failed", "database", targetDB, "error", err)
+			return err
+		}
+		if err := runCommandFromFile(ctx, buildMySQLRestoreCommand(s.conf, targetDB), sourceFile); err != nil {
+			s.logger.Error("mysql database restore failed", "database", targetDB, "error", err)
+			return err
+		}
+	}
+
+	s.logger.Info("mysql restore completed")
+	return nil
+}
+
+// CheckSource 检查导出命令是否可执行，并用 mysql 客户端连接每个库执行 SELECT 1。
+func (s mysqlBackupSource) CheckSource(ctx context.Context) []CheckResult {
+	results := []CheckResult{checkExecutable(ctx, mysqlContainer(s.conf), s.conf.GetExecutable())}
+	for _, db := range s.conf.Databases {
+		results = append(results, CheckResult{
+			Name: "connect database " + db,
+			Err:  runCommand(ctx, buildMySQLCheckCommand(s.conf, db)),
+		})
+	}
+	return results
+}
+
+// buildMySQLDumpCommand 导出单个库，不带 CREATE DATABASE / USE 语句，恢复时可以导入到其他库名。
+func buildMySQLDumpCommand(conf config.MySQLBackupConfig, database string) commandSpec {
+	mysqlArgs := []string{"--single-transaction", "--routines", "--triggers", "--events"}
+	mysqlArgs = appendMySQLConnectionArgs(mysqlArgs, conf)
+	mysqlArgs = append(mysqlArgs, conf.ExtraArgs...)
+	mysqlArgs = append(mysqlArgs, database)
+
+	return mysqlCommand(conf, conf.GetExecutable(), mysqlArgs)
+}
+
+func buildMySQLCreateDatabaseCommand(conf config.MySQLBackupConfig, database string) commandSpec {
+	mysqlArgs := appendMySQLConnectionArgs(nil, conf)
+	mysqlArgs = append(mysqlArgs, "--execute", "CREATE DATABASE IF NOT EXISTS "+quoteMySQLIdentifier(database))
+
+	return mysqlCommand(conf, conf.GetClientExecutable(), mysqlArgs)
+}
+
+// buildMySQLRestoreCommand 构造从标准输入读取 SQL 的 mysql 客户端命令。
+func buildMySQLRestoreCommand(conf config.MySQLBackupConfig, database string) commandSpec {
+	mysqlArgs := appendMySQLConnectionArgs(nil, conf)
+	mysqlArgs = append(mysqlArgs, "--database", database)
+
+	return mysqlCommand(conf, conf.GetClientExecutable(), mysqlArgs)
+}
+
+func buildMySQLCheckCommand(conf config.MySQLBackupConfig, database string) commandSpec {
+	mysqlArgs := appendMySQLConnectionArgs(nil, conf)
+	mysqlArgs = append(mysqlArgs, "--database", database, "--execute", "SELECT 1")
+
+	return mysqlCommand(conf, conf.GetClientExecutable(), mysqlArgs)
+}
+
+func appendMySQLConnectionArgs(args []string, conf config.MySQLBackupConfig) []string {
+	args = appendStringOption(args, "--host", conf.Host)
+	args = appendIntOption(args, "--port", conf.Port)
+	return appendStringOption(args, "--user", conf.User)
+}
+
+// mysqlCommand 通过 MYSQL_PWD 传递密码，避免密码出现在进程参数里。
+func mysqlCommand(conf config.MySQLBackupConfig, executable string, args []string) commandSpec {
+	var env []string
+	if conf.Password != "" {
+		env = append(env, "MYSQL_PWD="+conf.Password)
+	}
+
+	if conf.GetMode() == config.ExecModeDocker {
+		return dockerExecCommand(conf.Container, executable, env, args)
+	}
+
+	return commandSpec{Name: executable, Args: args, Env: env}
+}
+
+func mysqlContainer(conf config.MySQLBackupConfig) string {
+	if conf.GetMode() == config.ExecModeDocker {
+		return conf.Container
+	}
+	return ""
+}
+
+func quoteMySQLIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func mysqlDumpFileName(database string) string {
+	return sanitizeDumpFileName(database) + ".sql"
+}
@@ -0,0 +1,77 @@
+package exporter
+
+import (
+	"backupgo/config"
+	"reflect"
+	"testing"
+)
+
+func TestBuildMySQLDumpCommandLocal(t *testing.T) {
+	spec := buildMySQLDumpCommand(config.MySQLBackupConfig{
+		Host:      "127.0.0.1",
+		Port:      3306,
+		User:      "root",
+		Password:  "secret",
+		Databases: []string{"app"},
+		ExtraArgs: []string{"--skip-lock-tables"},
+	}, "app")
+
+	if spec.Name != config.MySQLDumpExecutable {
+		t.Fatalf("unexpected command name: %s", spec.Name)
+	}
+
+	wantArgs := []string{
+		"--single-transaction",
+		"--routines",
+		"--triggers",
+		"--events",
+		"--host", "127.0.0.1",
+		"--port", "3306",
+		"--user", "root",
+		"--skip-lock-tables",
+		"app",
+	}
+	if !reflect.DeepEqual(spec.Args, wantArgs) {
+		t.Fatalf("unexpected args: %#v", spec.Args)
+	}
+
+	if !reflect.DeepEqual(spec.Env, []string{"MYSQL_PWD=secret"}) {
+		t.Fatalf("unexpected env: %#v", spec.Env)
+	}
+}
+
+func TestBuildMySQLDumpCommandDockerMariaDB(t *testing.T) {
+	spec := buildMySQLDumpCommand(config.MySQLBackupConfig{
+		Mode:       config.ExecModeDocker,
+		Container:  "mariadb",
+		Executable: config.MariaDBDumpExecutable,
+		User:       "root",
+		Password:   "secret",
+		Databases:  []string{"app"},
+	}, "app")
+
+	wantPrefix := []string{"exec", "-i", "-e", "MYSQL_PWD=secret", "mariadb", "mariadb-dump"}
+	if !reflect.DeepEqual(spec.Args[:len(wantPrefix)], wantPrefix) {
+		t.Fatalf("unexpected docker args prefix: %#v", spec.Args)
+	}
+}
+
+func TestBuildMySQLRestoreCommands(t *testing.T) {
+	conf := config.MySQLBackupConfig{
+		Executable: config.MariaDBDumpExecutable,
+		User:       "root",
+		Databases:  []string{"app"},
+	}
+
+	create := buildMySQLCreateDatabaseCommand(conf, "app`restore")
+	wantCreate := []string{"--user", "root", "--execute", "CREATE DATABASE IF NOT EXISTS `app``restore`"}
+	if create.Name != "mariadb" || !reflect.DeepEqual(create.Args, wantCreate) {
+		t.Fatalf("unexpected create command: %s %#v", create.Name, create.Args)
+	}
+
+	restore := buildMySQLRestoreCommand(conf, "app_restore")
+	wantRestore := []string{"--user", "root", "--database", "app_restore"}
+	if restore.Name != "mariadb" || !reflect.DeepEqual(restore.Args, wantRestore) {
+		t.Fatalf("unexpected restore command: %s %#v", restore.Name, restore.Args)
+	}
+}
@@ -1,14 +1,24 @@
 package exporter
 
-import "log/slog"
+import (
+	"context"
+	"log/slog"
+
+	"backupgo/utils"
+)
 
 type pathSource struct {
 	taskID string
 	logger *slog.Logger
 	path   string
+	filter *utils.PathFilter
 }
 
-func (s pathSource) PrepareData() (*PreparedData, error) {
+func (s pathSource) PrepareData(ctx context.Context) (*PreparedData, error) {
 	s.logger.Info("using path backup source", "path", s.path)
-	return &PreparedData{Path: s.path}, nil
+	return &PreparedData{Path: s.path, Filter: s.filter}, nil
+}
+
+func (s pathSource) CheckSource(ctx context.Context) []CheckResult {
+	return []CheckResult{checkDirectory("path "+s.path, s.path)}
 }
@@ -1,6 +1,7 @@
 package exporter
 
 import (
+	"context"
 	"log/slog"
 	"path/filepath"
 
@@ -13,7 +14,7 @@ type postgresBackupSource struct {
 	conf   config.PostgresBackupConfig
 }
 
-func (s postgresBackupSource) PrepareData() (*PreparedData, error) {
+func (s postgresBackupSource) PrepareData(ctx context.Context) (*PreparedData, error) {
 	prepared, err := newPreparedData(s.taskID)
 	if err != nil {
 		return nil, err
@@ -26,7 +27,7 @@ func (s postgresBackupSource) PrepareData() (*PreparedData, error) {
 		s.logger.Info("postgres database export started", "database", db, "target_file", targetFile)
 
 		spec := buildPostgresDumpCommand(s.conf, db)
-		if err := runCommandToFile(spec, targetFile); err != nil {
+		if err := runCommandToFile(ctx, spec, targetFile); err != nil {
 			_ = prepared.Cleanup()
 			s.logger.Error("postgres database export failed", "database", db, "error", err)
 			return nil, err
@@ -37,24 +38,113 @@ func (s postgresBackupSource) PrepareData() (*PreparedData, error) {
 	return prepared, nil
 }
 
+func (s postgresBackupSource) PrepareStream(ctx context.Context) (*PreparedData, error) {
+	prepared := &PreparedData{}
+	for _, db := range s.conf.Databases {
+		s.logger.Info("postgres database stream prepared", "database", db)
+		prepared.Streams = append(prepared.Streams, newCommandStream(s.taskID, sanitizeDumpFileName(db)+".dump", buildPostgresDumpCommand(s.conf, db)))
+	}
+
+	return prepared, nil
+}
+
+func (s postgresBackupSource) RestoreData(ctx context.Context, dataDir string, opts RestoreOptions) error {
+	for _, db := range s.conf.Databases {
+		sourceFile := filepath.Join(dataDir, sanitizeDumpFileName(db)+".dump")
+		targetDB := db + opts.NameSuffix
+		s.logger.Info("postgres database restore started", "database", targetDB, "source_file", sourceFile)
+
+		if err := runCommandFromFile(ctx, buildPostgresRestoreCommand(s.conf, targetDB), sourceFile); err != nil {
+			s.logger.Error("postgres database restore failed", "database", targetDB, "error", err)
+			return err
+		}
+	}
+
+	s.logger.Info("postgres restore completed")
+	return nil
+}
+
+// VerifyData 用 pg_restore --list 读取每个备份的目录，能列出目录说明备份文件完整可读。
+func (s postgresBackupSource) VerifyData(ctx context.Context, dataDir string) error {
+	for _, db := range s.conf.Databases {
+		sourceFile := filepath.Join(dataDir, sanitizeDumpFileName(db)+".dump")
+		s.logger.Info("postgres database verify started", "database", db, "source_file", sourceFile)
+
+		if err := runCommandFromFile(ctx, buildPostgresListCommand(s.conf), sourceFile); err != nil {
+			s.logger.Error("postgres database verify failed", "database", db, "error", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CheckSource 检查 pg_dump 是否可执行，并对每个库只导出一张不存在的表，确认连接和认证都没有问题。
+func (s postgresBackupSource) CheckSource(ctx context.Context) []CheckResult {
+	results := []CheckResult{checkExecutable(ctx, postgresContainer(s.conf), "pg_dump")}
+	for _, db := range s.conf.Databases {
+		results = append(results, CheckResult{
+			Name: "connect database " + db,
+			Err:  runCommand(ctx, buildPostgresCheckCommand(s.conf, db)),
+		})
+	}
+	return results
+}
+
 func buildPostgresDumpCommand(conf config.PostgresBackupConfig, database string) commandSpec {
 	pgArgs := []string{"--format=custom", "--no-password"}
-	pgArgs = appendStringOption(pgArgs, "--host", conf.Host)
-	pgArgs = appendIntOption(pgArgs, "--port", conf.Port)
-	pgArgs = appendStringOption(pgArgs, "--username", conf.User)
+	pgArgs = appendPostgresConnectionArgs(pgArgs, conf)
 	pgArgs = append(pgArgs, conf.ExtraArgs...)
 	pgArgs = append(pgArgs, "--dbname", database)
 
+	return postgresCommand(conf, "pg_dump", pgArgs)
+}
+
+// buildPostgresRestoreCommand 构造从标准输入读取 custom 格式备份的 pg_restore 命令。
+func buildPostgresRestoreCommand(conf config.PostgresBackupConfig, database string) commandSpec {
+	pgArgs := []string{"--no-password", "--clean", "--if-exists", "--no-owner"}
+	pgArgs = appendPostgresConnectionArgs(pgArgs, conf)
+	pgArgs = append(pgArgs, "--dbname", database)
+
+	return postgresCommand(conf, "pg_restore", pgArgs)
+}
+
+// buildPostgresListCommand 构造从标准输入读取备份并只输出目录的 pg_restore 命令，不需要连接数据库。
+func buildPostgresListCommand(conf config.PostgresBackupConfig) commandSpec {
+	return postgresCommand(conf, "pg_restore", []string{"--list"})
+}
+
+// buildPostgresCheckCommand 构造只导出一张不存在的表的 pg_dump 命令，用于检查连接，不会读取表数据。
+func buildPostgresCheckCommand(conf config.PostgresBackupConfig, database string) commandSpec {
+	pgArgs := []string{"--schema-only", "--no-password"}
+	pgArgs = appendPostgresConnectionArgs(pgArgs, conf)
+	pgArgs = append(pgArgs, "--table", checkObjectName, "--dbname", database)
+
+	return postgresCommand(conf, "pg_dump", pgArgs)
+}
+
+func appendPostgresConnectionArgs(args []string, conf config.PostgresBackupConfig) []string {
+	args = appendStringOption(args, "--host", conf.Host)
+	args = appendIntOption(args, "--port", conf.Port)
+	return appendStringOption(args, "--username", conf.User)
+}
+
+func postgresCommand(conf config.PostgresBackupConfig, executable string, args []string) commandSpec {
 	var env []string
 	if conf.Password != "" {
 		env = append(env, "PGPASSWORD="+conf.Password)
 	}
 
 	if conf.GetMode() == config.ExecModeDocker {
-		return dockerExecCommand(conf.Container, "pg_dump", env, pgArgs)
+		return dockerExecCommand(conf.Container, executable, env, args)
 	}
 
-	spec := commandSpec{Name: "pg_dump", Args: pgArgs}
-	spec.Env = env
-	return spec
+	return commandSpec{Name: executable, Args: args, Env: env}
+}
+
+func postgresContainer(conf config.PostgresBackupConfig) string {
+	if conf.GetMode() == config.ExecModeDocker {
+		return conf.Container
+	}
+	return ""
 }
@@ -57,3 +57,61 @@ func TestBuildPostgresDumpCommandDocker(t *testing.T) {
 		t.Fatalf("unexpected docker args prefix: %#v", spec.Args)
 	}
 }
+
+func TestBuildPostgresRestoreCommandDocker(t *testing.T) {
+	spec := buildPostgresRestoreCommand(config.PostgresBackupConfig{
+		Mode:      config.ExecModeDocker,
+		Container: "postgres",
+		User:      "postgres",
+		Password:  "secret",
+		Databases: []string{"app"},
+		ExtraArgs: []string{"--no-acl"},
+	}, "app_restore_test")
+
+	wantArgs := []string{
+		"exec", "-i", "-e", "PGPASSWORD=secret", "postgres", "pg_restore",
+		"--no-password",
+		"--clean",
+		"--if-exists",
+		"--no-owner",
+		"--username", "postgres",
+		"--dbname", "app_restore_test",
+	}
+	if !reflect.DeepEqual(spec.Args, wantArgs) {
+		t.Fatalf("unexpected args: %#v", spec.Args)
+	}
+}
+
+func TestBuildPostgresListCommandDocker(t *testing.T) {
+	spec := buildPostgresListCommand(config.PostgresBackupConfig{
+		Mode:      config.ExecModeDocker,
+		Container: "postgres",
+		Databases: []string{"app"},
+	})
+
+	wantArgs := []string{"exec", "-i", "postgres", "pg_restore", "--list"}
+	if spec.Name != "docker" || !reflect.DeepEqual(spec.Args, wantArgs) {
+		t.Fatalf("unexpected command: %s %#v", spec.Name, spec.Args)
+	}
+}
+
+func TestBuildPostgresCheckCommand(t *testing.T) {
+	spec := buildPostgresCheckCommand(config.PostgresBackupConfig{
+		Host:     "db.internal",
+		User:     "backup",
+		Password: "secret",
+	}, "app")
+
+	wantArgs := []string{
+		"--schema-only", "--no-password",
+		"--host", "db.internal",
+		"--username", "backup",
+		"--table", checkObjectName, "--dbname", "app",
+	}
+	if spec.Name != "pg_dump" || !reflect.DeepEqual(spec.Args, wantArgs) {
+		t.Fatalf("unexpected command: %s %#v", spec.Name, spec.Args)
+	}
+	if !reflect.DeepEqual(spec.Env, []string{"PGPASSWORD=secret"}) {
+		t.Fatalf("unexpected env: %#v", spec.Env)
+	}
+}
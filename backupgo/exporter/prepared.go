@@ -1,17 +1,36 @@
 package exporter
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+
+	"backupgo/utils"
 )
 
 // PreparedData 表示已经准备完成、可用于后续压缩和上传的本地备份产物。
+// 流式模式下数据库导出不落盘，而是以 Streams 的形式在压缩时才执行导出命令。
 type PreparedData struct {
-	Path    string
+	Path string
+	// Filter 筛选 Path 中写入备份的文件，只有 path 类型使用
+	Filter *utils.PathFilter
+	// Dirs 是组合备份中直接读取、不复制到 Path 的目录
+	Dirs    []utils.SourceDir
+	Streams []utils.StreamFile
 	cleanup func() error
 }
 
+// ArchiveDirs 返回需要写入备份的全部目录。
+func (p *PreparedData) ArchiveDirs() []utils.SourceDir {
+	var dirs []utils.SourceDir
+	if p.Path != "" {
+		dirs = append(dirs, utils.NewSourceDir(p.Path, p.Filter))
+	}
+	return append(dirs, p.Dirs...)
+}
+
 // Cleanup 清理 Prepare 阶段生成的临时备份产物。
 func (p *PreparedData) Cleanup() error {
 	if p == nil || p.cleanup == nil {
@@ -42,3 +61,14 @@ func newPreparedData(taskID string) (*PreparedData, error) {
 		},
 	}, nil
 }
+
+// newCommandStream 构造执行导出命令的数据流，条目路径与落盘模式下的 zip 目录结构一致，保证 restore 可以通用。
+// 导出命令使用压缩时传入的 ctx，超时或取消时随之终止。
+func newCommandStream(taskID string, fileName string, spec commandSpec) utils.StreamFile {
+	return utils.StreamFile{
+		Name: sanitizeDumpFileName(taskID) + "/" + fileName,
+		Open: func(ctx context.Context) (io.ReadCloser, error) {
+			return startCommandReader(ctx, spec)
+		},
+	}
+}
@@ -0,0 +1,57 @@
+package exporter
+
+import (
+	"backupgo/config"
+	"reflect"
+	"testing"
+)
+
+func TestBuildRedisDumpCommandLocal(t *testing.T) {
+	spec := buildRedisDumpCommand(config.RedisBackupConfig{
+		Host:     "127.0.0.1",
+		Port:     6379,
+		Password: "secret",
+	}, "/tmp/dump.rdb")
+
+	if spec.Name != "redis-cli" {
+		t.Fatalf("unexpected command name: %s", spec.Name)
+	}
+
+	wantArgs := []string{"-h", "127.0.0.1", "-p", "6379", "--rdb", "/tmp/dump.rdb"}
+	if !reflect.DeepEqual(spec.Args, wantArgs) {
+		t.Fatalf("unexpected args: %#v", spec.Args)
+	}
+	if !reflect.DeepEqual(spec.Env, []string{"REDISCLI_AUTH=secret"}) {
+		t.Fatalf("unexpected env: %#v", spec.Env)
+	}
+}
+
+func TestBuildRedisDumpCommandDocker(t *testing.T) {
+	spec := buildRedisDumpCommand(config.RedisBackupConfig{
+		Mode:      config.ExecModeDocker,
+		Container: "redis",
+		Username:  "backup",
+		Password:  "secret",
+	}, "/tmp/backupgo-cache-dump.rdb")
+
+	wantArgs := []string{
+		"exec", "-i", "-e", "REDISCLI_AUTH=secret", "redis", "redis-cli",
+		"--user", "backup",
+		"--rdb", "/tmp/backupgo-cache-dump.rdb",
+	}
+	if !reflect.DeepEqual(spec.Args, wantArgs) {
+		t.Fatalf("unexpected args: %#v", spec.Args)
+	}
+}
+
+func TestBuildRedisPingCommand(t *testing.T) {
+	spec := buildRedisPingCommand(config.RedisBackupConfig{
+		Port:      6380,
+		ExtraArgs: []string{"--tls"},
+	})
+
+	wantArgs := []string{"-p", "6380", "--tls", "PING"}
+	if spec.Name != "redis-cli" || !reflect.DeepEqual(spec.Args, wantArgs) {
+		t.Fatalf("unexpected command: %s %#v", spec.Name, spec.Args)
+	}
+}
@@ -0,0 +1,42 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+
+	"backupgo/config"
+)
+
+// RestoreOptions 控制把备份数据导回数据源时的行为。
+type RestoreOptions struct {
+	// NameSuffix 追加到目标数据库名或 volume 名之后，便于恢复到新的库验证备份，而不覆盖原数据
+	NameSuffix string
+}
+
+// Restorer 由支持把备份数据导回数据源的备份源实现。
+type Restorer interface {
+	// RestoreData 把 dataDir 中的备份产物导回数据源。
+	RestoreData(ctx context.Context, dataDir string, opts RestoreOptions) error
+}
+
+// Restore 把解压到 extractDir 的备份导回任务配置的数据源。
+func Restore(ctx context.Context, taskID string, conf config.BackupConfig, extractDir string, opts RestoreOptions, logger *slog.Logger) error {
+	source, err := New(taskID, conf, logger)
+	if err != nil {
+		return err
+	}
+
+	restorer, ok := source.(Restorer)
+	if !ok {
+		return fmt.Errorf("backup type %s does not support loading data back", conf.GetType())
+	}
+
+	return restorer.RestoreData(ctx, RestoreDataDir(taskID, extractDir), opts)
+}
+
+// RestoreDataDir 返回内置备份源解压后数据所在的目录。
+func RestoreDataDir(taskID string, extractDir string) string {
+	return filepath.Join(extractDir, sanitizeDumpFileName(taskID))
+}
@@ -1,39 +1,71 @@
 package exporter
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 
 	"backupgo/config"
+	"backupgo/utils"
 )
 
-// Source 定义具体备份源的准备动作。
+// Source 定义具体备份源的准备动作。ctx 取消时正在执行的导出命令会被终止。
 type Source interface {
 	// PrepareData 根据任务配置生成可供压缩的备份产物。
-	PrepareData() (*PreparedData, error)
+	PrepareData(ctx context.Context) (*PreparedData, error)
+}
+
+// StreamSource 由支持流式导出的备份源实现，导出命令的输出直接进入压缩流程而不写临时文件。
+type StreamSource interface {
+	PrepareStream(ctx context.Context) (*PreparedData, error)
 }
 
 // Prepare 根据任务配置选择备份源，并生成可供后续压缩的备份产物。
-func Prepare(taskID string, conf config.BackupConfig, logger *slog.Logger) (*PreparedData, error) {
+func Prepare(ctx context.Context, taskID string, conf config.BackupConfig, logger *slog.Logger) (*PreparedData, error) {
+	source, err := New(taskID, conf, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return source.PrepareData(ctx)
+}
+
+// PrepareStream 为流式备份准备数据源，不支持流式导出的备份源退回 PrepareData。
+func PrepareStream(ctx context.Context, taskID string, conf config.BackupConfig, logger *slog.Logger) (*PreparedData, error) {
 	source, err := New(taskID, conf, logger)
 	if err != nil {
 		return nil, err
 	}
 
-	return source.PrepareData()
+	if streamSource, ok := source.(StreamSource); ok {
+		return streamSource.PrepareStream(ctx)
+	}
+	return source.PrepareData(ctx)
 }
 
 // New 根据任务配置构造对应的备份源实现。
 func New(taskID string, conf config.BackupConfig, logger *slog.Logger) (Source, error) {
 	switch conf.GetType() {
 	case config.BackupTypePath:
-		return pathSource{taskID: taskID, logger: logger.With("backup_source", config.BackupTypePath), path: conf.BackupPath}, nil
+		filter, err := utils.NewPathFilter(conf.Include, conf.Exclude)
+		if err != nil {
+			return nil, err
+		}
+		return pathSource{taskID: taskID, logger: logger.With("backup_source", config.BackupTypePath), path: conf.BackupPath, filter: filter}, nil
 	case config.BackupTypePostgres:
 		return postgresBackupSource{taskID: taskID, logger: logger.With("backup_source", config.BackupTypePostgres), conf: *conf.Postgres}, nil
 	case config.BackupTypeMongoDB:
 		return mongoBackupSource{taskID: taskID, logger: logger.With("backup_source", config.BackupTypeMongoDB), conf: *conf.MongoDB}, nil
+	case config.BackupTypeMySQL:
+		return mysqlBackupSource{taskID: taskID, logger: logger.With("backup_source", config.BackupTypeMySQL), conf: *conf.MySQL}, nil
+	case config.BackupTypeRedis:
+		return redisBackupSource{taskID: taskID, logger: logger.With("backup_source", config.BackupTypeRedis), conf: *conf.Redis}, nil
+	case config.BackupTypeSQLite:
+		return sqliteBackupSource{taskID: taskID, logger: logger.With("backup_source", config.BackupTypeSQLite), conf: *conf.SQLite}, nil
 	case config.BackupTypeDockerVolume:
 		return dockerVolumeSource{taskID: taskID, logger: logger.With("backup_source", config.BackupTypeDockerVolume), conf: *conf.DockerVolume}, nil
+	case config.BackupTypeComposite:
+		return newCompositeSource(taskID, conf, logger)
 	default:
 		return nil, fmt.Errorf("unsupported backup type: %s", conf.GetType())
 	}
@@ -0,0 +1,120 @@
+package exporter
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"backupgo/config"
+)
+
+type sqliteBackupSource struct {
+	taskID string
+	logger *slog.Logger
+	conf   config.SQLiteBackupConfig
+}
+
+// PrepareData 使用 SQLite 在线备份 API（或 VACUUM INTO）生成一致性快照，
+// 不直接复制正在写入的数据库文件，避免漏掉 WAL 中的数据或得到损坏的副本。
+func (s sqliteBackupSource) PrepareData(ctx context.Context) (*PreparedData, error) {
+	prepared, err := newPreparedData(s.taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("sqlite export started", "vacuum", s.conf.Vacuum)
+
+	for _, db := range s.conf.Databases {
+		targetFile := filepath.Join(prepared.Path, sqliteBackupFileName(db))
+		s.logger.Info("sqlite database export started", "database", db, "target_file", targetFile)
+
+		err := runCommandToPath(ctx, sqliteContainer(s.conf), s.taskID, targetFile, func(outputPath string) commandSpec {
+			return buildSQLiteBackupCommand(s.conf, db, outputPath)
+		})
+		if err != nil {
+			_ = prepared.Cleanup()
+			s.logger.Error("sqlite database export failed", "database", db, "error", err)
+			return nil, err
+		}
+	}
+
+	s.logger.Info("sqlite export completed")
+	return prepared, nil
+}
+
+func (s sqliteBackupSource) RestoreData(ctx context.Context, dataDir string, opts RestoreOptions) error {
+	for _, db := range s.conf.Databases {
+		sourceFile := filepath.Join(dataDir, sqliteBackupFileName(db))
+		targetDB := db + opts.NameSuffix
+		s.logger.Info("sqlite database restore started", "database", targetDB, "source_file", sourceFile)
+
+		err := runCommandFromPath(ctx, sqliteContainer(s.conf), s.taskID, sourceFile, func(inputPath string) commandSpec {
+			return buildSQLiteRestoreCommand(s.conf, targetDB, inputPath)
+		})
+		if err != nil {
+			s.logger.Error("sqlite database restore failed", "database", targetDB, "error", err)
+			return err
+		}
+	}
+
+	s.logger.Info("sqlite restore completed")
+	return nil
+}
+
+// CheckSource 检查 sqlite3 是否可执行，并以只读方式打开每个数据库读取表结构。
+func (s sqliteBackupSource) CheckSource(ctx context.Context) []CheckResult {
+	results := []CheckResult{checkExecutable(ctx, sqliteContainer(s.conf), "sqlite3")}
+	for _, db := range s.conf.Databases {
+		results = append(results, CheckResult{
+			Name: "open database " + db,
+			Err:  runCommand(ctx, buildSQLiteCheckCommand(s.conf, db)),
+		})
+	}
+	return results
+}
+
+func buildSQLiteBackupCommand(conf config.SQLiteBackupConfig, database string, outputPath string) commandSpec {
+	statement := ".backup " + quoteSQLiteArgument(outputPath)
+	if conf.Vacuum {
+		statement = "VACUUM INTO '" + strings.ReplaceAll(outputPath, "'", "''") + "'"
+	}
+
+	return sqliteCommand(conf, []string{"-bail", database, statement})
+}
+
+// buildSQLiteRestoreCommand 使用在线备份 API 的 .restore 覆盖目标库，目标库不存在时会自动创建。
+func buildSQLiteRestoreCommand(conf config.SQLiteBackupConfig, database string, inputPath string) commandSpec {
+	return sqliteCommand(conf, []string{"-bail", database, ".restore " + quoteSQLiteArgument(inputPath)})
+}
+
+// buildSQLiteCheckCommand 以只读方式打开数据库，文件不存在时报错而不是创建新库。
+func buildSQLiteCheckCommand(conf config.SQLiteBackupConfig, database string) commandSpec {
+	return sqliteCommand(conf, []string{"-bail", "-readonly", database, "SELECT count(*) FROM sqlite_master"})
+}
+
+func sqliteCommand(conf config.SQLiteBackupConfig, args []string) commandSpec {
+	if conf.GetMode() == config.ExecModeDocker {
+		return dockerExecCommand(conf.Container, "sqlite3", nil, args)
+	}
+
+	return commandSpec{Name: "sqlite3", Args: args}
+}
+
+func sqliteContainer(conf config.SQLiteBackupConfig) string {
+	if conf.GetMode() == config.ExecModeDocker {
+		return conf.Container
+	}
+	return ""
+}
+
+// quoteSQLiteArgument 按 sqlite3 命令行点命令的规则给参数加双引号。
+func quoteSQLiteArgument(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+}
+
+// sqliteBackupFileName 用完整路径生成文件名，避免不同目录下的同名数据库互相覆盖。
+func sqliteBackupFileName(database string) string {
+	return sanitizeDumpFileName(database)
+}
@@ -0,0 +1,75 @@
+package exporter
+
+import (
+	"backupgo/config"
+	"context"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildSQLiteBackupCommand(t *testing.T) {
+	spec := buildSQLiteBackupCommand(config.SQLiteBackupConfig{}, "/data/app.db", "/tmp/out dir/data_app.db")
+
+	wantArgs := []string{"-bail", "/data/app.db", `.backup "/tmp/out dir/data_app.db"`}
+	if spec.Name != "sqlite3" || !reflect.DeepEqual(spec.Args, wantArgs) {
+		t.Fatalf("unexpected command: %s %#v", spec.Name, spec.Args)
+	}
+}
+
+func TestBuildSQLiteBackupCommandVacuumDocker(t *testing.T) {
+	spec := buildSQLiteBackupCommand(config.SQLiteBackupConfig{
+		Mode:      config.ExecModeDocker,
+		Container: "picstash",
+		Vacuum:    true,
+	}, "/data/app.db", "/tmp/it's.db")
+
+	wantArgs := []string{"exec", "-i", "picstash", "sqlite3", "-bail", "/data/app.db", "VACUUM INTO '/tmp/it''s.db'"}
+	if !reflect.DeepEqual(spec.Args, wantArgs) {
+		t.Fatalf("unexpected args: %#v", spec.Args)
+	}
+}
+
+func TestSQLiteBackupFileName(t *testing.T) {
+	if got := sqliteBackupFileName("/var/lib/picstash/app.db"); got != "var_lib_picstash_app.db" {
+		t.Fatalf("unexpected backup file name: %s", got)
+	}
+}
+
+func TestSQLiteSourceBackupAndRestore(t *testing.T) {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		t.Skip("sqlite3 not installed")
+	}
+
+	dbFile := filepath.Join(t.TempDir(), "app.db")
+	if err := exec.Command("sqlite3", dbFile, "CREATE TABLE items(name TEXT); INSERT INTO items VALUES ('photo');").Run(); err != nil {
+		t.Fatalf("create database: %v", err)
+	}
+
+	source := sqliteBackupSource{taskID: "picstash", logger: slog.Default(), conf: config.SQLiteBackupConfig{Databases: []string{dbFile}}}
+	prepared, err := source.PrepareData(context.Background())
+	if err != nil {
+		t.Fatalf("PrepareData returned error: %v", err)
+	}
+	defer prepared.Cleanup()
+
+	if _, err := os.Stat(filepath.Join(prepared.Path, sqliteBackupFileName(dbFile))); err != nil {
+		t.Fatalf("expected backup file: %v", err)
+	}
+
+	if err := source.RestoreData(context.Background(), prepared.Path, RestoreOptions{NameSuffix: ".restored"}); err != nil {
+		t.Fatalf("RestoreData returned error: %v", err)
+	}
+
+	output, err := exec.Command("sqlite3", dbFile+".restored", "SELECT name FROM items").Output()
+	if err != nil {
+		t.Fatalf("query restored database: %v", err)
+	}
+	if strings.TrimSpace(string(output)) != "photo" {
+		t.Fatalf("unexpected restored data: %q", output)
+	}
+}
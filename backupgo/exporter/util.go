@@ -2,13 +2,16 @@ package exporter
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type commandSpec struct {
@@ -19,17 +22,27 @@ type commandSpec struct {
 
 var dumpFileNameCleaner = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
 
-func runCommandToFile(spec commandSpec, targetFile string) error {
+// commandWaitDelay 是 ctx 取消、命令被终止后等待输出管道关闭的最长时间。
+// 命令派生的子进程可能继续持有管道，不设置时 Wait 会一直阻塞到子进程退出。
+const commandWaitDelay = 5 * time.Second
+
+func newCommand(ctx context.Context, spec commandSpec) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, spec.Name, spec.Args...)
+	if len(spec.Env) > 0 {
+		cmd.Env = append(os.Environ(), spec.Env...)
+	}
+	cmd.WaitDelay = commandWaitDelay
+	return cmd
+}
+
+func runCommandToFile(ctx context.Context, spec commandSpec, targetFile string) error {
 	file, err := os.Create(targetFile)
 	if err != nil {
 		return fmt.Errorf("create target file failed: %w", err)
 	}
 	defer file.Close()
 
-	cmd := exec.Command(spec.Name, spec.Args...)
-	if len(spec.Env) > 0 {
-		cmd.Env = append(os.Environ(), spec.Env...)
-	}
+	cmd := newCommand(ctx, spec)
 	cmd.Stdout = file
 
 	var stderr bytes.Buffer
@@ -37,37 +50,131 @@ func runCommandToFile(spec commandSpec, targetFile string) error {
 
 	if err := cmd.Run(); err != nil {
 		_ = os.Remove(targetFile)
-		message := strings.TrimSpace(stderr.String())
-		if message != "" {
-			return fmt.Errorf("%w: %s", err, message)
-		}
-		return fmt.Errorf("%w: command failed without stderr output", err)
+		return commandError(err, &stderr)
 	}
 
 	return nil
 }
 
-func runCommand(spec commandSpec) error {
-	cmd := exec.Command(spec.Name, spec.Args...)
-	if len(spec.Env) > 0 {
-		cmd.Env = append(os.Environ(), spec.Env...)
+// runCommandFromFile 执行命令并把 sourceFile 作为标准输入。
+func runCommandFromFile(ctx context.Context, spec commandSpec, sourceFile string) error {
+	file, err := os.Open(sourceFile)
+	if err != nil {
+		return fmt.Errorf("open source file failed: %w", err)
+	}
+	defer file.Close()
+
+	cmd := newCommand(ctx, spec)
+	cmd.Stdin = file
+	cmd.Stdout = io.Discard
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return commandError(err, &stderr)
 	}
+
+	return nil
+}
+
+func runCommand(ctx context.Context, spec commandSpec) error {
+	cmd := newCommand(ctx, spec)
 	cmd.Stdout = io.Discard
 
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
-		message := strings.TrimSpace(stderr.String())
-		if message != "" {
-			return fmt.Errorf("%w: %s", err, message)
+		return commandError(err, &stderr)
+	}
+
+	return nil
+}
+
+// runCommandOutput 执行命令并返回标准输出。
+func runCommandOutput(ctx context.Context, spec commandSpec) (string, error) {
+	cmd := newCommand(ctx, spec)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", commandError(err, &stderr)
+	}
+
+	return stdout.String(), nil
+}
+
+// commandReader 把命令的标准输出作为 io.ReadCloser，读到 EOF 时返回命令的退出错误，
+// 避免导出命令中途失败时把不完整的数据当成成功的备份。
+type commandReader struct {
+	cmd    *exec.Cmd
+	stdout io.ReadCloser
+	stderr bytes.Buffer
+	waited bool
+	err    error
+}
+
+func startCommandReader(ctx context.Context, spec commandSpec) (io.ReadCloser, error) {
+	cmd := newCommand(ctx, spec)
+
+	reader := &commandReader{cmd: cmd}
+	cmd.Stderr = &reader.stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("create stdout pipe failed: %w", err)
+	}
+	reader.stdout = stdout
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start command %s failed: %w", spec.Name, err)
+	}
+
+	return reader, nil
+}
+
+func (r *commandReader) Read(p []byte) (int, error) {
+	n, err := r.stdout.Read(p)
+	if err == io.EOF {
+		if waitErr := r.wait(); waitErr != nil {
+			return n, waitErr
 		}
-		return fmt.Errorf("%w: command failed without stderr output", err)
 	}
+	return n, err
+}
 
+// Close 在数据没有读完时终止命令，保证不会留下后台进程。
+func (r *commandReader) Close() error {
+	if !r.waited {
+		_ = r.cmd.Process.Kill()
+		_ = r.wait()
+	}
 	return nil
 }
 
+func (r *commandReader) wait() error {
+	if r.waited {
+		return r.err
+	}
+
+	r.waited = true
+	if err := r.cmd.Wait(); err != nil {
+		r.err = commandError(err, &r.stderr)
+	}
+	return r.err
+}
+
+func commandError(err error, stderr *bytes.Buffer) error {
+	message := strings.TrimSpace(stderr.String())
+	if message != "" {
+		return fmt.Errorf("%w: %s", err, message)
+	}
+	return fmt.Errorf("%w: command failed without stderr output", err)
+}
+
 func sanitizeDumpFileName(value string) string {
 	value = dumpFileNameCleaner.ReplaceAllString(value, "_")
 	value = strings.Trim(value, "._-")
@@ -104,3 +211,47 @@ func dockerExecCommand(container string, executable string, env []string, args [
 
 	return commandSpec{Name: "docker", Args: dockerArgs}
 }
+
+// runCommandToPath 执行把结果写到指定路径的导出命令。container 非空时命令在容器内执行，
+// 先写到容器内的临时文件，再通过 docker cp 复制到 targetFile。
+func runCommandToPath(ctx context.Context, container string, taskID string, targetFile string, build func(outputPath string) commandSpec) error {
+	if container == "" {
+		return runCommand(ctx, build(targetFile))
+	}
+
+	containerFile := dockerTempPath(taskID, filepath.Base(targetFile))
+	removeTemp := dockerExecCommand(container, "rm", nil, []string{"-f", containerFile})
+	_ = runCommand(ctx, removeTemp)
+	// 任务被取消时也要清理容器内的临时文件
+	defer func() { _ = runCommand(context.WithoutCancel(ctx), removeTemp) }()
+
+	if err := runCommand(ctx, build(containerFile)); err != nil {
+		return err
+	}
+	return runCommand(ctx, dockerCopyCommand(container+":"+containerFile, targetFile))
+}
+
+// runCommandFromPath 与 runCommandToPath 相反，container 非空时先把 sourceFile 复制到容器内再执行命令。
+func runCommandFromPath(ctx context.Context, container string, taskID string, sourceFile string, build func(inputPath string) commandSpec) error {
+	if container == "" {
+		return runCommand(ctx, build(sourceFile))
+	}
+
+	containerFile := dockerTempPath(taskID, filepath.Base(sourceFile))
+	defer func() {
+		_ = runCommand(context.WithoutCancel(ctx), dockerExecCommand(container, "rm", nil, []string{"-f", containerFile}))
+	}()
+
+	if err := runCommand(ctx, dockerCopyCommand(sourceFile, container+":"+containerFile)); err != nil {
+		return err
+	}
+	return runCommand(ctx, build(containerFile))
+}
+
+func dockerCopyCommand(source string, target string) commandSpec {
+	return commandSpec{Name: "docker", Args: []string{"cp", source, target}}
+}
+
+func dockerTempPath(taskID string, fileName string) string {
+	return "/tmp/backupgo-" + sanitizeDumpFileName(taskID) + "-" + fileName
+}
@@ -0,0 +1,68 @@
+package exporter
+
+import (
+	"backupgo/config"
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommandReaderReadsOutput(t *testing.T) {
+	reader, err := startCommandReader(context.Background(), commandSpec{Name: "sh", Args: []string{"-c", "printf backupgo"}})
+	if err != nil {
+		t.Fatalf("startCommandReader returned error: %v", err)
+	}
+	defer reader.Close()
+
+	output, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("read command output: %v", err)
+	}
+	if string(output) != "backupgo" {
+		t.Fatalf("unexpected output: %q", output)
+	}
+}
+
+func TestCommandReaderReturnsExitError(t *testing.T) {
+	reader, err := startCommandReader(context.Background(), commandSpec{Name: "sh", Args: []string{"-c", "printf partial; echo dump failed >&2; exit 3"}})
+	if err != nil {
+		t.Fatalf("startCommandReader returned error: %v", err)
+	}
+	defer reader.Close()
+
+	_, err = io.ReadAll(reader)
+	if err == nil || !strings.Contains(err.Error(), "dump failed") {
+		t.Fatalf("expected command error with stderr, got %v", err)
+	}
+}
+
+func TestRunCommandStopsWhenContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	if err := runCommand(ctx, commandSpec{Name: "sh", Args: []string{"-c", "exec sleep 10"}}); err == nil {
+		t.Fatal("expected runCommand to fail after context is canceled")
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Fatalf("command was not stopped, took %s", elapsed)
+	}
+}
+
+func TestPrepareStreamUsesDumpFileLayout(t *testing.T) {
+	prepared, err := postgresBackupSource{
+		taskID: "app db",
+		logger: slog.Default(),
+		conf:   config.PostgresBackupConfig{Databases: []string{"main"}},
+	}.PrepareStream(context.Background())
+	if err != nil {
+		t.Fatalf("PrepareStream returned error: %v", err)
+	}
+
+	if len(prepared.Streams) != 1 || prepared.Streams[0].Name != "app_db/main.dump" {
+		t.Fatalf("unexpected streams: %+v", prepared.Streams)
+	}
+}
@@ -0,0 +1,32 @@
+package exporter
+
+import (
+	"context"
+	"log/slog"
+
+	"backupgo/config"
+)
+
+// Verifier 由能用数据源自带工具检查备份产物的备份源实现，检查过程不会写入数据源。
+type Verifier interface {
+	// VerifyData 检查 dataDir 中的备份产物能否被恢复工具正常读取。
+	VerifyData(ctx context.Context, dataDir string) error
+}
+
+// Verify 检查解压到 extractDir 的备份产物，备份源不支持检查时返回 false。
+func Verify(ctx context.Context, taskID string, conf config.BackupConfig, extractDir string, logger *slog.Logger) (bool, error) {
+	source, err := New(taskID, conf, logger)
+	if err != nil {
+		return false, err
+	}
+
+	verifier, ok := source.(Verifier)
+	if !ok {
+		return false, nil
+	}
+	if composite, ok := source.(compositeSource); ok && !composite.canVerify() {
+		return false, nil
+	}
+
+	return true, verifier.VerifyData(ctx, RestoreDataDir(taskID, extractDir))
+}
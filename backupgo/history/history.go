@@ -0,0 +1,188 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Record 是一次任务运行的记录
+type Record struct {
+	TaskID     string    `json:"task_id"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Duration   float64   `json:"duration_seconds"`
+	Status     string    `json:"status"`
+	// Size 是上传的字节数，增量备份为新增内容的大小
+	Size   int64    `json:"size"`
+	Bucket string   `json:"bucket,omitempty"`
+	Key    string   `json:"key,omitempty"`
+	Mode   string   `json:"mode,omitempty"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+// mu 保护同一进程内并发运行的任务同时写历史文件，
+// 调度器和单独执行的 backupgo backup 之间靠 path + ".lock" 上的文件锁互斥
+var mu sync.Mutex
+
+// Append 把记录追加到 path，该任务的记录超过 keep 条时删除最旧的记录；keep <= 0 表示不限制。
+func Append(path string, record Record, keep int) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("encode history record failed: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create history dir failed: %w", err)
+	}
+
+	// prune 会重写并替换历史文件，锁加在单独的文件上，替换后其他进程仍然锁的是同一个文件
+	unlock, err := acquireLock(path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("open history file failed: %w", err)
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("write history file failed: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close history file failed: %w", err)
+	}
+
+	if keep <= 0 {
+		return nil
+	}
+	return prune(path, record.TaskID, keep)
+}
+
+// acquireLock 打开并锁住 lockPath，返回释放锁的函数。
+func acquireLock(lockPath string) (func(), error) {
+	file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open history lock failed: %w", err)
+	}
+	if err := lockFile(file); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("lock history file failed: %w", err)
+	}
+	return func() {
+		_ = unlockFile(file)
+		_ = file.Close()
+	}, nil
+}
+
+// Load 按时间从旧到新读取记录，taskID 为空时返回全部任务的记录。文件不存在时返回空结果。
+func Load(path string, taskID string) ([]Record, error) {
+	records, err := readAll(path)
+	if err != nil {
+		return nil, err
+	}
+	if taskID == "" {
+		return records, nil
+	}
+
+	filtered := make([]Record, 0, len(records))
+	for _, record := range records {
+		if record.TaskID == taskID {
+			filtered = append(filtered, record)
+		}
+	}
+	return filtered, nil
+}
+
+// readAll 读取全部记录，跳过无法解析的行（例如进程中断时写了一半的最后一行）。
+func readAll(path string) ([]Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open history file failed: %w", err)
+	}
+	defer file.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read history file failed: %w", err)
+	}
+
+	return records, nil
+}
+
+// prune 在任务记录超过 keep 条时重写历史文件，只保留该任务最新的 keep 条。
+func prune(path string, taskID string, keep int) error {
+	records, err := readAll(path)
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	for _, record := range records {
+		if record.TaskID == taskID {
+			count++
+		}
+	}
+	if count <= keep {
+		return nil
+	}
+
+	drop := count - keep
+	kept := make([]Record, 0, len(records)-drop)
+	for _, record := range records {
+		if record.TaskID == taskID && drop > 0 {
+			drop--
+			continue
+		}
+		kept = append(kept, record)
+	}
+
+	return rewrite(path, kept)
+}
+
+func rewrite(path string, records []Record) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-")
+	if err != nil {
+		return fmt.Errorf("create temp history file failed: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	writer := bufio.NewWriter(tmpFile)
+	encoder := json.NewEncoder(writer)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			_ = tmpFile.Close()
+			return fmt.Errorf("write temp history file failed: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		_ = tmpFile.Close()
+		return fmt.Errorf("write temp history file failed: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("close temp history file failed: %w", err)
+	}
+
+	return os.Rename(tmpFile.Name(), path)
+}
@@ -0,0 +1,130 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAppendAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "history.jsonl")
+	startedAt := time.Date(2024, 3, 8, 0, 25, 0, 0, time.UTC)
+
+	records := []Record{
+		{TaskID: "app", StartedAt: startedAt, Status: "success", Size: 1024, Key: "app_2024_03_08.zip", Mode: "NORMAL"},
+		{TaskID: "pg", StartedAt: startedAt, Status: "failed", Errors: []string{"上传失败"}},
+	}
+	for _, record := range records {
+		if err := Append(path, record, 10); err != nil {
+			t.Fatalf("Append returned error: %v", err)
+		}
+	}
+
+	all, err := Load(path, "")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if len(all) != 2 || all[0].Key != "app_2024_03_08.zip" || !all[0].StartedAt.Equal(startedAt) {
+		t.Fatalf("unexpected records: %+v", all)
+	}
+
+	pg, err := Load(path, "pg")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if len(pg) != 1 || pg[0].Errors[0] != "上传失败" {
+		t.Fatalf("unexpected pg records: %+v", pg)
+	}
+}
+
+func TestAppendKeepsLatestRecordsPerTask(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+
+	for i := 1; i <= 5; i++ {
+		if err := Append(path, Record{TaskID: "app", Size: int64(i)}, 3); err != nil {
+			t.Fatalf("Append returned error: %v", err)
+		}
+		if err := Append(path, Record{TaskID: "pg", Size: int64(i)}, 0); err != nil {
+			t.Fatalf("Append returned error: %v", err)
+		}
+	}
+
+	app, err := Load(path, "app")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if len(app) != 3 || app[0].Size != 3 || app[2].Size != 5 {
+		t.Fatalf("unexpected app records: %+v", app)
+	}
+
+	pg, err := Load(path, "pg")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if len(pg) != 5 {
+		t.Fatalf("expected pg records to be untouched, got %d", len(pg))
+	}
+}
+
+func TestLoadSkipsBrokenLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	content := `{"task_id":"app","status":"success"}` + "\n" + `{"task_id":"app","sta`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write history: %v", err)
+	}
+
+	records, err := Load(path, "app")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("unexpected records: %+v", records)
+	}
+
+	missing, err := Load(filepath.Join(t.TempDir(), "missing.jsonl"), "")
+	if err != nil || len(missing) != 0 {
+		t.Fatalf("expected empty history for missing file, got %v, err: %v", missing, err)
+	}
+}
+
+// 调度器和单独执行的 backupgo backup 会同时写同一个历史文件
+func TestAppendFromSeveralProcesses(t *testing.T) {
+	if path := os.Getenv("BACKUPGO_HISTORY_PATH"); path != "" {
+		taskID := os.Getenv("BACKUPGO_HISTORY_TASK")
+		for i := 0; i < 40; i++ {
+			if err := Append(path, Record{TaskID: taskID, Size: int64(i)}, 10); err != nil {
+				t.Fatalf("Append returned error: %v", err)
+			}
+		}
+		return
+	}
+
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	var cmds []*exec.Cmd
+	for i := 0; i < 4; i++ {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestAppendFromSeveralProcesses$")
+		cmd.Env = append(os.Environ(), "BACKUPGO_HISTORY_PATH="+path, fmt.Sprintf("BACKUPGO_HISTORY_TASK=task-%d", i))
+		if err := cmd.Start(); err != nil {
+			t.Fatalf("start helper process: %v", err)
+		}
+		cmds = append(cmds, cmd)
+	}
+	for _, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			t.Fatalf("helper process failed: %v", err)
+		}
+	}
+
+	for i := 0; i < 4; i++ {
+		records, err := Load(path, fmt.Sprintf("task-%d", i))
+		if err != nil {
+			t.Fatalf("Load returned error: %v", err)
+		}
+		if len(records) != 10 || records[9].Size != 39 {
+			t.Fatalf("records of task-%d lost: %+v", i, records)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package history
+
+import "os"
+
+// 其他平台不加文件锁，只靠 mu 保护同一进程内的写入
+func lockFile(file *os.File) error {
+	return nil
+}
+
+func unlockFile(file *os.File) error {
+	return nil
+}
@@ -0,0 +1,220 @@
+package manifest
+
+import (
+	"backupgo/config"
+	"backupgo/oss"
+	"backupgo/utils"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	manifestVersion = 1
+
+	// Suffix 追加在备份对象 key 后面，清单与备份放在同一目录
+	Suffix = ".manifest.json"
+
+	// FormatSnapshot 是增量备份清单的格式，备份对象为快照清单
+	FormatSnapshot = "snapshot"
+)
+
+// ErrNotFound 表示备份没有对应的清单，例如在引入清单之前上传的备份
+var ErrNotFound = errors.New("manifest not found")
+
+// Manifest 描述一个备份对象的内容，不加密，不下载备份也能知道里面有什么。
+type Manifest struct {
+	Version   int      `json:"version"`
+	TaskID    string   `json:"task_id"`
+	Type      string   `json:"type"`
+	Databases []string `json:"databases,omitempty"`
+	// Key 是备份对象的 key，Size 和 SHA256 是上传的对象（加密后）的大小和摘要
+	Key        string `json:"key"`
+	Format     string `json:"format"`
+	Encryption string `json:"encryption,omitempty"`
+	Size       int64  `json:"size"`
+	SHA256     string `json:"sha256"`
+	// Files 是备份中的文件及其原始大小
+	Files           []File    `json:"files"`
+	Host            string    `json:"host"`
+	BackupgoVersion string    `json:"backupgo_version"`
+	StartedAt       time.Time `json:"started_at"`
+	FinishedAt      time.Time `json:"finished_at"`
+}
+
+// File 是备份中的一个文件，Path 与备份中的条目路径一致
+type File struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// Key 返回备份对象对应的清单 key。
+func Key(archiveKey string) string {
+	return archiveKey + Suffix
+}
+
+// IsKey 判断对象 key 是否为备份清单。
+func IsKey(key string) bool {
+	return strings.HasSuffix(key, Suffix)
+}
+
+// ArchiveKey 返回清单对应的备份对象 key。
+func ArchiveKey(manifestKey string) string {
+	return strings.TrimSuffix(manifestKey, Suffix)
+}
+
+// New 返回任务的清单，备份对象相关的字段由调用方填写。
+func New(conf config.BackupConfig, host string, version string) Manifest {
+	m := Manifest{
+		Version:         manifestVersion,
+		TaskID:          conf.GetID(),
+		Type:            conf.GetType(),
+		Databases:       databases(conf),
+		Format:          conf.GetArchive().GetFormat(),
+		Host:            host,
+		BackupgoVersion: version,
+	}
+	if conf.Encryption != nil {
+		m.Encryption = conf.Encryption.GetType()
+	}
+	return m
+}
+
+// databases 返回任务导出的数据库，组合备份中的数据库以 <name>/<database> 表示。
+func databases(conf config.BackupConfig) []string {
+	var names []string
+	switch {
+	case conf.Postgres != nil:
+		names = conf.Postgres.Databases
+	case conf.MongoDB != nil:
+		names = conf.MongoDB.Databases
+	case conf.MySQL != nil:
+		names = conf.MySQL.Databases
+	case conf.SQLite != nil:
+		names = conf.SQLite.Databases
+	}
+	names = append([]string(nil), names...)
+
+	for _, source := range conf.Sources {
+		for _, database := range databases(source.BackupConfig(conf.GetID())) {
+			names = append(names, path.Join(source.GetName(), database))
+		}
+	}
+	return names
+}
+
+// HashFile 返回文件的大小和 SHA-256 摘要。
+func HashFile(file string) (int64, string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return 0, "", fmt.Errorf("open %s failed: %w", file, err)
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return 0, "", fmt.Errorf("hash %s failed: %w", file, err)
+	}
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Upload 把清单上传到 m.Key 对应的清单 key。
+func Upload(ctx context.Context, storage oss.Storage, m Manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode manifest failed: %w", err)
+	}
+
+	tmpFile, err := os.CreateTemp("", "backupgo-manifest-")
+	if err != nil {
+		return fmt.Errorf("create manifest file failed: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	_, err = tmpFile.Write(append(data, '\n'))
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("write manifest file failed: %w", err)
+	}
+
+	key := Key(m.Key)
+	if _, err := storage.Upload(ctx, key, tmpFile.Name()); err != nil {
+		return fmt.Errorf("upload manifest %s failed: %w", key, err)
+	}
+	return nil
+}
+
+// Read 读取备份对象 archiveKey 的清单，清单不存在时返回 ErrNotFound。
+func Read(ctx context.Context, storage oss.Storage, archiveKey string) (*Manifest, error) {
+	m, err := download(ctx, storage, Key(archiveKey))
+	if errors.Is(err, oss.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	return m, err
+}
+
+func download(ctx context.Context, storage oss.Storage, key string) (*Manifest, error) {
+	tempDir, err := os.MkdirTemp("", "backupgo-manifest-")
+	if err != nil {
+		return nil, fmt.Errorf("create temp dir failed: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	localFile := filepath.Join(tempDir, path.Base(key))
+	if err := storage.Download(ctx, key, localFile); err != nil {
+		return nil, fmt.Errorf("download manifest %s failed: %w", key, err)
+	}
+
+	file, err := os.Open(localFile)
+	if err != nil {
+		return nil, fmt.Errorf("open manifest failed: %w", err)
+	}
+	defer file.Close()
+
+	var m Manifest
+	if err := json.NewDecoder(file).Decode(&m); err != nil {
+		return nil, fmt.Errorf("decode manifest %s failed: %w", key, err)
+	}
+	if m.Version != manifestVersion {
+		return nil, fmt.Errorf("unsupported manifest version: %d", m.Version)
+	}
+	return &m, nil
+}
+
+// Latest 读取按 names 的 key 模板识别出的最新备份的清单，没有带清单的备份时返回 ErrNotFound。
+func Latest(ctx context.Context, storage oss.Storage, names *utils.FileNameProcessor) (*Manifest, error) {
+	objects, err := storage.ListObjects(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list objects failed: %w", err)
+	}
+
+	var latestKey string
+	var latestTime time.Time
+	for _, obj := range objects {
+		if !IsKey(obj.Key) {
+			continue
+		}
+		result, err := names.Parse(ArchiveKey(obj.Key))
+		if err != nil {
+			continue
+		}
+		if t := result.ToTime(); latestKey == "" || t.After(latestTime) || (t.Equal(latestTime) && obj.Key > latestKey) {
+			latestKey, latestTime = obj.Key, t
+		}
+	}
+	if latestKey == "" {
+		return nil, ErrNotFound
+	}
+	return download(ctx, storage, latestKey)
+}
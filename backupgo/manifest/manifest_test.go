@@ -0,0 +1,90 @@
+package manifest
+
+import (
+	"backupgo/config"
+	"backupgo/oss"
+	"backupgo/utils"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestUploadAndRead(t *testing.T) {
+	storage, err := oss.NewLocalStorage(config.LocalStorageConfig{Path: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewLocalStorage returned error: %v", err)
+	}
+
+	if _, err := Read(context.Background(), storage, "app_2024_03_10.zip"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	m := New(config.BackupConfig{ID: "app", Postgres: &config.PostgresBackupConfig{Databases: []string{"shop"}}}, "web-1", "v1.2.0")
+	m.Key = "app_2024_03_10.zip"
+	m.Files = []File{{Path: "shop.dump", Size: 42}}
+	if err := Upload(context.Background(), storage, m); err != nil {
+		t.Fatalf("Upload returned error: %v", err)
+	}
+
+	got, err := Read(context.Background(), storage, "app_2024_03_10.zip")
+	if err != nil {
+		t.Fatalf("Read returned error: %v", err)
+	}
+	if !reflect.DeepEqual(*got, m) {
+		t.Fatalf("unexpected manifest: %+v", got)
+	}
+	if got.Type != config.BackupTypePostgres || got.Format != "zip" || got.BackupgoVersion != "v1.2.0" {
+		t.Fatalf("unexpected manifest fields: %+v", got)
+	}
+}
+
+func TestLatest(t *testing.T) {
+	storage, err := oss.NewLocalStorage(config.LocalStorageConfig{Path: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewLocalStorage returned error: %v", err)
+	}
+
+	for _, key := range []string{"app_2024_03_09.zip", "app_2024_03_10.zip", "other_2024_03_11.zip"} {
+		if err := Upload(context.Background(), storage, Manifest{Version: manifestVersion, Key: key}); err != nil {
+			t.Fatalf("Upload returned error: %v", err)
+		}
+	}
+
+	names, err := utils.NewFileNameProcessor(utils.DefaultKeyTemplate, "app", "")
+	if err != nil {
+		t.Fatalf("NewFileNameProcessor returned error: %v", err)
+	}
+	m, err := Latest(context.Background(), storage, names)
+	if err != nil {
+		t.Fatalf("Latest returned error: %v", err)
+	}
+	if m.Key != "app_2024_03_10.zip" {
+		t.Fatalf("unexpected latest manifest: %s", m.Key)
+	}
+
+	other, err := utils.NewFileNameProcessor(utils.DefaultKeyTemplate, "db", "")
+	if err != nil {
+		t.Fatalf("NewFileNameProcessor returned error: %v", err)
+	}
+	if _, err := Latest(context.Background(), storage, other); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestHashFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(file, []byte("backupgo"), 0644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	size, sum, err := HashFile(file)
+	if err != nil {
+		t.Fatalf("HashFile returned error: %v", err)
+	}
+	if size != 8 || sum != "0d8551eeac6de0c1f6b831e4a078caac88c9eb1a155c6119ca4dce30898922a8" {
+		t.Fatalf("unexpected hash result: %d %s", size, sum)
+	}
+}
@@ -0,0 +1,72 @@
+package monitor
+
+import (
+	"backupgo/state"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type metric struct {
+	name  string
+	help  string
+	kind  string
+	value func(status taskStatus) float64
+}
+
+// taskMetrics 按任务输出的指标。从未运行或从未成功的任务时间戳为 0，
+// 便于用 time() - backupgo_task_last_success_timestamp_seconds 告警。
+var taskMetrics = []metric{
+	{"backupgo_task_next_run_timestamp_seconds", "Unix time of the next scheduled run.", "gauge", func(s taskStatus) float64 { return unixSeconds(s.NextRun) }},
+	{"backupgo_task_last_run_timestamp_seconds", "Unix time of the last run.", "gauge", func(s taskStatus) float64 { return unixSeconds(s.LastRun) }},
+	{"backupgo_task_last_success_timestamp_seconds", "Unix time of the last successful run.", "gauge", func(s taskStatus) float64 { return unixSeconds(s.LastSuccess) }},
+	{"backupgo_task_last_run_success", "Whether the last run succeeded (1) or failed (0).", "gauge", func(s taskStatus) float64 { return boolValue(s.LastStatus == state.StatusSuccess) }},
+	{"backupgo_task_last_duration_seconds", "Duration of the last run in seconds.", "gauge", func(s taskStatus) float64 { return s.LastDuration }},
+	{"backupgo_task_last_size_bytes", "Bytes uploaded by the last successful run.", "gauge", func(s taskStatus) float64 { return float64(s.LastSize) }},
+	{"backupgo_task_runs_total", "Total number of runs.", "counter", func(s taskStatus) float64 { return float64(s.Runs) }},
+	{"backupgo_task_failures_total", "Total number of failed runs.", "counter", func(s taskStatus) float64 { return float64(s.Failures) }},
+	{"backupgo_task_uploaded_bytes_total", "Total bytes uploaded by successful runs.", "counter", func(s taskStatus) float64 { return float64(s.UploadedBytes) }},
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	writeMetrics(w, s.taskStatuses())
+}
+
+// writeMetrics 以 Prometheus 文本格式输出指标。
+func writeMetrics(w io.Writer, statuses []taskStatus) {
+	for _, m := range taskMetrics {
+		fmt.Fprintf(w, "# HELP %s %s\n", m.name, m.help)
+		fmt.Fprintf(w, "# TYPE %s %s\n", m.name, m.kind)
+		for _, status := range statuses {
+			fmt.Fprintf(w, "%s{task=\"%s\",type=\"%s\"} %s\n", m.name, escapeLabel(status.ID), escapeLabel(status.Type), formatValue(m.value(status)))
+		}
+	}
+}
+
+func unixSeconds(t *time.Time) float64 {
+	if t == nil {
+		return 0
+	}
+	return float64(t.UnixMilli()) / 1000
+}
+
+func boolValue(v bool) float64 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
@@ -0,0 +1,180 @@
+package monitor
+
+import (
+	"backupgo/state"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Task 是注册到调度器的备份任务
+type Task struct {
+	ID       string
+	Type     string
+	Storage  string
+	Schedule string
+	EntryID  cron.EntryID
+}
+
+// Server 为调度进程提供 JSON 状态接口和 Prometheus 指标。
+type Server struct {
+	scheduler *cron.Cron
+	mu        sync.RWMutex
+	tasks     []Task
+	states    func() map[string]state.TaskState
+	server    *http.Server
+	logger    *slog.Logger
+}
+
+type taskStatus struct {
+	ID            string     `json:"id"`
+	Type          string     `json:"type"`
+	Storage       string     `json:"storage"`
+	Schedule      string     `json:"schedule"`
+	NextRun       *time.Time `json:"next_run,omitempty"`
+	LastRun       *time.Time `json:"last_run,omitempty"`
+	LastStatus    string     `json:"last_status,omitempty"`
+	LastSuccess   *time.Time `json:"last_success,omitempty"`
+	LastDuration  float64    `json:"last_duration_seconds"`
+	LastSize      int64      `json:"last_size_bytes"`
+	Runs          int64      `json:"runs"`
+	Failures      int64      `json:"failures"`
+	UploadedBytes int64      `json:"uploaded_bytes"`
+}
+
+func NewServer(addr string, scheduler *cron.Cron, tasks []Task) *Server {
+	s := &Server{
+		scheduler: scheduler,
+		tasks:     tasks,
+		states:    state.GetState().Tasks,
+		logger:    slog.Default().With("component", "http"),
+	}
+	s.server = &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// Start 监听端口并在后台处理请求，端口被占用等错误会直接返回。
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		return fmt.Errorf("listen %s failed: %w", s.server.Addr, err)
+	}
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error("http server stopped", "error", err)
+		}
+	}()
+
+	s.logger.Info("http server started", "addr", listener.Addr().String())
+	return nil
+}
+
+// SetTasks 在配置重新加载后替换任务列表
+func (s *Server) SetTasks(tasks []Task) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tasks = tasks
+}
+
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", s.handleHealth)
+	mux.HandleFunc("GET /api/tasks", s.handleTasks)
+	mux.HandleFunc("GET /api/tasks/{id}", s.handleTask)
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+	return mux
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte("ok\n"))
+}
+
+func (s *Server) handleTasks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.taskStatuses())
+}
+
+func (s *Server) handleTask(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	for _, status := range s.taskStatuses() {
+		if status.ID == id {
+			writeJSON(w, http.StatusOK, status)
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusNotFound, map[string]string{"error": "task not found: " + id})
+}
+
+func (s *Server) taskStatuses() []taskStatus {
+	s.mu.RLock()
+	tasks := s.tasks
+	s.mu.RUnlock()
+
+	states := s.states()
+	statuses := make([]taskStatus, 0, len(tasks))
+	for _, task := range tasks {
+		status := taskStatus{
+			ID:       task.ID,
+			Type:     task.Type,
+			Storage:  task.Storage,
+			Schedule: task.Schedule,
+			NextRun:  optionalTime(s.nextRun(task)),
+		}
+
+		if taskState, ok := states[task.ID]; ok {
+			status.LastRun = optionalTime(taskState.LastRun)
+			status.LastStatus = taskState.LastStatus
+			status.LastSuccess = optionalTime(taskState.LastSuccess)
+			status.LastDuration = taskState.LastDuration
+			status.LastSize = taskState.LastSize
+			status.Runs = taskState.Runs
+			status.Failures = taskState.Failures
+			status.UploadedBytes = taskState.UploadedBytes
+		}
+
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// nextRun 返回任务下一次触发时间，调度器尚未启动时为零值。
+func (s *Server) nextRun(task Task) time.Time {
+	if s.scheduler == nil {
+		return time.Time{}
+	}
+	return s.scheduler.Entry(task.EntryID).Next
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func writeJSON(w http.ResponseWriter, code int, value any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(value)
+}
@@ -0,0 +1,92 @@
+package monitor
+
+import (
+	"backupgo/state"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestServer() *Server {
+	lastRun := time.Date(2024, 3, 8, 0, 25, 0, 0, time.UTC)
+	return &Server{
+		tasks: []Task{
+			{ID: "app", Type: "path", Storage: "oss", Schedule: "0 25 0 * * ?"},
+			{ID: "pg", Type: "postgres", Storage: "nas", Schedule: "0 40 0 * * ?"},
+		},
+		states: func() map[string]state.TaskState {
+			return map[string]state.TaskState{
+				"app": {
+					LastRun:       lastRun,
+					LastStatus:    state.StatusSuccess,
+					LastSuccess:   lastRun,
+					LastDuration:  12.5,
+					LastSize:      2048,
+					Runs:          3,
+					Failures:      1,
+					UploadedBytes: 4096,
+				},
+			}
+		},
+	}
+}
+
+func TestHandleTasks(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	newTestServer().Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/tasks", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: %d", recorder.Code)
+	}
+
+	var statuses []taskStatus
+	if err := json.Unmarshal(recorder.Body.Bytes(), &statuses); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(statuses) != 2 {
+		t.Fatalf("unexpected tasks: %+v", statuses)
+	}
+	if statuses[0].LastStatus != state.StatusSuccess || statuses[0].LastSize != 2048 || statuses[0].LastSuccess == nil {
+		t.Fatalf("unexpected app status: %+v", statuses[0])
+	}
+	if statuses[1].LastRun != nil || statuses[1].Runs != 0 {
+		t.Fatalf("unexpected pg status: %+v", statuses[1])
+	}
+}
+
+func TestHandleTaskNotFound(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	newTestServer().Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/tasks/missing", nil))
+
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status code: %d", recorder.Code)
+	}
+}
+
+func TestHandleMetrics(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	newTestServer().Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := recorder.Body.String()
+	for _, want := range []string{
+		"# TYPE backupgo_task_failures_total counter\n",
+		`backupgo_task_last_success_timestamp_seconds{task="app",type="path"} 1709857500` + "\n",
+		`backupgo_task_last_success_timestamp_seconds{task="pg",type="postgres"} 0` + "\n",
+		`backupgo_task_last_run_success{task="app",type="path"} 1` + "\n",
+		`backupgo_task_last_duration_seconds{task="app",type="path"} 12.5` + "\n",
+		`backupgo_task_uploaded_bytes_total{task="app",type="path"} 4096` + "\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("metrics missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	if got := escapeLabel("a\"b\\c\nd"); got != `a\"b\\c\nd` {
+		t.Fatalf("unexpected escaped label: %s", got)
+	}
+}
@@ -0,0 +1,32 @@
+package notice
+
+// discordMaxContentLength Discord 单条消息的最大字符数
+const discordMaxContentLength = 2000
+
+// DiscordNotifier 通过 Discord webhook 发送消息。
+type DiscordNotifier struct {
+	webhookURL string
+}
+
+func NewDiscordNotifier(webhookURL string) *DiscordNotifier {
+	return &DiscordNotifier{webhookURL: webhookURL}
+}
+
+func (n *DiscordNotifier) IsAvailable() bool {
+	return n.webhookURL != ""
+}
+
+func (n *DiscordNotifier) GetName() string {
+	return "Discord"
+}
+
+func (n *DiscordNotifier) GetFormatType() FormatType {
+	return FormatTypeMarkdown
+}
+
+func (n *DiscordNotifier) Send(msg string) error {
+	if runes := []rune(msg); len(runes) > discordMaxContentLength {
+		msg = string(runes[:discordMaxContentLength])
+	}
+	return postJSON(n.webhookURL, nil, map[string]string{"content": msg})
+}
@@ -15,13 +15,39 @@ func NewManagerFromConfig(cfg config.GlobalConfig) *NoticeManager {
 	if cfg.Notice.Telegram != nil {
 		telegramConfig := cfg.Notice.Telegram
 		tgBot := utils.NewTgBot(telegramConfig.BotToken)
-		manager.AddNotifier(NewTGNotifier(&tgBot, telegramConfig.ChatID))
+		manager.AddChannel(telegramConfig.GetName(config.NoticeChannelTelegram), telegramConfig.GetOn(), NewTGNotifier(&tgBot, telegramConfig.ChatID))
 	}
 
 	if cfg.Notice.Mail != nil {
 		mailConfig := cfg.Notice.Mail
 		mailSender := utils.NewMailSender(mailConfig.Smtp, mailConfig.Port, mailConfig.User, mailConfig.Password)
-		manager.AddNotifier(NewMailNotifier(&mailSender, mailConfig.To))
+		manager.AddChannel(mailConfig.GetName(config.NoticeChannelMail), mailConfig.GetOn(), NewMailNotifier(&mailSender, mailConfig.To))
+	}
+
+	for _, webhook := range cfg.Notice.Webhooks {
+		manager.AddChannel(webhook.GetName(config.NoticeChannelWebhook), webhook.GetOn(), NewWebhookNotifier(webhook.URL, webhook.Headers))
+	}
+
+	for _, slack := range cfg.Notice.Slack {
+		manager.AddChannel(slack.GetName(config.NoticeChannelSlack), slack.GetOn(), NewSlackNotifier(slack.WebhookURL))
+	}
+
+	for _, discord := range cfg.Notice.Discord {
+		manager.AddChannel(discord.GetName(config.NoticeChannelDiscord), discord.GetOn(), NewDiscordNotifier(discord.WebhookURL))
+	}
+
+	for _, ntfy := range cfg.Notice.Ntfy {
+		manager.AddChannel(ntfy.GetName(config.NoticeChannelNtfy), ntfy.GetOn(), NewNtfyNotifier(ntfy.GetServer(), ntfy.Topic, ntfy.Token))
+	}
+
+	for _, gotify := range cfg.Notice.Gotify {
+		manager.AddChannel(gotify.GetName(config.NoticeChannelGotify), gotify.GetOn(), NewGotifyNotifier(gotify.Server, gotify.Token))
+	}
+
+	for _, backup := range cfg.BackupConf {
+		if backup.Notice != nil {
+			manager.SetTaskRoute(backup.GetID(), backup.Notice.GetOn(), backup.Notice.Channels)
+		}
 	}
 
 	return manager
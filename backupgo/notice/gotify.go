@@ -0,0 +1,54 @@
+package notice
+
+import "strings"
+
+const (
+	gotifyPriorityNormal = 4
+	gotifyPriorityHigh   = 8
+)
+
+// GotifyNotifier 通过 Gotify 推送消息，失败的任务使用高优先级。
+type GotifyNotifier struct {
+	server string
+	token  string
+}
+
+type gotifyMessage struct {
+	Title    string `json:"title"`
+	Message  string `json:"message"`
+	Priority int    `json:"priority"`
+}
+
+func NewGotifyNotifier(server string, token string) *GotifyNotifier {
+	return &GotifyNotifier{
+		server: strings.TrimRight(server, "/"),
+		token:  token,
+	}
+}
+
+func (n *GotifyNotifier) IsAvailable() bool {
+	return n.server != "" && n.token != ""
+}
+
+func (n *GotifyNotifier) GetName() string {
+	return "Gotify"
+}
+
+func (n *GotifyNotifier) GetFormatType() FormatType {
+	return FormatTypePlain
+}
+
+func (n *GotifyNotifier) Send(msg string) error {
+	return n.push(gotifyMessage{Title: "backupgo", Message: msg, Priority: gotifyPriorityNormal})
+}
+
+func (n *GotifyNotifier) SendReport(report TaskReport, msg string) error {
+	if report.HasErrors {
+		return n.push(gotifyMessage{Title: "backupgo: " + report.TaskID + " 备份失败", Message: msg, Priority: gotifyPriorityHigh})
+	}
+	return n.push(gotifyMessage{Title: "backupgo: " + report.TaskID + " 备份成功", Message: msg, Priority: gotifyPriorityNormal})
+}
+
+func (n *GotifyNotifier) push(message gotifyMessage) error {
+	return postJSON(n.server+"/message", map[string]string{"X-Gotify-Key": n.token}, message)
+}
@@ -1,7 +1,11 @@
 package notice
 
 import (
+	"backupgo/config"
+	"errors"
 	"log"
+	"slices"
+	"strings"
 )
 
 type Notifier interface {
@@ -18,38 +22,133 @@ type Notifier interface {
 	GetFormatType() FormatType
 }
 
+// ReportNotifier 由需要完整任务报告的通知渠道实现，例如发送 JSON 的 webhook、按结果设置优先级的 ntfy。
+type ReportNotifier interface {
+	// SendReport 发送任务报告，msg 是按 GetFormatType 格式化好的消息
+	SendReport(report TaskReport, msg string) error
+}
+
+type channel struct {
+	name     string
+	on       string
+	notifier Notifier
+}
+
+// route 任务级别的通知路由，channels 为空时发送到全部渠道
+type route struct {
+	on       string
+	channels map[string]bool
+}
+
 type NoticeManager struct {
-	notifiers []Notifier
+	channels []channel
+	routes   map[string]route
 }
 
 func NewNoticeManager() *NoticeManager {
 	return &NoticeManager{
-		notifiers: make([]Notifier, 0),
+		channels: make([]channel, 0),
+		routes:   make(map[string]route),
 	}
 }
 
 func (m *NoticeManager) AddNotifier(n Notifier) {
-	m.notifiers = append(m.notifiers, n)
+	m.AddChannel(n.GetName(), config.NoticeOnAlways, n)
+}
+
+// AddChannel 以 name 注册通知渠道，on 控制哪些任务结果会发送到该渠道。
+func (m *NoticeManager) AddChannel(name string, on string, n Notifier) {
+	m.channels = append(m.channels, channel{name: name, on: on, notifier: n})
+}
+
+// SetTaskRoute 设置任务的通知路由：on 控制任务在哪些结果下发送通知，channels 限定发送到哪些渠道。
+func (m *NoticeManager) SetTaskRoute(taskID string, on string, channels []string) {
+	r := route{on: on}
+	if len(channels) > 0 {
+		r.channels = make(map[string]bool, len(channels))
+		for _, name := range channels {
+			r.channels[strings.TrimSpace(name)] = true
+		}
+	}
+	m.routes[taskID] = r
 }
 
 // NoticeReport 根据任务报告发送格式化的消息
 func (m *NoticeManager) NoticeReport(report TaskReport) {
 	messages := make(map[FormatType]string)
 
-	for _, n := range m.notifiers {
-		if !n.IsAvailable() {
+	for _, c := range m.channels {
+		if !c.notifier.IsAvailable() || !m.shouldSend(c, report) {
 			continue
 		}
 
-		formatType := n.GetFormatType()
+		formatType := c.notifier.GetFormatType()
 		msg, ok := messages[formatType]
 		if !ok {
 			msg = newFormatter(formatType).FormatReport(report)
 			messages[formatType] = msg
 		}
 
-		if err := n.Send(msg); err != nil {
-			log.Printf("Failed to send messages via %s: %v", n.GetName(), err)
+		var err error
+		if reportNotifier, ok := c.notifier.(ReportNotifier); ok {
+			err = reportNotifier.SendReport(report, msg)
+		} else {
+			err = c.notifier.Send(msg)
+		}
+		if err != nil {
+			log.Printf("Failed to send messages via %s: %v", c.name, err)
+		}
+	}
+}
+
+// testMessage 是 check 命令发送到各通知渠道的测试消息
+const testMessage = "🔔 backupgo 通知测试：收到这条消息说明通知渠道配置正确"
+
+// ChannelResult 是向单个通知渠道发送消息的结果
+type ChannelResult struct {
+	Name string
+	Err  error
+}
+
+// SendTest 向通知渠道发送测试消息，不受渠道和任务的 on 限制。channels 为空时发送到全部渠道。
+func (m *NoticeManager) SendTest(channels []string) []ChannelResult {
+	var results []ChannelResult
+	for _, c := range m.channels {
+		if len(channels) > 0 && !slices.Contains(channels, c.name) {
+			continue
+		}
+
+		err := errors.New("channel is not available")
+		if c.notifier.IsAvailable() {
+			err = c.notifier.Send(testMessage)
 		}
+		results = append(results, ChannelResult{Name: c.name, Err: err})
+	}
+	return results
+}
+
+func (m *NoticeManager) shouldSend(c channel, report TaskReport) bool {
+	if !matchesOn(c.on, report) {
+		return false
+	}
+
+	r, ok := m.routes[report.TaskID]
+	if !ok {
+		return true
+	}
+	if !matchesOn(r.on, report) {
+		return false
+	}
+	return r.channels == nil || r.channels[c.name]
+}
+
+func matchesOn(on string, report TaskReport) bool {
+	switch on {
+	case config.NoticeOnFailure:
+		return report.HasErrors
+	case config.NoticeOnSuccess:
+		return !report.HasErrors
+	default:
+		return true
 	}
 }
@@ -1,6 +1,7 @@
 package notice
 
 import (
+	"backupgo/config"
 	"strings"
 	"testing"
 	"time"
@@ -67,4 +68,76 @@ func TestNotifierFormatTypes(t *testing.T) {
 	if (&MailNotifier{}).GetFormatType() != FormatTypeHTML {
 		t.Fatalf("mail notifier should use html format")
 	}
+	if (&DiscordNotifier{}).GetFormatType() != FormatTypeMarkdown {
+		t.Fatalf("discord notifier should use markdown format")
+	}
+}
+
+func TestNoticeManagerRoutesReports(t *testing.T) {
+	manager := NewNoticeManager()
+	ops := &stubNotifier{name: "ops", formatType: FormatTypePlain, available: true}
+	alerts := &stubNotifier{name: "alerts", formatType: FormatTypePlain, available: true}
+	dba := &stubNotifier{name: "dba", formatType: FormatTypePlain, available: true}
+
+	manager.AddChannel("ops", config.NoticeOnAlways, ops)
+	manager.AddChannel("alerts", config.NoticeOnFailure, alerts)
+	manager.AddChannel("dba", config.NoticeOnAlways, dba)
+	manager.SetTaskRoute("pg", config.NoticeOnAlways, []string{"dba", "alerts"})
+	manager.SetTaskRoute("nightly", config.NoticeOnFailure, nil)
+
+	manager.NoticeReport(TaskReport{TaskID: "app"})
+	manager.NoticeReport(TaskReport{TaskID: "pg"})
+	manager.NoticeReport(TaskReport{TaskID: "pg", HasErrors: true})
+	manager.NoticeReport(TaskReport{TaskID: "nightly"})
+	manager.NoticeReport(TaskReport{TaskID: "nightly", HasErrors: true})
+
+	// ops: app 成功、nightly 失败；alerts: pg 失败、nightly 失败；dba: app 成功、pg 成功和失败、nightly 失败
+	if len(ops.sent) != 2 || len(alerts.sent) != 2 || len(dba.sent) != 4 {
+		t.Fatalf("unexpected sent counts: ops=%d alerts=%d dba=%d", len(ops.sent), len(alerts.sent), len(dba.sent))
+	}
+}
+
+type stubReportNotifier struct {
+	stubNotifier
+	reports []TaskReport
+}
+
+func (n *stubReportNotifier) SendReport(report TaskReport, msg string) error {
+	n.reports = append(n.reports, report)
+	return nil
+}
+
+func TestNoticeManagerPrefersSendReport(t *testing.T) {
+	manager := NewNoticeManager()
+	notifier := &stubReportNotifier{stubNotifier: stubNotifier{name: "webhook", formatType: FormatTypePlain, available: true}}
+	manager.AddNotifier(notifier)
+
+	manager.NoticeReport(TaskReport{TaskID: "app"})
+
+	if len(notifier.reports) != 1 || len(notifier.sent) != 0 {
+		t.Fatalf("expected SendReport to be used, reports=%d sent=%d", len(notifier.reports), len(notifier.sent))
+	}
+}
+
+func TestSendTestIgnoresOnAndFiltersChannels(t *testing.T) {
+	manager := NewNoticeManager()
+	failureOnly := &stubNotifier{name: "tg", formatType: FormatTypePlain, available: true}
+	unavailable := &stubNotifier{name: "mail", formatType: FormatTypeHTML}
+	skipped := &stubNotifier{name: "slack", formatType: FormatTypeMarkdown, available: true}
+
+	manager.AddChannel("tg", config.NoticeOnFailure, failureOnly)
+	manager.AddChannel("mail", config.NoticeOnAlways, unavailable)
+	manager.AddChannel("slack", config.NoticeOnAlways, skipped)
+
+	results := manager.SendTest([]string{"tg", "mail"})
+
+	if len(results) != 2 || results[0].Name != "tg" || results[0].Err != nil {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if results[1].Name != "mail" || results[1].Err == nil {
+		t.Fatalf("expected unavailable channel to fail, got %+v", results[1])
+	}
+	if len(failureOnly.sent) != 1 || len(unavailable.sent) != 0 || len(skipped.sent) != 0 {
+		t.Fatalf("unexpected sent messages: %d %d %d", len(failureOnly.sent), len(unavailable.sent), len(skipped.sent))
+	}
 }
@@ -40,18 +40,24 @@ func (f formatter) FormatReport(report TaskReport) string {
 
 func renderPlain(builder *strings.Builder, report TaskReport) {
 	writeLine(builder, "📦 备份任务: %s", report.TaskID)
-	writeLine(builder, "%s 状态: %s", statusIcon(report.HasErrors), statusText(report.HasErrors))
+	writeLine(builder, "%s 状态: %s", statusIcon(report), statusText(report))
 	writeLine(builder, "⏱️ 耗时: %s", FormatDuration(report.Duration))
 	writeSeparator(builder)
 
 	if report.CompressedSize != "" {
 		writeLine(builder, "📦 %s", report.CompressedSize)
 	}
+	if report.ExcludedFiles > 0 {
+		writeLine(builder, "🚫 已排除: %d 个文件 (%s)", report.ExcludedFiles, FormatBytes(report.ExcludedBytes))
+	}
 
 	for _, upload := range report.Uploads {
 		writePlainUpload(builder, upload)
 	}
 
+	writePlainVerify(builder, report.Verify)
+	writePlainAttempts(builder, report.Attempts)
+
 	if report.FirstError != "" {
 		writeLine(builder, "❌ 错误: %s", report.FirstError)
 	}
@@ -59,7 +65,7 @@ func renderPlain(builder *strings.Builder, report TaskReport) {
 
 func renderMarkdown(builder *strings.Builder, report TaskReport) {
 	writeLine(builder, "📦 **备份任务**: `%s`", report.TaskID)
-	writeLine(builder, "%s **状态**: %s", statusIcon(report.HasErrors), statusText(report.HasErrors))
+	writeLine(builder, "%s **状态**: %s", statusIcon(report), statusText(report))
 	writeLine(builder, "⏱️ **耗时**: %s", FormatDuration(report.Duration))
 	writeLine(builder, "")
 	writeLine(builder, "---")
@@ -68,11 +74,17 @@ func renderMarkdown(builder *strings.Builder, report TaskReport) {
 	if report.CompressedSize != "" {
 		writeLine(builder, "📦 **压缩**: %s", report.CompressedSize)
 	}
+	if report.ExcludedFiles > 0 {
+		writeLine(builder, "🚫 **已排除**: %d 个文件 (%s)", report.ExcludedFiles, FormatBytes(report.ExcludedBytes))
+	}
 
 	for _, upload := range report.Uploads {
 		writeMarkdownUpload(builder, upload)
 	}
 
+	writeMarkdownVerify(builder, report.Verify)
+	writeMarkdownAttempts(builder, report.Attempts)
+
 	if report.FirstError != "" {
 		writeLine(builder, "")
 		writeLine(builder, "❌ **错误**: `%s`", report.FirstError)
@@ -81,18 +93,24 @@ func renderMarkdown(builder *strings.Builder, report TaskReport) {
 
 func renderHTML(builder *strings.Builder, report TaskReport) {
 	writeHTMLBlock(builder, "<b>📦 备份任务:</b> <code>%s</code>", escapeHTML(report.TaskID))
-	writeHTMLBlock(builder, "%s <b>状态:</b> %s", statusIcon(report.HasErrors), escapeHTML(statusText(report.HasErrors)))
+	writeHTMLBlock(builder, "%s <b>状态:</b> %s", statusIcon(report), escapeHTML(statusText(report)))
 	writeHTMLBlock(builder, "⏱️ <b>耗时:</b> %s", escapeHTML(FormatDuration(report.Duration)))
 	writeHTMLSpacer(builder)
 
 	if report.CompressedSize != "" {
 		writeHTMLBlock(builder, "📦 <b>压缩:</b> %s", escapeHTML(report.CompressedSize))
 	}
+	if report.ExcludedFiles > 0 {
+		writeHTMLBlock(builder, "🚫 <b>已排除:</b> %d 个文件 (%s)", report.ExcludedFiles, escapeHTML(FormatBytes(report.ExcludedBytes)))
+	}
 
 	for _, upload := range report.Uploads {
 		writeHTMLUpload(builder, upload)
 	}
 
+	writeHTMLVerify(builder, report.Verify)
+	writeHTMLAttempts(builder, report.Attempts)
+
 	if report.FirstError != "" {
 		writeHTMLSpacer(builder)
 		writeHTMLBlock(builder, "❌ <b>错误:</b> <code>%s</code>", escapeHTML(report.FirstError))
@@ -145,6 +163,74 @@ func writeHTMLUpload(builder *strings.Builder, upload UploadReport) {
 	writeHTMLBlock(builder, "☁️ <b>对象路径:</b> <code>%s</code>", path)
 }
 
+func writePlainVerify(builder *strings.Builder, verify VerifyReport) {
+	switch verify.Status {
+	case VerifyStatusPassed:
+		writeLine(builder, "🔍 校验通过: %s", verify.Detail)
+	case VerifyStatusFailed:
+		writeLine(builder, "🔍 校验失败: %s", verify.Detail)
+	}
+}
+
+func writeMarkdownVerify(builder *strings.Builder, verify VerifyReport) {
+	switch verify.Status {
+	case VerifyStatusPassed:
+		writeLine(builder, "🔍 **校验通过**: %s", verify.Detail)
+	case VerifyStatusFailed:
+		writeLine(builder, "🔍 **校验失败**: `%s`", verify.Detail)
+	}
+}
+
+func writeHTMLVerify(builder *strings.Builder, verify VerifyReport) {
+	switch verify.Status {
+	case VerifyStatusPassed:
+		writeHTMLBlock(builder, "🔍 <b>校验通过:</b> %s", escapeHTML(verify.Detail))
+	case VerifyStatusFailed:
+		writeHTMLBlock(builder, "🔍 <b>校验失败:</b> <code>%s</code>", escapeHTML(verify.Detail))
+	}
+}
+
+func writePlainAttempts(builder *strings.Builder, attempts []AttemptReport) {
+	for _, attempt := range attempts {
+		if !attempt.Retried() {
+			continue
+		}
+		if attempt.Error != "" {
+			writeLine(builder, "🔁 %s 第%d次尝试失败 (%s): %s", attempt.Stage, attempt.Attempt, FormatDuration(attempt.Duration), attempt.Error)
+			continue
+		}
+		writeLine(builder, "🔁 %s 第%d次尝试成功 (%s)", attempt.Stage, attempt.Attempt, FormatDuration(attempt.Duration))
+	}
+}
+
+func writeMarkdownAttempts(builder *strings.Builder, attempts []AttemptReport) {
+	for _, attempt := range attempts {
+		if !attempt.Retried() {
+			continue
+		}
+		if attempt.Error != "" {
+			writeLine(builder, "🔁 **%s** 第%d次尝试失败 (%s): `%s`", attempt.Stage, attempt.Attempt, FormatDuration(attempt.Duration), attempt.Error)
+			continue
+		}
+		writeLine(builder, "🔁 **%s** 第%d次尝试成功 (%s)", attempt.Stage, attempt.Attempt, FormatDuration(attempt.Duration))
+	}
+}
+
+func writeHTMLAttempts(builder *strings.Builder, attempts []AttemptReport) {
+	for _, attempt := range attempts {
+		if !attempt.Retried() {
+			continue
+		}
+		stage := escapeHTML(attempt.Stage)
+		duration := escapeHTML(FormatDuration(attempt.Duration))
+		if attempt.Error != "" {
+			writeHTMLBlock(builder, "🔁 <b>%s</b> 第%d次尝试失败 (%s): <code>%s</code>", stage, attempt.Attempt, duration, escapeHTML(attempt.Error))
+			continue
+		}
+		writeHTMLBlock(builder, "🔁 <b>%s</b> 第%d次尝试成功 (%s)", stage, attempt.Attempt, duration)
+	}
+}
+
 func writeHTMLBlock(builder *strings.Builder, format string, args ...interface{}) {
 	fmt.Fprintf(builder, "<div>%s</div>\n", fmt.Sprintf(format, args...))
 }
@@ -161,18 +247,26 @@ func writeSeparator(builder *strings.Builder) {
 	writeLine(builder, "━━━━━━━━━━━━━━━━━━━━")
 }
 
-func statusIcon(hasErrors bool) string {
-	if hasErrors {
+func statusIcon(report TaskReport) string {
+	switch {
+	case report.Interrupted:
+		return "⏹"
+	case report.HasErrors:
 		return "❌"
+	default:
+		return "✅"
 	}
-	return "✅"
 }
 
-func statusText(hasErrors bool) string {
-	if hasErrors {
+func statusText(report TaskReport) string {
+	switch {
+	case report.Interrupted:
+		return "已中断"
+	case report.HasErrors:
 		return "失败"
+	default:
+		return "成功"
 	}
-	return "成功"
 }
 
 func FormatBytes(bytes int64) string {
@@ -91,3 +91,71 @@ func TestFormatterRendersFailedUpload(t *testing.T) {
 		t.Fatalf("html output missing failed upload: %s", html)
 	}
 }
+
+func TestFormatterRendersVerifyResult(t *testing.T) {
+	report := TaskReport{
+		TaskID:   "task-1",
+		Duration: 5 * time.Second,
+		Verify:   VerifyReport{Status: VerifyStatusPassed, Detail: "full, 3 个文件"},
+	}
+
+	plain := newFormatter(FormatTypePlain).FormatReport(report)
+	if !strings.Contains(plain, "🔍 校验通过: full, 3 个文件") {
+		t.Fatalf("plain output missing verify result: %s", plain)
+	}
+
+	report.Verify = VerifyReport{Status: VerifyStatusFailed, Detail: "zip: checksum error"}
+	html := newFormatter(FormatTypeHTML).FormatReport(report)
+	if !strings.Contains(html, "<div>🔍 <b>校验失败:</b> <code>zip: checksum error</code></div>") {
+		t.Fatalf("html output missing verify result: %s", html)
+	}
+
+	report.Verify = VerifyReport{}
+	if plain := newFormatter(FormatTypePlain).FormatReport(report); strings.Contains(plain, "校验") {
+		t.Fatalf("plain output should not mention verify when it did not run: %s", plain)
+	}
+}
+
+func TestFormatterRendersRetriedAttempts(t *testing.T) {
+	report := TaskReport{
+		TaskID:   "task-1",
+		Duration: time.Minute,
+		Attempts: []AttemptReport{
+			{Stage: "执行前置命令", Attempt: 1, Duration: time.Second},
+			{Stage: "上传到OSS", Attempt: 1, Duration: 12 * time.Second, Error: "connection reset"},
+			{Stage: "上传到OSS", Attempt: 2, Duration: 3 * time.Second},
+		},
+	}
+
+	plain := newFormatter(FormatTypePlain).FormatReport(report)
+	for _, want := range []string{
+		"🔁 上传到OSS 第1次尝试失败 (12秒): connection reset",
+		"🔁 上传到OSS 第2次尝试成功 (3秒)",
+	} {
+		if !strings.Contains(plain, want) {
+			t.Fatalf("plain output missing %q: %s", want, plain)
+		}
+	}
+	if strings.Contains(plain, "执行前置命令") {
+		t.Fatalf("plain output should skip attempts that succeeded the first time: %s", plain)
+	}
+
+	html := newFormatter(FormatTypeHTML).FormatReport(report)
+	if !strings.Contains(html, "<div>🔁 <b>上传到OSS</b> 第1次尝试失败 (12秒): <code>connection reset</code></div>") {
+		t.Fatalf("html output missing failed attempt: %s", html)
+	}
+}
+
+func TestFormatterRendersExcludedFiles(t *testing.T) {
+	report := TaskReport{TaskID: "task-1", CompressedSize: "1.0 MB", ExcludedFiles: 12, ExcludedBytes: 3 << 20}
+
+	plain := newFormatter(FormatTypePlain).FormatReport(report)
+	if !strings.Contains(plain, "🚫 已排除: 12 个文件 (3.0 MB)") {
+		t.Fatalf("plain output missing excluded files: %s", plain)
+	}
+
+	report.ExcludedFiles = 0
+	if plain := newFormatter(FormatTypePlain).FormatReport(report); strings.Contains(plain, "已排除") {
+		t.Fatalf("plain output should not mention excluded files when nothing was excluded: %s", plain)
+	}
+}
@@ -0,0 +1,60 @@
+package notice
+
+import (
+	"mime"
+	"strings"
+)
+
+// NtfyNotifier 把消息发布到 ntfy 主题，失败的任务使用高优先级。
+type NtfyNotifier struct {
+	server string
+	topic  string
+	token  string
+}
+
+func NewNtfyNotifier(server string, topic string, token string) *NtfyNotifier {
+	return &NtfyNotifier{
+		server: strings.TrimRight(server, "/"),
+		topic:  topic,
+		token:  token,
+	}
+}
+
+func (n *NtfyNotifier) IsAvailable() bool {
+	return n.server != "" && n.topic != ""
+}
+
+func (n *NtfyNotifier) GetName() string {
+	return "ntfy"
+}
+
+func (n *NtfyNotifier) GetFormatType() FormatType {
+	return FormatTypePlain
+}
+
+func (n *NtfyNotifier) Send(msg string) error {
+	return n.publish("backupgo", "default", "", msg)
+}
+
+func (n *NtfyNotifier) SendReport(report TaskReport, msg string) error {
+	if report.HasErrors {
+		return n.publish("backupgo: "+report.TaskID+" 备份失败", "high", "x", msg)
+	}
+	return n.publish("backupgo: "+report.TaskID+" 备份成功", "default", "white_check_mark", msg)
+}
+
+func (n *NtfyNotifier) publish(title string, priority string, tags string, msg string) error {
+	// HTTP 头只能安全地携带 ASCII，中文标题按 RFC 2047 编码，ntfy 会自动解码
+	headers := map[string]string{
+		"Title":    mime.BEncoding.Encode("UTF-8", title),
+		"Priority": priority,
+	}
+	if tags != "" {
+		headers["Tags"] = tags
+	}
+	if n.token != "" {
+		headers["Authorization"] = "Bearer " + n.token
+	}
+
+	return post(n.server+"/"+n.topic, "text/plain; charset=utf-8", headers, strings.NewReader(msg))
+}
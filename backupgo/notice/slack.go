@@ -0,0 +1,27 @@
+package notice
+
+// SlackNotifier 通过 Slack incoming webhook 发送消息。
+type SlackNotifier struct {
+	webhookURL string
+}
+
+func NewSlackNotifier(webhookURL string) *SlackNotifier {
+	return &SlackNotifier{webhookURL: webhookURL}
+}
+
+func (n *SlackNotifier) IsAvailable() bool {
+	return n.webhookURL != ""
+}
+
+func (n *SlackNotifier) GetName() string {
+	return "Slack"
+}
+
+// GetFormatType Slack 的 mrkdwn 与标准 Markdown 语法不同，使用纯文本
+func (n *SlackNotifier) GetFormatType() FormatType {
+	return FormatTypePlain
+}
+
+func (n *SlackNotifier) Send(msg string) error {
+	return postJSON(n.webhookURL, nil, map[string]string{"text": msg})
+}
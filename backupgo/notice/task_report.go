@@ -12,6 +12,32 @@ const (
 	UploadStatusFailed  UploadStatus = "failed"
 )
 
+type VerifyStatus string
+
+const (
+	VerifyStatusPassed VerifyStatus = "passed"
+	VerifyStatusFailed VerifyStatus = "failed"
+)
+
+// VerifyReport 记录上传后校验的结果，Status 为空表示未执行校验
+type VerifyReport struct {
+	Status VerifyStatus
+	Detail string
+}
+
+// AttemptReport 记录一个阶段的一次尝试，Error 为空表示这次尝试成功
+type AttemptReport struct {
+	Stage    string
+	Attempt  int
+	Duration time.Duration
+	Error    string
+}
+
+// Retried 表示这次尝试需要出现在通知里：失败的尝试，或重试后成功的尝试
+func (a AttemptReport) Retried() bool {
+	return a.Error != "" || a.Attempt > 1
+}
+
 type UploadReport struct {
 	Bucket string
 	Key    string
@@ -38,8 +64,16 @@ type TaskReport struct {
 	HasErrors      bool
 	ErrorCount     int
 	CompressedSize string
+	ExcludedFiles  int64
+	ExcludedBytes  int64
 	Uploads        []UploadReport
+	Verify         VerifyReport
+	Attempts       []AttemptReport
 	FirstError     string
+	// Errors 按发生顺序记录的错误描述
+	Errors []string
+	// Interrupted 表示任务因停止服务被取消，而不是自身出错
+	Interrupted bool
 
 	startedAt time.Time
 }
@@ -55,8 +89,14 @@ func (r *TaskReport) Reset() {
 	r.HasErrors = false
 	r.ErrorCount = 0
 	r.CompressedSize = ""
+	r.ExcludedFiles = 0
+	r.ExcludedBytes = 0
 	r.Uploads = make([]UploadReport, 0)
+	r.Verify = VerifyReport{}
+	r.Attempts = nil
 	r.FirstError = ""
+	r.Errors = nil
+	r.Interrupted = false
 	r.startedAt = time.Now()
 }
 
@@ -70,6 +110,7 @@ func (r *TaskReport) Finish() {
 func (r *TaskReport) MarkError(message string) {
 	r.HasErrors = true
 	r.ErrorCount++
+	r.Errors = append(r.Errors, message)
 	if r.FirstError == "" {
 		r.FirstError = message
 	}
@@ -77,15 +118,30 @@ func (r *TaskReport) MarkError(message string) {
 
 func (r *TaskReport) EnsureFailed(message string) {
 	r.HasErrors = true
+	if len(r.Errors) == 0 {
+		r.Errors = append(r.Errors, message)
+	}
 	if r.FirstError == "" {
 		r.FirstError = message
 	}
 }
 
+// MarkInterrupted 把任务标记为被中断，中断的任务同样按失败处理。
+func (r *TaskReport) MarkInterrupted() {
+	r.Interrupted = true
+	r.EnsureFailed("任务被中断")
+}
+
 func (r *TaskReport) SetCompressedSize(total int64) {
 	r.CompressedSize = FormatBytes(total)
 }
 
+// SetExcluded 记录被 include/exclude 规则和 .backupignore 排除的文件数和字节数
+func (r *TaskReport) SetExcluded(files int64, bytes int64) {
+	r.ExcludedFiles = files
+	r.ExcludedBytes = bytes
+}
+
 func (r *TaskReport) AddUploadSuccess(bucket string, key string) {
 	r.Uploads = append(r.Uploads, UploadReport{
 		Bucket: bucket,
@@ -103,17 +159,52 @@ func (r *TaskReport) AddUploadFailure(bucket string, key string, reason string)
 	})
 }
 
+// AddAttempt 记录阶段的一次尝试结果
+func (r *TaskReport) AddAttempt(stage string, attempt int, duration time.Duration, err error) {
+	report := AttemptReport{Stage: stage, Attempt: attempt, Duration: duration}
+	if err != nil {
+		report.Error = err.Error()
+	}
+	r.Attempts = append(r.Attempts, report)
+}
+
+func (r *TaskReport) SetVerifyPassed(detail string) {
+	r.Verify = VerifyReport{Status: VerifyStatusPassed, Detail: detail}
+}
+
+func (r *TaskReport) SetVerifyFailed(reason string) {
+	r.Verify = VerifyReport{Status: VerifyStatusFailed, Detail: reason}
+}
+
 func (r *TaskReport) Snapshot() TaskReport {
 	uploads := make([]UploadReport, len(r.Uploads))
 	copy(uploads, r.Uploads)
 
+	var attempts []AttemptReport
+	if len(r.Attempts) > 0 {
+		attempts = make([]AttemptReport, len(r.Attempts))
+		copy(attempts, r.Attempts)
+	}
+
+	var errs []string
+	if len(r.Errors) > 0 {
+		errs = make([]string, len(r.Errors))
+		copy(errs, r.Errors)
+	}
+
 	return TaskReport{
 		TaskID:         r.TaskID,
 		Duration:       r.Duration,
 		HasErrors:      r.HasErrors,
 		ErrorCount:     r.ErrorCount,
 		CompressedSize: r.CompressedSize,
+		ExcludedFiles:  r.ExcludedFiles,
+		ExcludedBytes:  r.ExcludedBytes,
 		Uploads:        uploads,
+		Verify:         r.Verify,
+		Attempts:       attempts,
 		FirstError:     r.FirstError,
+		Errors:         errs,
+		Interrupted:    r.Interrupted,
 	}
 }
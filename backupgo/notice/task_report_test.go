@@ -1,6 +1,9 @@
 package notice
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestTaskReportTracksFirstErrorAndUploadResults(t *testing.T) {
 	report := NewTaskReport("task-1")
@@ -28,6 +31,9 @@ func TestTaskReportTracksFirstErrorAndUploadResults(t *testing.T) {
 	if snapshot.FirstError != "上传失败" {
 		t.Fatalf("expected first error 上传失败, got %q", snapshot.FirstError)
 	}
+	if len(snapshot.Errors) != 1 || snapshot.Errors[0] != "上传失败" {
+		t.Fatalf("unexpected errors: %v", snapshot.Errors)
+	}
 	if len(snapshot.Uploads) != 2 {
 		t.Fatalf("expected 2 uploads, got %d", len(snapshot.Uploads))
 	}
@@ -46,6 +52,7 @@ func TestTaskReportResetClearsState(t *testing.T) {
 	report := NewTaskReport("task-1")
 	report.MarkError("上传失败")
 	report.AddUploadFailure("archive", "demo.zip", "network error")
+	report.SetVerifyFailed("object not found")
 	report.Reset()
 
 	snapshot := report.Snapshot()
@@ -55,10 +62,43 @@ func TestTaskReportResetClearsState(t *testing.T) {
 	if snapshot.ErrorCount != 0 {
 		t.Fatalf("expected reset error count 0, got %d", snapshot.ErrorCount)
 	}
+	if len(snapshot.Errors) != 0 {
+		t.Fatalf("expected reset errors to be empty, got %v", snapshot.Errors)
+	}
 	if len(snapshot.Uploads) != 0 {
 		t.Fatalf("expected reset uploads to be empty, got %d", len(snapshot.Uploads))
 	}
 	if snapshot.FirstError != "" {
 		t.Fatalf("expected reset first error to be empty, got %q", snapshot.FirstError)
 	}
+	if snapshot.Verify.Status != "" {
+		t.Fatalf("expected reset verify status to be empty, got %q", snapshot.Verify.Status)
+	}
+}
+
+func TestTaskReportMarkInterrupted(t *testing.T) {
+	report := NewTaskReport("task-1")
+	report.MarkError("上传失败")
+	report.MarkInterrupted()
+
+	snapshot := report.Snapshot()
+	if !snapshot.Interrupted || !snapshot.HasErrors {
+		t.Fatalf("expected interrupted failed report, got %+v", snapshot)
+	}
+	if snapshot.FirstError != "上传失败" {
+		t.Fatalf("expected first error to be kept, got %q", snapshot.FirstError)
+	}
+	if reportStatus(snapshot) != "interrupted" {
+		t.Fatalf("expected webhook status interrupted, got %q", reportStatus(snapshot))
+	}
+
+	plain := newFormatter(FormatTypePlain).FormatReport(snapshot)
+	if !strings.Contains(plain, "⏹ 状态: 已中断") {
+		t.Fatalf("plain output missing interrupted status: %s", plain)
+	}
+
+	report.Reset()
+	if report.Snapshot().Interrupted {
+		t.Fatal("expected reset to clear interrupted flag")
+	}
 }
@@ -0,0 +1,163 @@
+package notice
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// WebhookNotifier 以 JSON 格式把任务报告 POST 到指定 URL，便于接入自建系统。
+type WebhookNotifier struct {
+	url     string
+	headers map[string]string
+}
+
+type webhookPayload struct {
+	TaskID          string           `json:"task_id"`
+	Status          string           `json:"status"`
+	DurationSeconds float64          `json:"duration_seconds"`
+	ErrorCount      int              `json:"error_count"`
+	CompressedSize  string           `json:"compressed_size,omitempty"`
+	ExcludedFiles   int64            `json:"excluded_files,omitempty"`
+	ExcludedBytes   int64            `json:"excluded_bytes,omitempty"`
+	Uploads         []webhookUpload  `json:"uploads"`
+	Verify          *webhookVerify   `json:"verify,omitempty"`
+	Attempts        []webhookAttempt `json:"attempts,omitempty"`
+	FirstError      string           `json:"first_error,omitempty"`
+	Message         string           `json:"message"`
+}
+
+type webhookUpload struct {
+	Bucket string `json:"bucket"`
+	Key    string `json:"key"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type webhookAttempt struct {
+	Stage           string  `json:"stage"`
+	Attempt         int     `json:"attempt"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Error           string  `json:"error,omitempty"`
+}
+
+type webhookVerify struct {
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func NewWebhookNotifier(url string, headers map[string]string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:     url,
+		headers: headers,
+	}
+}
+
+func (n *WebhookNotifier) IsAvailable() bool {
+	return n.url != ""
+}
+
+func (n *WebhookNotifier) GetName() string {
+	return "Webhook"
+}
+
+func (n *WebhookNotifier) GetFormatType() FormatType {
+	return FormatTypePlain
+}
+
+func (n *WebhookNotifier) Send(msg string) error {
+	return postJSON(n.url, n.headers, map[string]string{"message": msg})
+}
+
+func (n *WebhookNotifier) SendReport(report TaskReport, msg string) error {
+	return postJSON(n.url, n.headers, newWebhookPayload(report, msg))
+}
+
+func newWebhookPayload(report TaskReport, msg string) webhookPayload {
+	payload := webhookPayload{
+		TaskID:          report.TaskID,
+		Status:          reportStatus(report),
+		DurationSeconds: report.Duration.Seconds(),
+		ErrorCount:      report.ErrorCount,
+		CompressedSize:  report.CompressedSize,
+		ExcludedFiles:   report.ExcludedFiles,
+		ExcludedBytes:   report.ExcludedBytes,
+		Uploads:         make([]webhookUpload, 0, len(report.Uploads)),
+		FirstError:      report.FirstError,
+		Message:         msg,
+	}
+
+	for _, upload := range report.Uploads {
+		payload.Uploads = append(payload.Uploads, webhookUpload{
+			Bucket: upload.Bucket,
+			Key:    upload.Key,
+			Status: string(upload.Status),
+			Reason: upload.Reason,
+		})
+	}
+	for _, attempt := range report.Attempts {
+		payload.Attempts = append(payload.Attempts, webhookAttempt{
+			Stage:           attempt.Stage,
+			Attempt:         attempt.Attempt,
+			DurationSeconds: attempt.Duration.Seconds(),
+			Error:           attempt.Error,
+		})
+	}
+	if report.Verify.Status != "" {
+		payload.Verify = &webhookVerify{Status: string(report.Verify.Status), Detail: report.Verify.Detail}
+	}
+
+	return payload
+}
+
+func reportStatus(report TaskReport) string {
+	switch {
+	case report.Interrupted:
+		return "interrupted"
+	case report.HasErrors:
+		return "failed"
+	default:
+		return "success"
+	}
+}
+
+func postJSON(url string, headers map[string]string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode payload failed: %w", err)
+	}
+
+	return post(url, "application/json", headers, bytes.NewReader(body))
+}
+
+// post 发送请求，非 2xx 响应视为失败并带上响应内容，便于排查 token 或地址错误。
+func post(url string, contentType string, headers map[string]string, body io.Reader) error {
+	req, err := http.NewRequest(http.MethodPost, url, body)
+	if err != nil {
+		return fmt.Errorf("create request failed: %w", err)
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected response %s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package notice
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type capturedRequest struct {
+	path   string
+	header http.Header
+	body   string
+}
+
+func newCaptureServer(t *testing.T, status int) (*httptest.Server, *[]capturedRequest) {
+	t.Helper()
+
+	var requests []capturedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, capturedRequest{path: r.URL.Path, header: r.Header.Clone(), body: string(body)})
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("invalid token"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestWebhookNotifierSendsReportJSON(t *testing.T) {
+	server, requests := newCaptureServer(t, http.StatusNoContent)
+	notifier := NewWebhookNotifier(server.URL+"/hook", map[string]string{"Authorization": "Bearer token"})
+
+	report := TaskReport{
+		TaskID:     "app",
+		Duration:   2 * time.Second,
+		HasErrors:  true,
+		ErrorCount: 1,
+		Uploads:    []UploadReport{{Bucket: "nas", Key: "app.zip", Status: UploadStatusFailed, Reason: "disk full"}},
+		FirstError: "上传失败",
+	}
+	if err := notifier.SendReport(report, "message"); err != nil {
+		t.Fatalf("SendReport returned error: %v", err)
+	}
+
+	req := (*requests)[0]
+	if req.header.Get("Authorization") != "Bearer token" || req.header.Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected headers: %v", req.header)
+	}
+
+	var payload webhookPayload
+	if err := json.Unmarshal([]byte(req.body), &payload); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if payload.TaskID != "app" || payload.Status != "failed" || payload.DurationSeconds != 2 || payload.Message != "message" {
+		t.Fatalf("unexpected payload: %+v", payload)
+	}
+	if len(payload.Uploads) != 1 || payload.Uploads[0].Reason != "disk full" || payload.Verify != nil {
+		t.Fatalf("unexpected payload uploads: %+v", payload)
+	}
+}
+
+func TestPostReturnsErrorForNon2xx(t *testing.T) {
+	server, _ := newCaptureServer(t, http.StatusUnauthorized)
+
+	err := NewSlackNotifier(server.URL).Send("hello")
+	if err == nil || !strings.Contains(err.Error(), "401") || !strings.Contains(err.Error(), "invalid token") {
+		t.Fatalf("expected 401 error, got %v", err)
+	}
+}
+
+func TestNtfyNotifierSetsPriorityForFailures(t *testing.T) {
+	server, requests := newCaptureServer(t, http.StatusOK)
+	notifier := NewNtfyNotifier(server.URL+"/", "backups", "tk_secret")
+
+	if err := notifier.SendReport(TaskReport{TaskID: "app", HasErrors: true}, "failed message"); err != nil {
+		t.Fatalf("SendReport returned error: %v", err)
+	}
+
+	req := (*requests)[0]
+	if req.path != "/backups" || req.body != "failed message" {
+		t.Fatalf("unexpected request: %+v", req)
+	}
+	if req.header.Get("Priority") != "high" || req.header.Get("Authorization") != "Bearer tk_secret" {
+		t.Fatalf("unexpected headers: %v", req.header)
+	}
+	title, err := new(mime.WordDecoder).DecodeHeader(req.header.Get("Title"))
+	if err != nil || title != "backupgo: app 备份失败" {
+		t.Fatalf("unexpected title %q, err: %v", title, err)
+	}
+}
+
+func TestGotifyNotifierPushesMessage(t *testing.T) {
+	server, requests := newCaptureServer(t, http.StatusOK)
+	notifier := NewGotifyNotifier(server.URL, "app-token")
+
+	if err := notifier.SendReport(TaskReport{TaskID: "app"}, "ok"); err != nil {
+		t.Fatalf("SendReport returned error: %v", err)
+	}
+
+	req := (*requests)[0]
+	if req.path != "/message" || req.header.Get("X-Gotify-Key") != "app-token" {
+		t.Fatalf("unexpected request: %+v", req)
+	}
+
+	var message gotifyMessage
+	if err := json.Unmarshal([]byte(req.body), &message); err != nil {
+		t.Fatalf("decode message: %v", err)
+	}
+	if message.Priority != gotifyPriorityNormal || message.Message != "ok" {
+		t.Fatalf("unexpected message: %+v", message)
+	}
+}
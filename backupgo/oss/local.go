@@ -0,0 +1,197 @@
+package oss
+
+import (
+	"backupgo/config"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStorage 把备份保存到本地目录，也适用于挂载到本机的 NAS / NFS 目录。
+type LocalStorage struct {
+	root string
+}
+
+func NewLocalStorage(cfg config.LocalStorageConfig) (*LocalStorage, error) {
+	root, err := filepath.Abs(strings.TrimSpace(cfg.Path))
+	if err != nil {
+		return nil, fmt.Errorf("resolve local storage path failed: %w", err)
+	}
+
+	return &LocalStorage{root: root}, nil
+}
+
+func (ls *LocalStorage) BucketName() string {
+	return ls.root
+}
+
+func (ls *LocalStorage) Upload(ctx context.Context, objKey, filePath string) (UploadResult, error) {
+	src, err := os.Open(filePath)
+	if err != nil {
+		return UploadResult{Bucket: ls.root, Key: objKey, Mode: NORMAL}, fmt.Errorf("open source file failed: %w", err)
+	}
+	defer src.Close()
+
+	return ls.UploadStream(ctx, objKey, src, StreamOptions{})
+}
+
+// UploadStream 直接把流写入目标文件，本地目录不需要分片。
+func (ls *LocalStorage) UploadStream(ctx context.Context, objKey string, r io.Reader, _ StreamOptions) (UploadResult, error) {
+	result := UploadResult{
+		Bucket: ls.root,
+		Key:    objKey,
+		Mode:   NORMAL,
+	}
+
+	target, err := ls.objectPath(objKey)
+	if err != nil {
+		return result, err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return result, fmt.Errorf("create target directory failed: %w", err)
+	}
+
+	// 先写临时文件再重命名，避免中途失败留下不完整的备份被当成有效文件
+	tmpFile := target + ".part"
+	dst, err := os.Create(tmpFile)
+	if err != nil {
+		return result, fmt.Errorf("create target file failed: %w", err)
+	}
+
+	if _, err := io.Copy(dst, contextReader{ctx: ctx, r: r}); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(tmpFile)
+		return result, fmt.Errorf("copy file failed: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		_ = os.Remove(tmpFile)
+		return result, fmt.Errorf("close target file failed: %w", err)
+	}
+
+	if err := os.Rename(tmpFile, target); err != nil {
+		_ = os.Remove(tmpFile)
+		return result, fmt.Errorf("rename target file failed: %w", err)
+	}
+
+	return result, nil
+}
+
+func (ls *LocalStorage) Download(ctx context.Context, objKey, filePath string) error {
+	source, err := ls.objectPath(objKey)
+	if err != nil {
+		return err
+	}
+
+	src, err := os.Open(source)
+	if errors.Is(err, fs.ErrNotExist) {
+		return notFound(err)
+	}
+	if err != nil {
+		return fmt.Errorf("open object failed: %w", err)
+	}
+	defer src.Close()
+
+	return writeFile(filePath, contextReader{ctx: ctx, r: src})
+}
+
+func (ls *LocalStorage) ListObjects(ctx context.Context) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+
+	err := filepath.WalkDir(ls.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == ls.root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasSuffix(path, ".part") {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(ls.root, path)
+		if err != nil {
+			return err
+		}
+
+		objects = append(objects, ObjectInfo{
+			Key:          filepath.ToSlash(relPath),
+			Size:         info.Size(),
+			LastModified: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+func (ls *LocalStorage) DeleteObjects(ctx context.Context, keys []string) ([]string, error) {
+	var deleted []string
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+		target, err := ls.objectPath(key)
+		if err != nil {
+			return deleted, err
+		}
+		if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+			return deleted, err
+		}
+		ls.removeEmptyDirs(filepath.Dir(target))
+		deleted = append(deleted, key)
+	}
+
+	return deleted, nil
+}
+
+// removeEmptyDirs 从 dir 开始向上删除空目录，直到 root，避免按日期分目录的 key 清理后留下空目录。
+func (ls *LocalStorage) removeEmptyDirs(dir string) {
+	for dir != ls.root && strings.HasPrefix(dir, ls.root+string(filepath.Separator)) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// objectPath 把对象 key 映射为 root 下的本地路径，拒绝跳出 root 的 key。
+func (ls *LocalStorage) objectPath(objKey string) (string, error) {
+	target := filepath.Join(ls.root, filepath.FromSlash(objKey))
+	relPath, err := filepath.Rel(ls.root, target)
+	if err != nil || relPath == "." || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid object key: %s", objKey)
+	}
+
+	return target, nil
+}
+
+func writeFile(filePath string, src io.Reader) error {
+	dst, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("create local file failed: %w", err)
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(filePath)
+		return fmt.Errorf("write local file failed: %w", err)
+	}
+
+	return dst.Close()
+}
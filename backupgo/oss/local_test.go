@@ -0,0 +1,171 @@
+package oss
+
+import (
+	"backupgo/config"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestLocalStorageUploadListDelete(t *testing.T) {
+	root := t.TempDir()
+	storage, err := NewLocalStorage(config.LocalStorageConfig{Path: root})
+	if err != nil {
+		t.Fatalf("NewLocalStorage returned error: %v", err)
+	}
+
+	source := filepath.Join(t.TempDir(), "demo.zip")
+	if err := os.WriteFile(source, []byte("backupgo"), 0644); err != nil {
+		t.Fatalf("write source file: %v", err)
+	}
+
+	for _, key := range []string{"app_2024_03_08.zip", "nested/app_2024_03_09.zip"} {
+		result, err := storage.Upload(context.Background(), key, source)
+		if err != nil {
+			t.Fatalf("Upload(%s) returned error: %v", key, err)
+		}
+		if result.Key != key || result.Mode != NORMAL {
+			t.Fatalf("unexpected upload result: %+v", result)
+		}
+	}
+
+	objects, err := storage.ListObjects(context.Background())
+	if err != nil {
+		t.Fatalf("ListObjects returned error: %v", err)
+	}
+	var keys []string
+	for _, obj := range objects {
+		keys = append(keys, obj.Key)
+		if obj.Size != int64(len("backupgo")) {
+			t.Fatalf("unexpected object size for %s: %d", obj.Key, obj.Size)
+		}
+	}
+	sort.Strings(keys)
+	if want := []string{"app_2024_03_08.zip", "nested/app_2024_03_09.zip"}; !reflect.DeepEqual(keys, want) {
+		t.Fatalf("unexpected keys: %#v", keys)
+	}
+
+	deleted, err := storage.DeleteObjects(context.Background(), []string{"app_2024_03_08.zip"})
+	if err != nil {
+		t.Fatalf("DeleteObjects returned error: %v", err)
+	}
+	if !reflect.DeepEqual(deleted, []string{"app_2024_03_08.zip"}) {
+		t.Fatalf("unexpected deleted keys: %#v", deleted)
+	}
+	if _, err := os.Stat(filepath.Join(root, "app_2024_03_08.zip")); !os.IsNotExist(err) {
+		t.Fatalf("expected deleted file to be removed, got err = %v", err)
+	}
+}
+
+func TestLocalStorageDeleteRemovesEmptyDirs(t *testing.T) {
+	root := t.TempDir()
+	storage, err := NewLocalStorage(config.LocalStorageConfig{Path: root})
+	if err != nil {
+		t.Fatalf("NewLocalStorage returned error: %v", err)
+	}
+
+	source := filepath.Join(t.TempDir(), "demo.zip")
+	if err := os.WriteFile(source, []byte("backupgo"), 0644); err != nil {
+		t.Fatalf("write source file: %v", err)
+	}
+	for _, key := range []string{"app/2024/03/app_2024_03_08.zip", "app/2024/04/app_2024_04_08.zip"} {
+		if _, err := storage.Upload(context.Background(), key, source); err != nil {
+			t.Fatalf("Upload(%s) returned error: %v", key, err)
+		}
+	}
+
+	if _, err := storage.DeleteObjects(context.Background(), []string{"app/2024/03/app_2024_03_08.zip"}); err != nil {
+		t.Fatalf("DeleteObjects returned error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "app", "2024", "03")); !os.IsNotExist(err) {
+		t.Fatalf("expected empty directory to be removed, got err = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "app", "2024", "04")); err != nil {
+		t.Fatalf("expected non-empty directory to be kept, got err = %v", err)
+	}
+	if _, err := os.Stat(root); err != nil {
+		t.Fatalf("expected root to be kept, got err = %v", err)
+	}
+}
+
+func TestLocalStorageListMissingRoot(t *testing.T) {
+	storage, err := NewLocalStorage(config.LocalStorageConfig{Path: filepath.Join(t.TempDir(), "missing")})
+	if err != nil {
+		t.Fatalf("NewLocalStorage returned error: %v", err)
+	}
+
+	objects, err := storage.ListObjects(context.Background())
+	if err != nil {
+		t.Fatalf("ListObjects returned error: %v", err)
+	}
+	if len(objects) != 0 {
+		t.Fatalf("expected no objects, got %d", len(objects))
+	}
+}
+
+func TestLocalStorageRejectsEscapingKey(t *testing.T) {
+	storage, err := NewLocalStorage(config.LocalStorageConfig{Path: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewLocalStorage returned error: %v", err)
+	}
+
+	if _, err := storage.Upload(context.Background(), "../escape.zip", "unused"); err == nil {
+		t.Fatal("expected Upload to reject key outside of root")
+	}
+}
+
+func TestLocalStorageDownload(t *testing.T) {
+	root := t.TempDir()
+	storage, err := NewLocalStorage(config.LocalStorageConfig{Path: root})
+	if err != nil {
+		t.Fatalf("NewLocalStorage returned error: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(root, "nested"), 0755); err != nil {
+		t.Fatalf("create nested dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "nested", "app.zip"), []byte("backupgo"), 0644); err != nil {
+		t.Fatalf("write object: %v", err)
+	}
+
+	target := filepath.Join(t.TempDir(), "app.zip")
+	if err := storage.Download(context.Background(), "nested/app.zip", target); err != nil {
+		t.Fatalf("Download returned error: %v", err)
+	}
+
+	content, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatalf("read downloaded file: %v", err)
+	}
+	if string(content) != "backupgo" {
+		t.Fatalf("unexpected downloaded content: %q", content)
+	}
+
+	if err := storage.Download(context.Background(), "missing.zip", target); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestProbeLeavesNoObjects(t *testing.T) {
+	root := t.TempDir()
+	storage, err := NewLocalStorage(config.LocalStorageConfig{Path: root})
+	if err != nil {
+		t.Fatalf("NewLocalStorage returned error: %v", err)
+	}
+
+	if err := Probe(context.Background(), storage); err != nil {
+		t.Fatalf("Probe returned error: %v", err)
+	}
+
+	objects, err := storage.ListObjects(context.Background())
+	if err != nil {
+		t.Fatalf("ListObjects returned error: %v", err)
+	}
+	if len(objects) != 0 {
+		t.Fatalf("expected probe object to be deleted, got %+v", objects)
+	}
+}
@@ -2,9 +2,11 @@ package oss
 
 import (
 	"backupgo/config"
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"strings"
 	"time"
@@ -71,26 +73,29 @@ func (oc *OssClient) BucketName() string {
 	return oc.bucketName
 }
 
-func (oc *OssClient) Upload(objKey, filePath string) (UploadResult, error) {
+func (oc *OssClient) Upload(ctx context.Context, objKey, filePath string) (UploadResult, error) {
 	result := UploadResult{
 		Bucket: oc.bucketName,
 		Key:    objKey,
 		Mode:   NORMAL,
 	}
 
-	err := upload(oc.client, oc.bucketName, objKey, filePath)
+	err := upload(ctx, oc.client, oc.bucketName, objKey, filePath)
 	if err == nil {
 		oc.setLastSuccessTime()
 		return result, nil
 	}
 	normalErr := err
 
+	if ctx.Err() != nil {
+		return result, normalErr
+	}
 	if !oc.canUseFastBucket() {
 		return result, fmt.Errorf("普通上传失败: %v；%w", normalErr, ErrCoolDown)
 	}
 
 	result.Mode = FAST
-	err = upload(oc.fastClient, oc.bucketName, objKey, filePath)
+	err = upload(ctx, oc.fastClient, oc.bucketName, objKey, filePath)
 	if err == nil {
 		oc.setLastSuccessTime()
 		return result, nil
@@ -99,8 +104,8 @@ func (oc *OssClient) Upload(objKey, filePath string) (UploadResult, error) {
 	return result, fmt.Errorf("普通上传失败: %v；加速上传失败: %w", normalErr, err)
 }
 
-func upload(client *oss.Client, bucketName, objKey, filePath string) error {
-	_, err := client.PutObjectFromFile(context.Background(), &oss.PutObjectRequest{
+func upload(ctx context.Context, client *oss.Client, bucketName, objKey, filePath string) error {
+	_, err := client.PutObjectFromFile(ctx, &oss.PutObjectRequest{
 		Bucket: oss.Ptr(bucketName),
 		Key:    oss.Ptr(objKey),
 	}, filePath)
@@ -108,6 +113,94 @@ func upload(client *oss.Client, bucketName, objKey, filePath string) error {
 	return err
 }
 
+// UploadStream 使用分片上传逐片上传数据流。某个分片用普通域名重试仍失败时，
+// 在冷却期外改用加速域名上传该分片及后续分片。
+func (oc *OssClient) UploadStream(ctx context.Context, objKey string, r io.Reader, opts StreamOptions) (UploadResult, error) {
+	result := UploadResult{
+		Bucket: oc.bucketName,
+		Key:    objKey,
+		Mode:   NORMAL,
+	}
+
+	initResult, err := oc.client.InitiateMultipartUpload(ctx, &oss.InitiateMultipartUploadRequest{
+		Bucket: oss.Ptr(oc.bucketName),
+		Key:    oss.Ptr(objKey),
+	})
+	if err != nil {
+		return result, fmt.Errorf("initiate multipart upload failed: %w", err)
+	}
+	uploadID := initResult.UploadId
+
+	var parts []oss.UploadPart
+	_, err = uploadParts(ctx, r, opts, func(partNumber int, data []byte) error {
+		client := oc.client
+		if result.Mode == FAST {
+			client = oc.fastClient
+		}
+
+		etag, err := uploadPart(ctx, client, oc.bucketName, objKey, uploadID, partNumber, data)
+		if err != nil && ctx.Err() == nil && result.Mode == NORMAL && oc.canUseFastBucket() {
+			log.Printf("oss upload part %d failed, switch to fast endpoint: %v", partNumber, err)
+			result.Mode = FAST
+			etag, err = uploadPart(ctx, oc.fastClient, oc.bucketName, objKey, uploadID, partNumber, data)
+		}
+		if err != nil {
+			return err
+		}
+
+		parts = append(parts, oss.UploadPart{PartNumber: int32(partNumber), ETag: etag})
+		return nil
+	})
+	if err == nil {
+		_, err = oc.client.CompleteMultipartUpload(ctx, &oss.CompleteMultipartUploadRequest{
+			Bucket:                  oss.Ptr(oc.bucketName),
+			Key:                     oss.Ptr(objKey),
+			UploadId:                uploadID,
+			CompleteMultipartUpload: &oss.CompleteMultipartUpload{Parts: parts},
+		})
+	}
+	if err != nil {
+		// ctx 可能已经取消，中止请求仍需要发出，避免残留未完成的分片
+		_, _ = oc.client.AbortMultipartUpload(context.WithoutCancel(ctx), &oss.AbortMultipartUploadRequest{
+			Bucket:   oss.Ptr(oc.bucketName),
+			Key:      oss.Ptr(objKey),
+			UploadId: uploadID,
+		})
+		return result, err
+	}
+
+	oc.setLastSuccessTime()
+	return result, nil
+}
+
+func uploadPart(ctx context.Context, client *oss.Client, bucketName, objKey string, uploadID *string, partNumber int, data []byte) (*string, error) {
+	result, err := client.UploadPart(ctx, &oss.UploadPartRequest{
+		Bucket:     oss.Ptr(bucketName),
+		Key:        oss.Ptr(objKey),
+		PartNumber: int32(partNumber),
+		UploadId:   uploadID,
+		Body:       bytes.NewReader(data),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result.ETag, nil
+}
+
+func (oc *OssClient) Download(ctx context.Context, objKey, filePath string) error {
+	_, err := oc.client.GetObjectToFile(ctx, &oss.GetObjectRequest{
+		Bucket: oss.Ptr(oc.bucketName),
+		Key:    oss.Ptr(objKey),
+	}, filePath)
+
+	var serviceErr *oss.ServiceError
+	if errors.As(err, &serviceErr) && serviceErr.Code == "NoSuchKey" {
+		return notFound(err)
+	}
+	return err
+}
+
 func (oc *OssClient) canUseFastBucket() bool {
 	if oc.lastSuccessTime.IsZero() {
 		return true
@@ -137,47 +230,56 @@ func (oc *OssClient) TempVisitLink(objKey string) (string, error) {
 	return "", nil
 }
 
-func (oc *OssClient) DeleteObjectsByPredicate(shouldDelete func(key string) bool) ([]string, error) {
-	var keys []string
+func (oc *OssClient) ListObjects(ctx context.Context) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
 
 	p := oc.client.NewListObjectsV2Paginator(&oss.ListObjectsV2Request{
 		Bucket: oss.Ptr(oc.bucketName),
 	})
 
 	for p.HasNext() {
-		page, err := p.NextPage(context.Background())
+		page, err := p.NextPage(ctx)
 		if err != nil {
 			return nil, err
 		}
 
 		for _, obj := range page.Contents {
-			key := oss.ToString(obj.Key)
-			if shouldDelete(key) {
-				keys = append(keys, key)
-			}
+			objects = append(objects, ObjectInfo{
+				Key:          oss.ToString(obj.Key),
+				Size:         obj.Size,
+				LastModified: oss.ToTime(obj.LastModified),
+			})
 		}
 	}
 
-	if len(keys) == 0 {
-		return nil, nil
-	}
-
-	var deleteObjects []oss.DeleteObject
-	for _, key := range keys {
-		deleteObjects = append(deleteObjects, oss.DeleteObject{Key: oss.Ptr(key)})
-	}
+	return objects, nil
+}
 
-	result, err := oc.client.DeleteMultipleObjects(context.Background(), &oss.DeleteMultipleObjectsRequest{
-		Bucket: oss.Ptr(oc.bucketName),
-		Delete: &oss.Delete{Objects: deleteObjects},
-	})
-	if err != nil {
-		return nil, err
-	}
+func (oc *OssClient) DeleteObjects(ctx context.Context, keys []string) ([]string, error) {
+	// DeleteMultipleObjects 单次最多删除 1000 个对象
+	const batchSize = 1000
 
 	var deleted []string
-	for _, d := range result.DeletedObjects {
-		deleted = append(deleted, oss.ToString(d.Key))
+	for start := 0; start < len(keys); start += batchSize {
+		end := min(start+batchSize, len(keys))
+
+		var deleteObjects []oss.DeleteObject
+		for _, key := range keys[start:end] {
+			deleteObjects = append(deleteObjects, oss.DeleteObject{Key: oss.Ptr(key)})
+		}
+
+		result, err := oc.client.DeleteMultipleObjects(ctx, &oss.DeleteMultipleObjectsRequest{
+			Bucket: oss.Ptr(oc.bucketName),
+			Delete: &oss.Delete{Objects: deleteObjects},
+		})
+		if err != nil {
+			return deleted, err
+		}
+
+		for _, d := range result.DeletedObjects {
+			deleted = append(deleted, oss.ToString(d.Key))
+		}
 	}
+
 	return deleted, nil
 }
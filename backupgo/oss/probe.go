@@ -0,0 +1,43 @@
+package oss
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// probeKeyPrefix 是 Probe 写入的探测对象的前缀，不会和任务的备份文件重名
+const probeKeyPrefix = ".backupgo-check-"
+
+// Probe 检查存储能否列出、写入和删除对象：先列出全部对象，再上传一个探测对象并立即删除。
+func Probe(ctx context.Context, storage Storage) error {
+	if _, err := storage.ListObjects(ctx); err != nil {
+		return fmt.Errorf("list objects failed: %w", err)
+	}
+
+	file, err := os.CreateTemp("", "backupgo-check-")
+	if err != nil {
+		return fmt.Errorf("create probe file failed: %w", err)
+	}
+	defer os.Remove(file.Name())
+	_, err = file.WriteString("backupgo check\n")
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("write probe file failed: %w", err)
+	}
+
+	key := probeKeyPrefix + strconv.FormatInt(time.Now().UnixNano(), 10)
+	if _, err := storage.Upload(ctx, key, file.Name()); err != nil {
+		return fmt.Errorf("upload probe object failed: %w", err)
+	}
+
+	// 上传成功后即使 ctx 已经取消也要删除探测对象
+	if _, err := storage.DeleteObjects(context.WithoutCancel(ctx), []string{key}); err != nil {
+		return fmt.Errorf("delete probe object %s failed: %w", key, err)
+	}
+	return nil
+}
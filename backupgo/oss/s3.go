@@ -0,0 +1,153 @@
+package oss
+
+import (
+	"backupgo/config"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Storage 对接 S3 兼容存储，例如 AWS S3、MinIO、Cloudflare R2。
+type S3Storage struct {
+	bucketName string
+	client     *minio.Client
+}
+
+func NewS3Storage(cfg config.S3Config) (*S3Storage, error) {
+	endpoint := strings.TrimSpace(cfg.Endpoint)
+	endpoint = strings.TrimPrefix(endpoint, "https://")
+	if strings.HasPrefix(endpoint, "http://") {
+		endpoint = strings.TrimPrefix(endpoint, "http://")
+		cfg.Insecure = true
+	}
+
+	lookup := minio.BucketLookupAuto
+	if cfg.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(cfg.AccessKey, cfg.AccessKeySecret, ""),
+		Secure:       !cfg.Insecure,
+		Region:       strings.TrimSpace(cfg.Region),
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create s3 client failed: %w", err)
+	}
+
+	return &S3Storage{
+		bucketName: strings.TrimSpace(cfg.BucketName),
+		client:     client,
+	}, nil
+}
+
+func (s *S3Storage) BucketName() string {
+	return s.bucketName
+}
+
+func (s *S3Storage) Upload(ctx context.Context, objKey, filePath string) (UploadResult, error) {
+	result := UploadResult{
+		Bucket: s.bucketName,
+		Key:    objKey,
+		Mode:   NORMAL,
+	}
+
+	_, err := s.client.FPutObject(ctx, s.bucketName, objKey, filePath, minio.PutObjectOptions{})
+	return result, err
+}
+
+// UploadStream 使用 S3 分片上传接口逐片上传，失败时中止分片上传，避免残留未完成的分片。
+func (s *S3Storage) UploadStream(ctx context.Context, objKey string, r io.Reader, opts StreamOptions) (UploadResult, error) {
+	result := UploadResult{
+		Bucket: s.bucketName,
+		Key:    objKey,
+		Mode:   NORMAL,
+	}
+
+	core := minio.Core{Client: s.client}
+
+	uploadID, err := core.NewMultipartUpload(ctx, s.bucketName, objKey, minio.PutObjectOptions{})
+	if err != nil {
+		return result, fmt.Errorf("create multipart upload failed: %w", err)
+	}
+
+	var parts []minio.CompletePart
+	_, err = uploadParts(ctx, r, opts, func(partNumber int, data []byte) error {
+		part, err := core.PutObjectPart(ctx, s.bucketName, objKey, uploadID, partNumber, bytes.NewReader(data), int64(len(data)), minio.PutObjectPartOptions{})
+		if err != nil {
+			return err
+		}
+
+		parts = append(parts, minio.CompletePart{PartNumber: part.PartNumber, ETag: part.ETag})
+		return nil
+	})
+	if err == nil {
+		_, err = core.CompleteMultipartUpload(ctx, s.bucketName, objKey, uploadID, parts, minio.PutObjectOptions{})
+	}
+	if err != nil {
+		// ctx 可能已经取消，中止请求仍需要发出，避免残留未完成的分片
+		_ = core.AbortMultipartUpload(context.WithoutCancel(ctx), s.bucketName, objKey, uploadID)
+		return result, err
+	}
+
+	return result, nil
+}
+
+func (s *S3Storage) Download(ctx context.Context, objKey, filePath string) error {
+	err := s.client.FGetObject(ctx, s.bucketName, objKey, filePath, minio.GetObjectOptions{})
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return notFound(err)
+	}
+	return err
+}
+
+func (s *S3Storage) ListObjects(ctx context.Context) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+
+	for obj := range s.client.ListObjects(ctx, s.bucketName, minio.ListObjectsOptions{Recursive: true}) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+
+		objects = append(objects, ObjectInfo{
+			Key:          obj.Key,
+			Size:         obj.Size,
+			LastModified: obj.LastModified,
+		})
+	}
+
+	return objects, nil
+}
+
+func (s *S3Storage) DeleteObjects(ctx context.Context, keys []string) ([]string, error) {
+	objectsCh := make(chan minio.ObjectInfo, len(keys))
+	for _, key := range keys {
+		objectsCh <- minio.ObjectInfo{Key: key}
+	}
+	close(objectsCh)
+
+	failed := make(map[string]error)
+	for removeErr := range s.client.RemoveObjects(ctx, s.bucketName, objectsCh, minio.RemoveObjectsOptions{}) {
+		failed[removeErr.ObjectName] = removeErr.Err
+	}
+
+	var deleted []string
+	var firstErr error
+	for _, key := range keys {
+		if err, ok := failed[key]; ok {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("delete %s failed: %w", key, err)
+			}
+			continue
+		}
+		deleted = append(deleted, key)
+	}
+
+	return deleted, firstErr
+}
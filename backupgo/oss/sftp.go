@@ -0,0 +1,288 @@
+package oss
+
+import (
+	"backupgo/config"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// SFTPStorage 通过 SFTP 把备份保存到远程服务器目录。每次操作单独建立连接，避免调度间隔内长时间占用连接。
+type SFTPStorage struct {
+	cfg  config.SFTPConfig
+	root string
+}
+
+func NewSFTPStorage(cfg config.SFTPConfig) *SFTPStorage {
+	return &SFTPStorage{
+		cfg:  cfg,
+		root: path.Clean(strings.TrimSpace(cfg.Path)),
+	}
+}
+
+func (s *SFTPStorage) BucketName() string {
+	return s.cfg.Host + ":" + s.root
+}
+
+func (s *SFTPStorage) Upload(ctx context.Context, objKey, filePath string) (UploadResult, error) {
+	src, err := os.Open(filePath)
+	if err != nil {
+		return UploadResult{Bucket: s.BucketName(), Key: objKey, Mode: NORMAL}, fmt.Errorf("open source file failed: %w", err)
+	}
+	defer src.Close()
+
+	return s.UploadStream(ctx, objKey, src, StreamOptions{})
+}
+
+// UploadStream 直接把流写入远程文件。SFTP 连接中断后无法续传，失败时由上层重新执行备份。
+func (s *SFTPStorage) UploadStream(ctx context.Context, objKey string, r io.Reader, _ StreamOptions) (UploadResult, error) {
+	result := UploadResult{
+		Bucket: s.BucketName(),
+		Key:    objKey,
+		Mode:   NORMAL,
+	}
+
+	target, err := s.objectPath(objKey)
+	if err != nil {
+		return result, err
+	}
+
+	err = s.withClient(ctx, func(client *sftp.Client) error {
+		if err := client.MkdirAll(path.Dir(target)); err != nil {
+			return fmt.Errorf("create remote directory failed: %w", err)
+		}
+
+		tmpFile := target + ".part"
+		dst, err := client.Create(tmpFile)
+		if err != nil {
+			return fmt.Errorf("create remote file failed: %w", err)
+		}
+
+		if _, err := dst.ReadFrom(contextReader{ctx: ctx, r: r}); err != nil {
+			_ = dst.Close()
+			_ = client.Remove(tmpFile)
+			return fmt.Errorf("write remote file failed: %w", err)
+		}
+		if err := dst.Close(); err != nil {
+			_ = client.Remove(tmpFile)
+			return fmt.Errorf("close remote file failed: %w", err)
+		}
+
+		// PosixRename 可以覆盖已存在的同名文件，服务端不支持时退回普通 Rename
+		if err := client.PosixRename(tmpFile, target); err != nil {
+			_ = client.Remove(target)
+			if err := client.Rename(tmpFile, target); err != nil {
+				_ = client.Remove(tmpFile)
+				return fmt.Errorf("rename remote file failed: %w", err)
+			}
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+func (s *SFTPStorage) Download(ctx context.Context, objKey, filePath string) error {
+	source, err := s.objectPath(objKey)
+	if err != nil {
+		return err
+	}
+
+	return s.withClient(ctx, func(client *sftp.Client) error {
+		src, err := client.Open(source)
+		if errors.Is(err, os.ErrNotExist) {
+			return notFound(err)
+		}
+		if err != nil {
+			return fmt.Errorf("open remote file failed: %w", err)
+		}
+		defer src.Close()
+
+		return writeFile(filePath, contextReader{ctx: ctx, r: src})
+	})
+}
+
+func (s *SFTPStorage) ListObjects(ctx context.Context) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+
+	err := s.withClient(ctx, func(client *sftp.Client) error {
+		walker := client.Walk(s.root)
+		for walker.Step() {
+			if err := walker.Err(); err != nil {
+				if walker.Path() == s.root && errors.Is(err, os.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+
+			info := walker.Stat()
+			if info.IsDir() || strings.HasSuffix(walker.Path(), ".part") {
+				continue
+			}
+
+			objects = append(objects, ObjectInfo{
+				Key:          strings.TrimPrefix(strings.TrimPrefix(walker.Path(), s.root), "/"),
+				Size:         info.Size(),
+				LastModified: info.ModTime(),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+func (s *SFTPStorage) DeleteObjects(ctx context.Context, keys []string) ([]string, error) {
+	var deleted []string
+
+	err := s.withClient(ctx, func(client *sftp.Client) error {
+		for _, key := range keys {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			target, err := s.objectPath(key)
+			if err != nil {
+				return err
+			}
+			if err := client.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("delete %s failed: %w", key, err)
+			}
+			s.removeEmptyDirs(client, path.Dir(target))
+			deleted = append(deleted, key)
+		}
+		return nil
+	})
+
+	return deleted, err
+}
+
+// removeEmptyDirs 从 dir 开始向上删除空目录，直到 root，避免按日期分目录的 key 清理后留下空目录。
+func (s *SFTPStorage) removeEmptyDirs(client *sftp.Client, dir string) {
+	for dir != s.root && strings.HasPrefix(dir, s.root+"/") {
+		if err := client.RemoveDirectory(dir); err != nil {
+			return
+		}
+		dir = path.Dir(dir)
+	}
+}
+
+// objectPath 返回对象在远程服务器上的路径，拒绝 .. 等超出存储目录的 key。
+func (s *SFTPStorage) objectPath(objKey string) (string, error) {
+	target := path.Join(s.root, objKey)
+	if s.root == "." {
+		if target == "." || target == ".." || strings.HasPrefix(target, "../") || path.IsAbs(target) {
+			return "", fmt.Errorf("invalid object key: %s", objKey)
+		}
+		return target, nil
+	}
+	if target == s.root || !strings.HasPrefix(target, strings.TrimSuffix(s.root, "/")+"/") {
+		return "", fmt.Errorf("invalid object key: %s", objKey)
+	}
+	return target, nil
+}
+
+// withClient 建立连接后执行 fn，ctx 取消时关闭连接，让阻塞中的读写立即返回。
+func (s *SFTPStorage) withClient(ctx context.Context, fn func(client *sftp.Client) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	sshClient, err := s.dial()
+	if err != nil {
+		return err
+	}
+	defer sshClient.Close()
+	stop := context.AfterFunc(ctx, func() { _ = sshClient.Close() })
+	defer stop()
+
+	client, err := sftp.NewClient(sshClient)
+	if err != nil {
+		return fmt.Errorf("create sftp client failed: %w", err)
+	}
+	defer client.Close()
+
+	if err := fn(client); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *SFTPStorage) dial() (*ssh.Client, error) {
+	var authMethods []ssh.AuthMethod
+	if s.cfg.Password != "" {
+		authMethods = append(authMethods, ssh.Password(s.cfg.Password))
+	}
+	if keyPath := strings.TrimSpace(s.cfg.PrivateKey); keyPath != "" {
+		keyData, err := os.ReadFile(expandHome(keyPath))
+		if err != nil {
+			return nil, fmt.Errorf("read private key failed: %w", err)
+		}
+
+		signer, err := ssh.ParsePrivateKey(keyData)
+		if err != nil {
+			return nil, fmt.Errorf("parse private key failed: %w", err)
+		}
+		authMethods = append(authMethods, ssh.PublicKeys(signer))
+	}
+
+	hostKeyCallback, err := s.hostKeyCallback()
+	if err != nil {
+		return nil, err
+	}
+
+	addr := net.JoinHostPort(strings.TrimSpace(s.cfg.Host), strconv.Itoa(s.cfg.GetPort()))
+	client, err := ssh.Dial("tcp", addr, &ssh.ClientConfig{
+		User:            s.cfg.User,
+		Auth:            authMethods,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         30 * time.Second,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("dial ssh %s failed: %w", addr, err)
+	}
+
+	return client, nil
+}
+
+// hostKeyCallback 按 known_hosts 校验服务端主机密钥，未配置时使用 ~/.ssh/known_hosts。
+// 只有显式设置 insecure_ignore_host_key 时才跳过校验。
+func (s *SFTPStorage) hostKeyCallback() (ssh.HostKeyCallback, error) {
+	if s.cfg.InsecureIgnoreHostKey {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+
+	knownHostsFile := expandHome(s.cfg.GetKnownHostsFile())
+	callback, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("load known_hosts %s failed (set sftp.known_hosts, or sftp.insecure_ignore_host_key: true to skip host key verification): %w", knownHostsFile, err)
+	}
+	return callback, nil
+}
+
+func expandHome(p string) string {
+	if strings.HasPrefix(p, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, p[2:])
+		}
+	}
+	return p
+}
@@ -0,0 +1,51 @@
+package oss
+
+import (
+	"backupgo/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSFTPObjectPathRejectsEscapingKeys(t *testing.T) {
+	for _, root := range []string{"/data/backup", "/", "backup"} {
+		storage := NewSFTPStorage(config.SFTPConfig{Path: root})
+		if _, err := storage.objectPath("app/app_2024_03_08.zip"); err != nil {
+			t.Fatalf("objectPath under %s returned error: %v", root, err)
+		}
+		for _, key := range []string{"", ".", "../app_2024_03_08.zip", "app/../../etc/passwd"} {
+			if target, err := storage.objectPath(key); err == nil && root != "/" {
+				t.Fatalf("expected key %q under %s to be rejected, got %s", key, root, target)
+			}
+		}
+	}
+
+	storage := NewSFTPStorage(config.SFTPConfig{Path: "/data/backup"})
+	if target, _ := storage.objectPath("app/app_2024_03_08.zip"); target != "/data/backup/app/app_2024_03_08.zip" {
+		t.Fatalf("unexpected object path: %s", target)
+	}
+	if _, err := storage.objectPath("../backup2/app_2024_03_08.zip"); err == nil {
+		t.Fatal("expected sibling directory to be rejected")
+	}
+}
+
+func TestSFTPHostKeyCallbackRequiresKnownHosts(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "known_hosts")
+	storage := NewSFTPStorage(config.SFTPConfig{KnownHostsFile: missing})
+	if _, err := storage.hostKeyCallback(); err == nil || !strings.Contains(err.Error(), "insecure_ignore_host_key") {
+		t.Fatalf("expected missing known_hosts to fail, got %v", err)
+	}
+
+	if err := os.WriteFile(missing, nil, 0600); err != nil {
+		t.Fatalf("write known_hosts: %v", err)
+	}
+	if _, err := storage.hostKeyCallback(); err != nil {
+		t.Fatalf("hostKeyCallback returned error: %v", err)
+	}
+
+	storage = NewSFTPStorage(config.SFTPConfig{InsecureIgnoreHostKey: true})
+	if _, err := storage.hostKeyCallback(); err != nil {
+		t.Fatalf("hostKeyCallback returned error: %v", err)
+	}
+}
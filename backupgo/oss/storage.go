@@ -0,0 +1,100 @@
+package oss
+
+import (
+	"backupgo/config"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Storage 定义备份文件的远端存储，阿里云 OSS、S3 兼容存储、本地目录和 SFTP 都实现了这个接口。
+// ctx 取消时正在进行的操作尽快中止，未完成的分片上传会被清理。
+type Storage interface {
+	// BucketName 返回用于日志和通知展示的存储位置
+	BucketName() string
+
+	// Upload 把本地文件上传为 objKey
+	Upload(ctx context.Context, objKey, filePath string) (UploadResult, error)
+
+	// UploadStream 把数据流上传为 objKey，支持分片的存储按 opts 分片上传并逐片重试
+	UploadStream(ctx context.Context, objKey string, r io.Reader, opts StreamOptions) (UploadResult, error)
+
+	// Download 把对象 objKey 下载到本地文件，对象不存在时返回的错误满足 errors.Is(err, ErrNotFound)
+	Download(ctx context.Context, objKey, filePath string) error
+
+	// ListObjects 列出存储中的全部对象
+	ListObjects(ctx context.Context) ([]ObjectInfo, error)
+
+	// DeleteObjects 删除指定对象，返回实际删除的 key
+	DeleteObjects(ctx context.Context, keys []string) ([]string, error)
+}
+
+// ErrNotFound 表示要下载的对象不存在
+var ErrNotFound = errors.New("object not found")
+
+// notFound 把存储实现的“对象不存在”错误包装为 ErrNotFound，同时保留原始错误。
+func notFound(err error) error {
+	return fmt.Errorf("%w: %w", ErrNotFound, err)
+}
+
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// New 根据存储配置创建对应的存储实现。
+func New(cfg config.StorageConfig) (Storage, error) {
+	switch cfg.GetType() {
+	case config.StorageTypeOSS:
+		return CreateOSSClient(*cfg.OSS), nil
+	case config.StorageTypeS3:
+		return NewS3Storage(*cfg.S3)
+	case config.StorageTypeLocal:
+		return NewLocalStorage(*cfg.Local)
+	case config.StorageTypeSFTP:
+		return NewSFTPStorage(*cfg.SFTP), nil
+	default:
+		return nil, fmt.Errorf("unsupported storage type: %s", cfg.Type)
+	}
+}
+
+// Registry 按名称缓存存储实例，让共用同一个存储的任务共享连接和加速上传冷却状态。
+type Registry struct {
+	cfg      config.GlobalConfig
+	mu       sync.Mutex
+	storages map[string]Storage
+}
+
+func NewRegistry(cfg config.GlobalConfig) *Registry {
+	return &Registry{
+		cfg:      cfg,
+		storages: make(map[string]Storage),
+	}
+}
+
+// Get 返回指定名称的存储实例，首次访问时创建。
+func (r *Registry) Get(name string) (Storage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if storage, ok := r.storages[name]; ok {
+		return storage, nil
+	}
+
+	storageConfig, ok := r.cfg.FindStorage(name)
+	if !ok {
+		return nil, fmt.Errorf("storage not found: %s", name)
+	}
+
+	storage, err := New(storageConfig)
+	if err != nil {
+		return nil, fmt.Errorf("create storage %s failed: %w", name, err)
+	}
+
+	r.storages[name] = storage
+	return storage, nil
+}
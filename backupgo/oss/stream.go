@@ -0,0 +1,77 @@
+package oss
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"time"
+)
+
+// StreamOptions 控制流式上传的分片大小和单个分片的重试次数。
+type StreamOptions struct {
+	PartSize    int64
+	PartRetries int
+}
+
+// uploadParts 把 r 按 PartSize 切成分片依次交给 putPart。分片失败时只重试该分片，
+// 已经上传成功的分片不受影响，也不需要重新读取前面的数据。
+func uploadParts(ctx context.Context, r io.Reader, opts StreamOptions, putPart func(partNumber int, data []byte) error) (int64, error) {
+	buf := make([]byte, opts.PartSize)
+
+	var total int64
+	for partNumber := 1; ; partNumber++ {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+
+		n, readErr := io.ReadFull(r, buf)
+		if readErr != nil && readErr != io.EOF && readErr != io.ErrUnexpectedEOF {
+			return total, readErr
+		}
+
+		// 空流也需要上传一个空分片才能完成分片上传
+		if n > 0 || partNumber == 1 {
+			err := retryPart(ctx, opts.PartRetries, func() error {
+				return putPart(partNumber, buf[:n])
+			})
+			if err != nil {
+				return total, fmt.Errorf("upload part %d failed: %w", partNumber, err)
+			}
+			total += int64(n)
+		}
+
+		if readErr != nil {
+			return total, nil
+		}
+	}
+}
+
+var retryDelay = time.Second
+
+func retryPart(ctx context.Context, retries int, fn func() error) error {
+	err := fn()
+	for attempt := 1; err != nil && attempt <= retries; attempt++ {
+		log.Printf("upload part failed, retry %d/%d: %v", attempt, retries, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt) * retryDelay):
+		}
+		err = fn()
+	}
+	return err
+}
+
+// contextReader 在 ctx 取消后让读取返回错误，用于不支持 context 的本地文件和 SFTP 拷贝。
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
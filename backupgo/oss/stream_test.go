@@ -0,0 +1,160 @@
+package oss
+
+import (
+	"backupgo/config"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestUploadPartsSplitsStream(t *testing.T) {
+	var parts []string
+	total, err := uploadParts(context.Background(), strings.NewReader("abcdefgh"), StreamOptions{PartSize: 3}, func(partNumber int, data []byte) error {
+		parts = append(parts, string(data))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("uploadParts returned error: %v", err)
+	}
+
+	if total != 8 {
+		t.Fatalf("unexpected total: %d", total)
+	}
+	if want := []string{"abc", "def", "gh"}; !reflect.DeepEqual(parts, want) {
+		t.Fatalf("unexpected parts: got %v want %v", parts, want)
+	}
+}
+
+func TestUploadPartsUploadsSinglePartForEmptyStream(t *testing.T) {
+	calls := 0
+	if _, err := uploadParts(context.Background(), bytes.NewReader(nil), StreamOptions{PartSize: 3}, func(partNumber int, data []byte) error {
+		calls++
+		return nil
+	}); err != nil {
+		t.Fatalf("uploadParts returned error: %v", err)
+	}
+
+	if calls != 1 {
+		t.Fatalf("expected one empty part, got %d", calls)
+	}
+}
+
+func TestUploadPartsRetriesFailedPart(t *testing.T) {
+	defer func(delay time.Duration) { retryDelay = delay }(retryDelay)
+	retryDelay = 0
+
+	attempts := map[int]int{}
+	_, err := uploadParts(context.Background(), strings.NewReader("abcdef"), StreamOptions{PartSize: 3, PartRetries: 2}, func(partNumber int, data []byte) error {
+		attempts[partNumber]++
+		if partNumber == 2 && attempts[partNumber] < 3 {
+			return errors.New("temporary failure")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("uploadParts returned error: %v", err)
+	}
+
+	if want := map[int]int{1: 1, 2: 3}; !reflect.DeepEqual(attempts, want) {
+		t.Fatalf("unexpected attempts: got %v want %v", attempts, want)
+	}
+}
+
+func TestUploadPartsStopsAfterRetries(t *testing.T) {
+	defer func(delay time.Duration) { retryDelay = delay }(retryDelay)
+	retryDelay = 0
+
+	_, err := uploadParts(context.Background(), strings.NewReader("abc"), StreamOptions{PartSize: 3, PartRetries: 1}, func(partNumber int, data []byte) error {
+		return errors.New("permanent failure")
+	})
+	if err == nil {
+		t.Fatal("expected uploadParts to fail")
+	}
+}
+
+func TestLocalStorageUploadStream(t *testing.T) {
+	storage, err := NewLocalStorage(config.LocalStorageConfig{Path: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewLocalStorage returned error: %v", err)
+	}
+
+	if _, err := storage.UploadStream(context.Background(), "app_2024_03_08.zip", strings.NewReader("backupgo"), StreamOptions{}); err != nil {
+		t.Fatalf("UploadStream returned error: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(storage.BucketName(), "app_2024_03_08.zip"))
+	if err != nil {
+		t.Fatalf("read uploaded file: %v", err)
+	}
+	if string(content) != "backupgo" {
+		t.Fatalf("unexpected content: %q", content)
+	}
+}
+
+func TestLocalStorageUploadStreamDiscardsPartialFile(t *testing.T) {
+	root := t.TempDir()
+	storage, err := NewLocalStorage(config.LocalStorageConfig{Path: root})
+	if err != nil {
+		t.Fatalf("NewLocalStorage returned error: %v", err)
+	}
+
+	reader := io.MultiReader(strings.NewReader("partial"), iotest.ErrReader(errors.New("export failed")))
+	if _, err := storage.UploadStream(context.Background(), "app_2024_03_08.zip", reader, StreamOptions{}); err == nil {
+		t.Fatal("expected UploadStream to fail")
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatalf("read storage dir: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected no files left behind, got %d", len(entries))
+	}
+}
+
+func TestUploadPartsStopsWhenContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var parts int
+	_, err := uploadParts(ctx, strings.NewReader("abcdefgh"), StreamOptions{PartSize: 3, PartRetries: 3}, func(partNumber int, data []byte) error {
+		parts++
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if parts != 1 {
+		t.Fatalf("expected upload to stop after the first part, got %d parts", parts)
+	}
+}
+
+func TestLocalStorageUploadStreamStopsWhenContextCanceled(t *testing.T) {
+	root := t.TempDir()
+	storage, err := NewLocalStorage(config.LocalStorageConfig{Path: root})
+	if err != nil {
+		t.Fatalf("NewLocalStorage returned error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := storage.UploadStream(ctx, "app_2024_03_08.zip", strings.NewReader("backupgo"), StreamOptions{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatalf("read storage dir: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected no files left behind, got %d", len(entries))
+	}
+}
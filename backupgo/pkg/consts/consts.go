@@ -6,4 +6,9 @@ const (
 	LogFileName       = AppName + ".log"
 	LogBackupFileName = LogFileName + ".bak"
 	StateFileName     = AppName + ".state.json"
+	HistoryFileName   = AppName + ".history.jsonl"
+	SnapshotCacheDir  = "snapshots"
 )
+
+// Version 是 backupgo 的版本号，发布时通过 -ldflags "-X backupgo/pkg/consts.Version=v1.2.3" 注入
+var Version = "dev"
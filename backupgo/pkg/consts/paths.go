@@ -63,3 +63,23 @@ func StateFilePath() (string, error) {
 
 	return filepath.Join(dir, StateFileName), nil
 }
+
+// HistoryFilePath 返回运行历史文件路径，每行一条 JSON 记录。
+func HistoryFilePath() (string, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, HistoryFileName), nil
+}
+
+// SnapshotCacheFilePath 返回增量备份最近一次快照清单的本地缓存路径。
+func SnapshotCacheFilePath(taskID string) (string, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, SnapshotCacheDir, taskID+".json"), nil
+}
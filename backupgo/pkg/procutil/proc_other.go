@@ -23,3 +23,7 @@ func IsRunning(pid int) (bool, error) {
 func Terminate(pid int) error {
 	return errors.New("terminate is only supported on Unix-like systems")
 }
+
+func Reload(pid int) error {
+	return errors.New("reload is only supported on Unix-like systems")
+}
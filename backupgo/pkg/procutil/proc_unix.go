@@ -27,3 +27,12 @@ func Terminate(pid int) error {
 
 	return syscall.Kill(pid, syscall.SIGTERM)
 }
+
+// Reload 通知调度进程重新加载配置
+func Reload(pid int) error {
+	if pid <= 0 {
+		return nil
+	}
+
+	return syscall.Kill(pid, syscall.SIGHUP)
+}
@@ -0,0 +1,120 @@
+package retention
+
+import (
+	"backupgo/utils"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Policy 描述备份保留规则。多条规则之间是“或”的关系，只要有一条规则保留，备份就不会被删除。
+type Policy struct {
+	// KeepLast 保留最新的 N 个备份
+	KeepLast int
+	// KeepDays 保留最近 N 天内的备份
+	KeepDays int
+	// KeepDaily / KeepWeekly / KeepMonthly / KeepYearly 在最近 N 个有备份的日/周/月/年中，各保留该周期内最新的一个
+	KeepDaily   int
+	KeepWeekly  int
+	KeepMonthly int
+	KeepYearly  int
+}
+
+// Item 表示一个可参与保留计算的备份对象。
+type Item struct {
+	Key  string
+	Time time.Time
+}
+
+// Apply 对全部备份统一计算保留结果，返回需要保留和需要删除的备份，两者都按时间从新到旧排序。
+func (p Policy) Apply(items []Item, now time.Time) (keep []Item, remove []Item) {
+	sorted := make([]Item, len(items))
+	copy(sorted, items)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].Time.Equal(sorted[j].Time) {
+			return sorted[i].Time.After(sorted[j].Time)
+		}
+		return sorted[i].Key > sorted[j].Key
+	})
+
+	kept := make([]bool, len(sorted))
+	for i := 0; i < len(sorted) && i < p.KeepLast; i++ {
+		kept[i] = true
+	}
+
+	if p.KeepDays > 0 {
+		beforeDate := now.AddDate(0, 0, -p.KeepDays)
+		for i, item := range sorted {
+			if !item.Time.Before(beforeDate) {
+				kept[i] = true
+			}
+		}
+	}
+
+	keepPerBucket(sorted, kept, p.KeepDaily, func(t time.Time) string { return t.Format("2006-01-02") })
+	keepPerBucket(sorted, kept, p.KeepWeekly, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	})
+	keepPerBucket(sorted, kept, p.KeepMonthly, func(t time.Time) string { return t.Format("2006-01") })
+	keepPerBucket(sorted, kept, p.KeepYearly, func(t time.Time) string { return t.Format("2006") })
+
+	for i, item := range sorted {
+		if kept[i] {
+			keep = append(keep, item)
+		} else {
+			remove = append(remove, item)
+		}
+	}
+
+	return keep, remove
+}
+
+// keepPerBucket 从新到旧遍历，在最近 limit 个不同的周期里各保留最新的一个备份。
+func keepPerBucket(sorted []Item, kept []bool, limit int, bucketOf func(time.Time) string) {
+	if limit <= 0 {
+		return
+	}
+
+	count := 0
+	lastBucket := ""
+	for i, item := range sorted {
+		bucket := bucketOf(item.Time)
+		if bucket == lastBucket {
+			continue
+		}
+
+		kept[i] = true
+		lastBucket = bucket
+		count++
+		if count >= limit {
+			return
+		}
+	}
+}
+
+// ParseItems 用任务的 key 模板从对象 key 中挑出该任务的备份文件，不符合模板的 key 会被忽略。
+func ParseItems(names *utils.FileNameProcessor, keys []string) []Item {
+	var items []Item
+	for _, key := range keys {
+		result, err := names.Parse(key)
+		if err != nil {
+			continue
+		}
+
+		items = append(items, Item{Key: key, Time: result.ToTime()})
+	}
+
+	return items
+}
+
+// ExpiredKeys 返回按策略需要删除的对象 key。
+func ExpiredKeys(names *utils.FileNameProcessor, keys []string, policy Policy, now time.Time) []string {
+	_, remove := policy.Apply(ParseItems(names, keys), now)
+
+	expired := make([]string, 0, len(remove))
+	for _, item := range remove {
+		expired = append(expired, item.Key)
+	}
+	return expired
+}
@@ -0,0 +1,111 @@
+package retention
+
+import (
+	"backupgo/utils"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func dailyKeys(prefix string, from time.Time, days int) []string {
+	keys := make([]string, 0, days)
+	for i := 0; i < days; i++ {
+		day := from.AddDate(0, 0, -i)
+		keys = append(keys, prefix+"_"+day.Format("2006_01_02")+".zip")
+	}
+	return keys
+}
+
+func keyNames(t *testing.T, template string, taskID string) *utils.FileNameProcessor {
+	t.Helper()
+	names, err := utils.NewFileNameProcessor(template, taskID, "web-1")
+	if err != nil {
+		t.Fatalf("compile key template: %v", err)
+	}
+	return names
+}
+
+func TestKeepDaysMatchesLegacyWindow(t *testing.T) {
+	now := time.Date(2024, 3, 10, 10, 0, 0, 0, time.UTC)
+	keys := []string{
+		"test_cc_s_2024_03_01.zip",
+		"test_cc_s_2024_03_09.zip",
+		"other_prefix_2024_03_01.zip",
+		"invalid-name",
+	}
+
+	got := ExpiredKeys(keyNames(t, utils.DefaultKeyTemplate, "test_cc_s"), keys, Policy{KeepDays: 7}, now)
+	if want := []string{"test_cc_s_2024_03_01.zip"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected expired keys: %#v", got)
+	}
+}
+
+func TestKeepLast(t *testing.T) {
+	now := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	keys := dailyKeys("app", now, 5)
+
+	got := ExpiredKeys(keyNames(t, utils.DefaultKeyTemplate, "app"), keys, Policy{KeepLast: 2}, now)
+	want := []string{"app_2024_03_08.zip", "app_2024_03_07.zip", "app_2024_03_06.zip"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected expired keys: %#v", got)
+	}
+}
+
+func TestGrandfatherFatherSon(t *testing.T) {
+	now := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+	keys := dailyKeys("app", now, 400)
+
+	keep, _ := Policy{KeepDaily: 7, KeepMonthly: 12}.Apply(ParseItems(keyNames(t, utils.DefaultKeyTemplate, "app"), keys), now)
+
+	var kept []string
+	for _, item := range keep {
+		kept = append(kept, item.Key)
+	}
+
+	want := []string{
+		"app_2024_12_31.zip", "app_2024_12_30.zip", "app_2024_12_29.zip", "app_2024_12_28.zip",
+		"app_2024_12_27.zip", "app_2024_12_26.zip", "app_2024_12_25.zip",
+		"app_2024_11_30.zip", "app_2024_10_31.zip", "app_2024_09_30.zip", "app_2024_08_31.zip",
+		"app_2024_07_31.zip", "app_2024_06_30.zip", "app_2024_05_31.zip", "app_2024_04_30.zip",
+		"app_2024_03_31.zip", "app_2024_02_29.zip", "app_2024_01_31.zip",
+	}
+	if !reflect.DeepEqual(kept, want) {
+		t.Fatalf("unexpected kept keys: %#v", kept)
+	}
+}
+
+func TestWeeklyAndYearlyBuckets(t *testing.T) {
+	now := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	items := []Item{
+		{Key: "a", Time: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)}, // 2024-W10
+		{Key: "b", Time: time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)},  // 2024-W10
+		{Key: "c", Time: time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)},  // 2024-W09
+		{Key: "d", Time: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{Key: "e", Time: time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	keep, remove := Policy{KeepWeekly: 2, KeepYearly: 2}.Apply(items, now)
+	if len(keep) != 3 || keep[0].Key != "a" || keep[1].Key != "c" || keep[2].Key != "d" {
+		t.Fatalf("unexpected kept items: %#v", keep)
+	}
+	if len(remove) != 2 || remove[0].Key != "b" || remove[1].Key != "e" {
+		t.Fatalf("unexpected removed items: %#v", remove)
+	}
+}
+
+func TestKeyTemplateKeepsSeveralRunsPerDayAndIgnoresOtherHosts(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	keys := []string{
+		"web-1/app/app_2024_03_10_020000.zip",
+		"web-1/app/app_2024_03_10_080000.zip",
+		"web-1/app/app_2024_03_09_020000.zip",
+		"web-2/app/app_2024_03_01_020000.zip",
+		"app_2024_03_01.zip",
+	}
+
+	got := ExpiredKeys(keyNames(t, "{host}/{id}/{id}_{timestamp}.{ext}", "app"), keys, Policy{KeepLast: 1}, now)
+	want := []string{"web-1/app/app_2024_03_10_020000.zip", "web-1/app/app_2024_03_09_020000.zip"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected expired keys: %#v", got)
+	}
+}
@@ -0,0 +1,429 @@
+package snapshot
+
+import (
+	"backupgo/config"
+	"backupgo/encrypt"
+	"backupgo/oss"
+	"backupgo/utils"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Options 增量备份参数。
+type Options struct {
+	TaskID     string
+	Source     string
+	Storage    oss.Storage
+	Encryption *config.EncryptionConfig
+	PackSize   int64
+	// Filter 筛选源目录中参与备份的文件，为 nil 时包含全部文件
+	Filter *utils.PathFilter
+	Logger *slog.Logger
+}
+
+// Result 汇总一次增量备份的结果。
+type Result struct {
+	ManifestKey string
+	Files       int
+	TotalBytes  int64
+	NewBlobs    int
+	NewBytes    int64
+	Packs       int
+	// Excluded 是被 Filter 排除的文件统计
+	Excluded utils.FilterStats
+	// Manifest 是上传的快照清单，ManifestSize 和 ManifestSHA256 是清单对象（加密后）的大小和摘要
+	Manifest       *Manifest
+	ManifestSize   int64
+	ManifestSHA256 string
+}
+
+type pendingBlob struct {
+	hash string
+	path string
+	size int64
+}
+
+// Create 扫描源目录并上传一次快照：按内容 SHA-256 去重，只把存储中还没有的内容打包成数据包上传，
+// 最后上传描述完整目录的清单。大小和修改时间与上次快照相同的文件直接沿用上次的哈希，不再读取内容。
+func Create(ctx context.Context, opts Options) (Result, error) {
+	var result Result
+
+	objects, err := opts.Storage.ListObjects(ctx)
+	if err != nil {
+		return result, fmt.Errorf("list objects failed: %w", err)
+	}
+	existing := make(map[string]bool, len(objects))
+	for _, obj := range objects {
+		existing[obj.Key] = true
+	}
+
+	previous, err := loadCachedManifest(opts.TaskID)
+	if err != nil {
+		opts.Logger.Warn("snapshot cache unavailable, hashing all files", "error", err)
+	}
+
+	// 只沿用数据包仍然存在的内容，数据包被清理后会重新上传
+	blobs := make(map[string]string)
+	previousFiles := make(map[string]FileEntry)
+	if previous != nil {
+		for hash, packKey := range previous.Blobs {
+			if existing[packKey] {
+				blobs[hash] = packKey
+			}
+		}
+		for _, file := range previous.Files {
+			previousFiles[file.Path] = file
+		}
+	}
+	for hash, packKey := range remoteBlobs(ctx, opts, objects, previous) {
+		if existing[packKey] && blobs[hash] == "" {
+			blobs[hash] = packKey
+		}
+	}
+
+	manifest := &Manifest{
+		Version:   manifestVersion,
+		TaskID:    opts.TaskID,
+		CreatedAt: time.Now(),
+		Blobs:     make(map[string]string),
+	}
+
+	source := filepath.Clean(opts.Source)
+	baseDir := filepath.Base(source)
+	var pending []pendingBlob
+	queued := make(map[string]bool)
+
+	result.Excluded, err = utils.WalkFiltered(source, opts.Filter, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return fmt.Errorf("walk failed: %w", err)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(source, path)
+		if err != nil {
+			return fmt.Errorf("rel path failed: %w", err)
+		}
+		entryPath := filepath.ToSlash(filepath.Join(baseDir, relPath))
+
+		if info.IsDir() {
+			manifest.Dirs = append(manifest.Dirs, entryPath)
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			opts.Logger.Warn("skip non-regular file", "path", path)
+			return nil
+		}
+
+		entry := FileEntry{
+			Path:    entryPath,
+			Size:    info.Size(),
+			Mode:    info.Mode().Perm(),
+			ModTime: info.ModTime(),
+		}
+
+		prev, ok := previousFiles[entryPath]
+		if ok && prev.Size == entry.Size && prev.ModTime.Equal(entry.ModTime) && blobs[prev.Hash] != "" {
+			entry.Hash = prev.Hash
+		} else {
+			entry.Hash, err = hashFile(path)
+			if err != nil {
+				return err
+			}
+		}
+
+		if blobs[entry.Hash] == "" && !queued[entry.Hash] {
+			queued[entry.Hash] = true
+			pending = append(pending, pendingBlob{hash: entry.Hash, path: path, size: entry.Size})
+		}
+
+		manifest.Files = append(manifest.Files, entry)
+		result.Files++
+		result.TotalBytes += entry.Size
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	opts.Logger.Info("snapshot scan completed", "files", result.Files, "size", result.TotalBytes, "new_blobs", len(pending))
+
+	for _, group := range groupPacks(pending, opts.PackSize) {
+		packKey, err := uploadPack(ctx, opts, group)
+		if err != nil {
+			return result, err
+		}
+
+		for _, blob := range group {
+			blobs[blob.hash] = packKey
+			result.NewBlobs++
+			result.NewBytes += blob.size
+		}
+		result.Packs++
+	}
+
+	for _, file := range manifest.Files {
+		manifest.Blobs[file.Hash] = blobs[file.Hash]
+	}
+
+	result.ManifestKey = ManifestKey(opts.TaskID, opts.Encryption)
+	if err := uploadIndex(ctx, opts, result.ManifestKey, manifest); err != nil {
+		return result, err
+	}
+	result.ManifestSize, result.ManifestSHA256, err = uploadManifest(ctx, opts, result.ManifestKey, manifest)
+	if err != nil {
+		return result, err
+	}
+	result.Manifest = manifest
+
+	if err := saveCachedManifest(manifest); err != nil {
+		opts.Logger.Warn("save snapshot cache failed", "error", err)
+	}
+
+	return result, nil
+}
+
+// remoteBlobs 在本机缓存缺失或早于存储中最新的快照时（例如换了主机、缓存被删除），读取最新的快照清单，
+// 返回其中已经上传的内容，避免重新上传全部数据。清单加密且本机只有公钥时无法读取，只能重新上传。
+func remoteBlobs(ctx context.Context, opts Options, objects []oss.ObjectInfo, cached *Manifest) map[string]string {
+	key, date := latestManifestKey(opts.TaskID, objects)
+	if key == "" {
+		return nil
+	}
+	if cached != nil && cached.CreatedAt.Format("20060102") >= date {
+		return nil
+	}
+
+	manifest, err := downloadManifest(ctx, opts.Storage, key, opts.Encryption)
+	if err != nil {
+		opts.Logger.Warn("read latest snapshot manifest failed, uploading content missing from the local cache", "key", key, "error", err)
+		return nil
+	}
+	opts.Logger.Info("known blobs loaded from latest snapshot", "key", key, "blobs", len(manifest.Blobs))
+	return manifest.Blobs
+}
+
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("open file failed: %w", err)
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", fmt.Errorf("hash file failed: %w", err)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// groupPacks 按大小把待上传内容分组，超过 packSize 的单个文件独占一个数据包。
+func groupPacks(pending []pendingBlob, packSize int64) [][]pendingBlob {
+	var groups [][]pendingBlob
+	var current []pendingBlob
+	var currentSize int64
+
+	for _, blob := range pending {
+		if len(current) > 0 && currentSize+blob.size > packSize {
+			groups = append(groups, current)
+			current = nil
+			currentSize = 0
+		}
+		current = append(current, blob)
+		currentSize += blob.size
+	}
+	if len(current) > 0 {
+		groups = append(groups, current)
+	}
+
+	return groups
+}
+
+// uploadPack 把一组内容写成 zip 数据包（条目名为内容哈希），按需加密后上传。
+func uploadPack(ctx context.Context, opts Options, group []pendingBlob) (string, error) {
+	hashes := make([]string, 0, len(group))
+	streams := make([]utils.StreamFile, 0, len(group))
+	for _, blob := range group {
+		hashes = append(hashes, blob.hash)
+		streams = append(streams, utils.StreamFile{
+			Name: blob.hash,
+			Open: func(context.Context) (io.ReadCloser, error) {
+				return openVerified(blob.path, blob.hash)
+			},
+		})
+	}
+
+	packKey := packPrefix(opts.TaskID) + packID(hashes) + ".zip"
+	if opts.Encryption != nil {
+		packKey += encrypt.Extension(*opts.Encryption)
+	}
+
+	tmpFile, err := os.CreateTemp("", "backupgo-pack-")
+	if err != nil {
+		return "", fmt.Errorf("create temp pack failed: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if err := writePack(ctx, tmpFile, opts.Encryption, streams); err != nil {
+		_ = tmpFile.Close()
+		return "", err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return "", fmt.Errorf("close temp pack failed: %w", err)
+	}
+
+	opts.Logger.Info("snapshot pack upload started", "key", packKey, "blobs", len(group))
+	if _, err := opts.Storage.Upload(ctx, packKey, tmpFile.Name()); err != nil {
+		return "", fmt.Errorf("upload pack %s failed: %w", packKey, err)
+	}
+
+	return packKey, nil
+}
+
+func writePack(ctx context.Context, w io.Writer, encryption *config.EncryptionConfig, streams []utils.StreamFile) error {
+	var encWriter io.WriteCloser
+	if encryption != nil {
+		var err error
+		encWriter, err = encrypt.NewWriter(*encryption, w)
+		if err != nil {
+			return fmt.Errorf("create encrypt writer failed: %w", err)
+		}
+		w = encWriter
+	}
+
+	if err := utils.WriteArchive(ctx, w, utils.ArchiveOptions{}, nil, streams, func(string, int64, int64, float64) {}, nil); err != nil {
+		return err
+	}
+
+	if encWriter != nil {
+		if err := encWriter.Close(); err != nil {
+			return fmt.Errorf("finish encryption failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// packID 由数据包内的内容哈希生成，同一组内容得到相同的 key。
+func packID(hashes []string) string {
+	sorted := append([]string(nil), hashes...)
+	sort.Strings(sorted)
+
+	sum := sha256.Sum256([]byte(strings.Join(sorted, "\n")))
+	return hex.EncodeToString(sum[:16])
+}
+
+func uploadIndex(ctx context.Context, opts Options, manifestKey string, manifest *Manifest) error {
+	key, err := indexKey(opts.TaskID, manifestKey)
+	if err != nil {
+		return err
+	}
+
+	packs := make(map[string]bool)
+	for _, packKey := range manifest.Blobs {
+		packs[packKey] = true
+	}
+	var lines []string
+	for packKey := range packs {
+		lines = append(lines, packKey)
+	}
+	sort.Strings(lines)
+
+	_, _, err = uploadBytes(ctx, opts.Storage, key, []byte(strings.Join(lines, "\n")+"\n"), nil)
+	return err
+}
+
+func uploadManifest(ctx context.Context, opts Options, manifestKey string, manifest *Manifest) (int64, string, error) {
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return 0, "", fmt.Errorf("encode manifest failed: %w", err)
+	}
+
+	return uploadBytes(ctx, opts.Storage, manifestKey, data, opts.Encryption)
+}
+
+// uploadBytes 把 data（以及加密）上传为 key，返回上传对象的大小和 SHA-256 摘要。
+func uploadBytes(ctx context.Context, storage oss.Storage, key string, data []byte, encryption *config.EncryptionConfig) (int64, string, error) {
+	tmpFile, err := os.CreateTemp("", "backupgo-snapshot-")
+	if err != nil {
+		return 0, "", fmt.Errorf("create temp file failed: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	hasher := sha256.New()
+	out := io.MultiWriter(tmpFile, hasher)
+	var w io.Writer = out
+	var encWriter io.WriteCloser
+	if encryption != nil {
+		encWriter, err = encrypt.NewWriter(*encryption, out)
+		if err != nil {
+			_ = tmpFile.Close()
+			return 0, "", fmt.Errorf("create encrypt writer failed: %w", err)
+		}
+		w = encWriter
+	}
+
+	if _, err := w.Write(data); err != nil {
+		_ = tmpFile.Close()
+		return 0, "", fmt.Errorf("write temp file failed: %w", err)
+	}
+	if encWriter != nil {
+		if err := encWriter.Close(); err != nil {
+			_ = tmpFile.Close()
+			return 0, "", fmt.Errorf("finish encryption failed: %w", err)
+		}
+	}
+	if err := tmpFile.Close(); err != nil {
+		return 0, "", fmt.Errorf("close temp file failed: %w", err)
+	}
+
+	info, err := os.Stat(tmpFile.Name())
+	if err != nil {
+		return 0, "", fmt.Errorf("stat temp file failed: %w", err)
+	}
+
+	if _, err := storage.Upload(ctx, key, tmpFile.Name()); err != nil {
+		return 0, "", fmt.Errorf("upload %s failed: %w", key, err)
+	}
+	return info.Size(), hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// verifiedReader 在读完文件时校验内容哈希，防止扫描之后文件被修改导致数据包内容与哈希不一致。
+type verifiedReader struct {
+	file   *os.File
+	want   string
+	hasher hash.Hash
+}
+
+func openVerified(path string, want string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &verifiedReader{file: file, want: want, hasher: sha256.New()}, nil
+}
+
+func (r *verifiedReader) Read(p []byte) (int, error) {
+	n, err := r.file.Read(p)
+	r.hasher.Write(p[:n])
+	if err == io.EOF && hex.EncodeToString(r.hasher.Sum(nil)) != r.want {
+		return n, fmt.Errorf("file %s changed during backup", r.file.Name())
+	}
+	return n, err
+}
+
+func (r *verifiedReader) Close() error {
+	return r.file.Close()
+}
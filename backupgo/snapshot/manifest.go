@@ -0,0 +1,184 @@
+package snapshot
+
+import (
+	"backupgo/config"
+	"backupgo/encrypt"
+	"backupgo/oss"
+	"backupgo/pkg/consts"
+	"backupgo/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	manifestVersion = 1
+
+	// manifestSuffix 追加在备份文件名后面，清单与 zip 备份共用日期命名，保留规则和 restore 都能直接识别
+	manifestSuffix = ".snapshot.json"
+)
+
+// Manifest 描述一次增量备份的完整文件列表，以及每个文件内容所在的数据包。
+type Manifest struct {
+	Version   int               `json:"version"`
+	TaskID    string            `json:"task_id"`
+	CreatedAt time.Time         `json:"created_at"`
+	Dirs      []string          `json:"dirs"`
+	Files     []FileEntry       `json:"files"`
+	Blobs     map[string]string `json:"blobs"`
+}
+
+// FileEntry 记录单个文件，Path 与 zip 备份中的条目路径一致，包含源目录名。
+type FileEntry struct {
+	Path    string      `json:"path"`
+	Size    int64       `json:"size"`
+	Mode    os.FileMode `json:"mode"`
+	ModTime time.Time   `json:"mod_time"`
+	Hash    string      `json:"hash"`
+}
+
+// ManifestKey 返回当天快照清单的对象 key。
+func ManifestKey(taskID string, encryption *config.EncryptionConfig) string {
+	key := utils.GetFileName(taskID, manifestSuffix)
+	if encryption != nil {
+		key += encrypt.Extension(*encryption)
+	}
+	return key
+}
+
+// IsManifestKey 判断对象 key 是否为快照清单。
+func IsManifestKey(key string) bool {
+	return strings.HasSuffix(encrypt.TrimExtension(key), manifestSuffix)
+}
+
+// dataPrefix 返回任务数据包和索引所在的目录，名称中带 '.'，不会被保留规则当成备份文件。
+func dataPrefix(taskID string) string {
+	return taskID + ".data/"
+}
+
+func packPrefix(taskID string) string {
+	return dataPrefix(taskID) + "packs/"
+}
+
+func indexPrefix(taskID string) string {
+	return dataPrefix(taskID) + "index/"
+}
+
+// indexKey 返回清单对应的数据包索引。索引不加密，只记录数据包 key，
+// 只有公钥的备份机器也能据此清理不再引用的数据包。
+func indexKey(taskID string, manifestKey string) (string, error) {
+	result, err := utils.ParseFileName(filepath.Base(manifestKey))
+	if err != nil {
+		return "", err
+	}
+	return indexPrefix(taskID) + result.ToTime().Format("20060102") + ".packs", nil
+}
+
+// latestManifestKey 返回存储中任务最新的快照清单 key 及其日期（YYYYMMDD），没有快照时返回空字符串。
+func latestManifestKey(taskID string, objects []oss.ObjectInfo) (string, string) {
+	var latestKey, latestDate string
+	for _, obj := range objects {
+		if !IsManifestKey(obj.Key) {
+			continue
+		}
+		result, err := utils.ParseFileName(filepath.Base(obj.Key))
+		if err != nil || !strings.EqualFold(result.Prefix, taskID) {
+			continue
+		}
+		if date := result.ToTime().Format("20060102"); date > latestDate {
+			latestKey, latestDate = obj.Key, date
+		}
+	}
+	return latestKey, latestDate
+}
+
+// downloadManifest 下载存储中的快照清单，按后缀判断是否需要解密。
+func downloadManifest(ctx context.Context, storage oss.Storage, key string, encryption *config.EncryptionConfig) (*Manifest, error) {
+	tempDir, err := os.MkdirTemp("", "backupgo-snapshot-")
+	if err != nil {
+		return nil, fmt.Errorf("create temp dir failed: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	manifestFile := filepath.Join(tempDir, filepath.Base(key))
+	if err := storage.Download(ctx, key, manifestFile); err != nil {
+		return nil, fmt.Errorf("download manifest %s failed: %w", key, err)
+	}
+
+	if encrypt.IsEncrypted(key) {
+		if encryption == nil {
+			return nil, fmt.Errorf("manifest %s is encrypted but no encryption config is provided", key)
+		}
+
+		decryptedFile := encrypt.TrimExtension(manifestFile)
+		if err := encrypt.DecryptFile(*encryption, manifestFile, decryptedFile); err != nil {
+			return nil, fmt.Errorf("decrypt manifest %s failed: %w", key, err)
+		}
+		manifestFile = decryptedFile
+	}
+
+	return ReadManifest(manifestFile)
+}
+
+// ReadManifest 读取已经解密的清单文件。
+func ReadManifest(path string) (*Manifest, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open manifest failed: %w", err)
+	}
+	defer file.Close()
+
+	return decodeManifest(file)
+}
+
+func decodeManifest(r io.Reader) (*Manifest, error) {
+	var manifest Manifest
+	if err := json.NewDecoder(r).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("decode manifest failed: %w", err)
+	}
+	if manifest.Version != manifestVersion {
+		return nil, fmt.Errorf("unsupported manifest version: %d", manifest.Version)
+	}
+	return &manifest, nil
+}
+
+// loadCachedManifest 读取本机缓存的上一次快照清单，用于跳过未变化文件的哈希计算。
+func loadCachedManifest(taskID string) (*Manifest, error) {
+	cacheFile, err := consts.SnapshotCacheFilePath(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := ReadManifest(cacheFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return manifest, err
+}
+
+func saveCachedManifest(manifest *Manifest) error {
+	cacheFile, err := consts.SnapshotCacheFilePath(manifest.TaskID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(cacheFile), 0755); err != nil {
+		return fmt.Errorf("create snapshot cache dir failed: %w", err)
+	}
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("encode manifest failed: %w", err)
+	}
+
+	tmpFile := cacheFile + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0600); err != nil {
+		return fmt.Errorf("write snapshot cache failed: %w", err)
+	}
+	return os.Rename(tmpFile, cacheFile)
+}
@@ -0,0 +1,122 @@
+package snapshot
+
+import (
+	"backupgo/oss"
+	"backupgo/utils"
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Prune 清理不再被任何快照引用的数据包，以及对应清单已被删除的索引。
+// 需要在保留规则删除过期清单之后调用，只读取不加密的索引，不需要私钥。
+func Prune(ctx context.Context, taskID string, storage oss.Storage) ([]string, error) {
+	objects, err := storage.ListObjects(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list objects failed: %w", err)
+	}
+
+	expired, err := unreferenced(ctx, taskID, storage, objects)
+	if err != nil || len(expired) == 0 {
+		return nil, err
+	}
+	return storage.DeleteObjects(ctx, expired)
+}
+
+// PreviewPrune 返回删除 deleted 中的对象（保留规则删除的快照清单）之后，Prune 会删除的索引和数据包，
+// 不删除任何对象。
+func PreviewPrune(ctx context.Context, taskID string, storage oss.Storage, deleted []string) ([]string, error) {
+	objects, err := storage.ListObjects(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list objects failed: %w", err)
+	}
+
+	remaining := objects[:0]
+	for _, obj := range objects {
+		if !slices.Contains(deleted, obj.Key) {
+			remaining = append(remaining, obj)
+		}
+	}
+	return unreferenced(ctx, taskID, storage, remaining)
+}
+
+// unreferenced 返回 objects 中不再被任何快照引用的数据包，以及对应清单已不存在的索引。
+func unreferenced(ctx context.Context, taskID string, storage oss.Storage, objects []oss.ObjectInfo) ([]string, error) {
+	manifestDates := make(map[string]bool)
+	for _, obj := range objects {
+		if !IsManifestKey(obj.Key) {
+			continue
+		}
+		result, err := utils.ParseFileName(filepath.Base(obj.Key))
+		if err != nil || !strings.EqualFold(result.Prefix, taskID) {
+			continue
+		}
+		manifestDates[result.ToTime().Format("20060102")] = true
+	}
+
+	tempDir, err := os.MkdirTemp("", "backupgo-prune-")
+	if err != nil {
+		return nil, fmt.Errorf("create temp dir failed: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	var expired []string
+	referenced := make(map[string]bool)
+	for _, obj := range objects {
+		if !strings.HasPrefix(obj.Key, indexPrefix(taskID)) {
+			continue
+		}
+
+		date := strings.TrimSuffix(filepath.Base(obj.Key), ".packs")
+		if !manifestDates[date] {
+			expired = append(expired, obj.Key)
+			continue
+		}
+
+		packs, err := readIndex(ctx, storage, obj.Key, tempDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, packKey := range packs {
+			referenced[packKey] = true
+		}
+	}
+
+	for _, obj := range objects {
+		if strings.HasPrefix(obj.Key, packPrefix(taskID)) && !referenced[obj.Key] {
+			expired = append(expired, obj.Key)
+		}
+	}
+
+	return expired, nil
+}
+
+func readIndex(ctx context.Context, storage oss.Storage, key string, tempDir string) ([]string, error) {
+	indexFile := filepath.Join(tempDir, filepath.Base(key))
+	if err := storage.Download(ctx, key, indexFile); err != nil {
+		return nil, fmt.Errorf("download index %s failed: %w", key, err)
+	}
+
+	file, err := os.Open(indexFile)
+	if err != nil {
+		return nil, fmt.Errorf("open index failed: %w", err)
+	}
+	defer file.Close()
+
+	var packs []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			packs = append(packs, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read index %s failed: %w", key, err)
+	}
+
+	return packs, nil
+}
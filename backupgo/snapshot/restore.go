@@ -0,0 +1,153 @@
+package snapshot
+
+import (
+	"archive/zip"
+	"backupgo/config"
+	"backupgo/encrypt"
+	"backupgo/oss"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Restore 按清单把快照还原到 targetDir，每个数据包只下载一次。
+func Restore(ctx context.Context, storage oss.Storage, manifest *Manifest, targetDir string, encryption *config.EncryptionConfig, logger *slog.Logger) error {
+	targetDir = filepath.Clean(targetDir)
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return fmt.Errorf("create target directory failed: %w", err)
+	}
+
+	for _, dir := range manifest.Dirs {
+		dirPath, err := targetPath(targetDir, dir)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(dirPath, 0755); err != nil {
+			return fmt.Errorf("create directory failed: %w", err)
+		}
+	}
+
+	filesByPack := make(map[string][]FileEntry)
+	for _, file := range manifest.Files {
+		packKey, ok := manifest.Blobs[file.Hash]
+		if !ok || packKey == "" {
+			return fmt.Errorf("manifest has no pack for %s", file.Path)
+		}
+		filesByPack[packKey] = append(filesByPack[packKey], file)
+	}
+
+	packKeys := make([]string, 0, len(filesByPack))
+	for packKey := range filesByPack {
+		packKeys = append(packKeys, packKey)
+	}
+	sort.Strings(packKeys)
+
+	tempDir, err := os.MkdirTemp("", "backupgo-snapshot-")
+	if err != nil {
+		return fmt.Errorf("create temp dir failed: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	for _, packKey := range packKeys {
+		logger.Info("snapshot pack restore started", "key", packKey, "files", len(filesByPack[packKey]))
+		if err := restorePack(ctx, storage, packKey, filesByPack[packKey], targetDir, tempDir, encryption); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func restorePack(ctx context.Context, storage oss.Storage, packKey string, files []FileEntry, targetDir string, tempDir string, encryption *config.EncryptionConfig) error {
+	packFile := filepath.Join(tempDir, filepath.Base(packKey))
+	if err := storage.Download(ctx, packKey, packFile); err != nil {
+		return fmt.Errorf("download pack %s failed: %w", packKey, err)
+	}
+	defer os.Remove(packFile)
+
+	if encrypt.IsEncrypted(packKey) {
+		if encryption == nil {
+			return fmt.Errorf("pack %s is encrypted but no encryption config is provided", packKey)
+		}
+
+		decryptedFile := encrypt.TrimExtension(packFile)
+		if err := encrypt.DecryptFile(*encryption, packFile, decryptedFile); err != nil {
+			return fmt.Errorf("decrypt pack %s failed: %w", packKey, err)
+		}
+		defer os.Remove(decryptedFile)
+		packFile = decryptedFile
+	}
+
+	reader, err := zip.OpenReader(packFile)
+	if err != nil {
+		return fmt.Errorf("open pack %s failed: %w", packKey, err)
+	}
+	defer reader.Close()
+
+	blobs := make(map[string]*zip.File, len(reader.File))
+	for _, entry := range reader.File {
+		blobs[entry.Name] = entry
+	}
+
+	for _, file := range files {
+		blob, ok := blobs[file.Hash]
+		if !ok {
+			return fmt.Errorf("pack %s has no blob %s", packKey, file.Hash)
+		}
+
+		filePath, err := targetPath(targetDir, file.Path)
+		if err != nil {
+			return err
+		}
+		if err := restoreFile(blob, filePath, file); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func restoreFile(blob *zip.File, filePath string, file FileEntry) error {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("create directory failed: %w", err)
+	}
+
+	src, err := blob.Open()
+	if err != nil {
+		return fmt.Errorf("open blob failed: %w", err)
+	}
+	defer src.Close()
+
+	mode := file.Mode.Perm()
+	if mode == 0 {
+		mode = 0644
+	}
+
+	dst, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return fmt.Errorf("create file failed: %w", err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		_ = dst.Close()
+		return fmt.Errorf("write file failed: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		return fmt.Errorf("close file failed: %w", err)
+	}
+
+	return os.Chtimes(filePath, file.ModTime, file.ModTime)
+}
+
+// targetPath 把清单中的路径映射到 targetDir 下，拒绝跳出 targetDir 的路径。
+func targetPath(targetDir string, entryPath string) (string, error) {
+	target := filepath.Join(targetDir, filepath.FromSlash(entryPath))
+	if target != targetDir && !strings.HasPrefix(target, targetDir+string(filepath.Separator)) {
+		return "", fmt.Errorf("illegal file path in manifest: %s", entryPath)
+	}
+	return target, nil
+}
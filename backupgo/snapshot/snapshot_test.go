@@ -0,0 +1,341 @@
+package snapshot
+
+import (
+	"backupgo/config"
+	"backupgo/encrypt"
+	"backupgo/oss"
+	"backupgo/pkg/consts"
+	"backupgo/utils"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestStorage(t *testing.T) *oss.LocalStorage {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	storage, err := oss.NewLocalStorage(config.LocalStorageConfig{Path: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewLocalStorage returned error: %v", err)
+	}
+	return storage
+}
+
+func writeTestFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("create dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+}
+
+func restoreLatest(t *testing.T, storage oss.Storage, key string) string {
+	t.Helper()
+
+	manifestFile := filepath.Join(t.TempDir(), "manifest.json")
+	if err := storage.Download(context.Background(), key, manifestFile); err != nil {
+		t.Fatalf("download manifest: %v", err)
+	}
+	manifest, err := ReadManifest(manifestFile)
+	if err != nil {
+		t.Fatalf("ReadManifest returned error: %v", err)
+	}
+
+	targetDir := t.TempDir()
+	if err := Restore(context.Background(), storage, manifest, targetDir, nil, slog.Default()); err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+	return targetDir
+}
+
+func TestCreateAndRestoreSnapshot(t *testing.T) {
+	storage := newTestStorage(t)
+	source := filepath.Join(t.TempDir(), "photos")
+	writeTestFile(t, filepath.Join(source, "a.jpg"), "photo a")
+	writeTestFile(t, filepath.Join(source, "nested", "b.jpg"), "photo b")
+	writeTestFile(t, filepath.Join(source, "nested", "copy.jpg"), "photo b")
+	if err := os.MkdirAll(filepath.Join(source, "empty"), 0755); err != nil {
+		t.Fatalf("create empty dir: %v", err)
+	}
+
+	result, err := Create(context.Background(), Options{TaskID: "photos", Source: source, Storage: storage, PackSize: 1 << 20, Logger: slog.Default()})
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	if result.Files != 3 || result.NewBlobs != 2 || result.Packs != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if !IsManifestKey(result.ManifestKey) {
+		t.Fatalf("unexpected manifest key: %s", result.ManifestKey)
+	}
+
+	targetDir := restoreLatest(t, storage, result.ManifestKey)
+	for path, want := range map[string]string{
+		"photos/a.jpg":           "photo a",
+		"photos/nested/b.jpg":    "photo b",
+		"photos/nested/copy.jpg": "photo b",
+	} {
+		content, err := os.ReadFile(filepath.Join(targetDir, filepath.FromSlash(path)))
+		if err != nil {
+			t.Fatalf("read restored %s: %v", path, err)
+		}
+		if string(content) != want {
+			t.Fatalf("unexpected content for %s: %q", path, content)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(targetDir, "photos", "empty")); err != nil || !info.IsDir() {
+		t.Fatalf("expected empty directory to be restored, err: %v", err)
+	}
+}
+
+func TestCreateUploadsOnlyChangedFiles(t *testing.T) {
+	storage := newTestStorage(t)
+	source := filepath.Join(t.TempDir(), "photos")
+	writeTestFile(t, filepath.Join(source, "a.jpg"), "photo a")
+	writeTestFile(t, filepath.Join(source, "b.jpg"), "photo b")
+
+	opts := Options{TaskID: "photos", Source: source, Storage: storage, PackSize: 1 << 20, Logger: slog.Default()}
+	if _, err := Create(context.Background(), opts); err != nil {
+		t.Fatalf("first Create returned error: %v", err)
+	}
+
+	writeTestFile(t, filepath.Join(source, "b.jpg"), "photo b edited")
+	writeTestFile(t, filepath.Join(source, "c.jpg"), "photo a")
+
+	result, err := Create(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("second Create returned error: %v", err)
+	}
+	if result.Files != 3 || result.NewBlobs != 1 || result.NewBytes != int64(len("photo b edited")) {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	targetDir := restoreLatest(t, storage, result.ManifestKey)
+	content, err := os.ReadFile(filepath.Join(targetDir, "photos", "b.jpg"))
+	if err != nil || string(content) != "photo b edited" {
+		t.Fatalf("unexpected restored content %q, err: %v", content, err)
+	}
+}
+
+func TestCreateReusesRemoteBlobsWithoutCache(t *testing.T) {
+	storage := newTestStorage(t)
+	source := filepath.Join(t.TempDir(), "photos")
+	writeTestFile(t, filepath.Join(source, "a.jpg"), "photo a")
+	writeTestFile(t, filepath.Join(source, "b.jpg"), "photo b")
+
+	opts := Options{TaskID: "photos", Source: source, Storage: storage, PackSize: 1 << 20, Logger: slog.Default()}
+	if _, err := Create(context.Background(), opts); err != nil {
+		t.Fatalf("first Create returned error: %v", err)
+	}
+
+	// 缓存丢失，例如换了一台主机：从存储中最新的快照清单得知哪些内容已经上传
+	cacheFile, err := consts.SnapshotCacheFilePath("photos")
+	if err != nil {
+		t.Fatalf("SnapshotCacheFilePath returned error: %v", err)
+	}
+	if err := os.Remove(cacheFile); err != nil {
+		t.Fatalf("remove snapshot cache: %v", err)
+	}
+	writeTestFile(t, filepath.Join(source, "c.jpg"), "photo c")
+
+	result, err := Create(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("second Create returned error: %v", err)
+	}
+	if result.Files != 3 || result.NewBlobs != 1 || result.NewBytes != int64(len("photo c")) {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	// 缓存早于存储中最新的快照时同样以存储中的清单为准
+	cached, err := loadCachedManifest("photos")
+	if err != nil || cached == nil {
+		t.Fatalf("loadCachedManifest returned %v, %v", cached, err)
+	}
+	cached.CreatedAt = cached.CreatedAt.AddDate(0, 0, -1)
+	cached.Blobs = nil
+	if err := saveCachedManifest(cached); err != nil {
+		t.Fatalf("saveCachedManifest returned error: %v", err)
+	}
+
+	result, err = Create(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("third Create returned error: %v", err)
+	}
+	if result.NewBlobs != 0 || result.Packs != 0 {
+		t.Fatalf("expected no new content, got %+v", result)
+	}
+}
+
+func TestCreateSkipsFilteredFiles(t *testing.T) {
+	storage := newTestStorage(t)
+	source := filepath.Join(t.TempDir(), "photos")
+	writeTestFile(t, filepath.Join(source, "a.jpg"), "photo a")
+	writeTestFile(t, filepath.Join(source, ".thumbs", "a.jpg"), "thumb")
+	writeTestFile(t, filepath.Join(source, "upload.tmp"), "partial")
+	writeTestFile(t, filepath.Join(source, utils.BackupIgnoreFile), "*.tmp\n")
+
+	filter, err := utils.NewPathFilter(nil, []string{".thumbs"})
+	if err != nil {
+		t.Fatalf("NewPathFilter returned error: %v", err)
+	}
+
+	result, err := Create(context.Background(), Options{TaskID: "photos", Source: source, Storage: storage, PackSize: 1 << 20, Filter: filter, Logger: slog.Default()})
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	if result.Files != 2 || result.Excluded.Files != 2 || result.Excluded.Bytes != int64(len("thumb")+len("partial")) {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	targetDir := restoreLatest(t, storage, result.ManifestKey)
+	if _, err := os.Stat(filepath.Join(targetDir, "photos", ".thumbs")); !os.IsNotExist(err) {
+		t.Fatalf("excluded directory was restored, stat error: %v", err)
+	}
+}
+
+func TestCreateAndRestoreEncryptedSnapshot(t *testing.T) {
+	storage := newTestStorage(t)
+	source := filepath.Join(t.TempDir(), "docs")
+	writeTestFile(t, filepath.Join(source, "secret.txt"), "top secret")
+
+	encryption := &config.EncryptionConfig{Type: config.EncryptionTypeAESGCM, Passphrase: "passphrase"}
+	result, err := Create(context.Background(), Options{TaskID: "docs", Source: source, Storage: storage, Encryption: encryption, PackSize: 1 << 20, Logger: slog.Default()})
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	if !strings.HasSuffix(result.ManifestKey, ".snapshot.json.enc") {
+		t.Fatalf("unexpected manifest key: %s", result.ManifestKey)
+	}
+
+	objects, err := storage.ListObjects(context.Background())
+	if err != nil {
+		t.Fatalf("ListObjects returned error: %v", err)
+	}
+	for _, obj := range objects {
+		content, err := os.ReadFile(filepath.Join(storage.BucketName(), filepath.FromSlash(obj.Key)))
+		if err != nil {
+			t.Fatalf("read object: %v", err)
+		}
+		if strings.Contains(string(content), "secret.txt") {
+			t.Fatalf("object %s leaks file names", obj.Key)
+		}
+	}
+
+	tempDir := t.TempDir()
+	encryptedFile := filepath.Join(tempDir, filepath.Base(result.ManifestKey))
+	if err := storage.Download(context.Background(), result.ManifestKey, encryptedFile); err != nil {
+		t.Fatalf("download manifest: %v", err)
+	}
+	manifestFile := filepath.Join(tempDir, "manifest.json")
+	if err := encrypt.DecryptFile(*encryption, encryptedFile, manifestFile); err != nil {
+		t.Fatalf("decrypt manifest: %v", err)
+	}
+	manifest, err := ReadManifest(manifestFile)
+	if err != nil {
+		t.Fatalf("ReadManifest returned error: %v", err)
+	}
+
+	targetDir := t.TempDir()
+	if err := Restore(context.Background(), storage, manifest, targetDir, encryption, slog.Default()); err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(targetDir, "docs", "secret.txt"))
+	if err != nil || string(content) != "top secret" {
+		t.Fatalf("unexpected restored content %q, err: %v", content, err)
+	}
+}
+
+func TestPruneRemovesUnreferencedPacks(t *testing.T) {
+	storage := newTestStorage(t)
+	source := filepath.Join(t.TempDir(), "photos")
+	writeTestFile(t, filepath.Join(source, "a.jpg"), "photo a")
+
+	opts := Options{TaskID: "photos", Source: source, Storage: storage, PackSize: 1 << 20, Logger: slog.Default()}
+	first, err := Create(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+
+	// 模拟保留规则删除了旧清单，旧数据包只被旧清单引用
+	writeTestFile(t, filepath.Join(source, "a.jpg"), "photo a edited")
+	if _, err := storage.DeleteObjects(context.Background(), []string{first.ManifestKey}); err != nil {
+		t.Fatalf("delete manifest: %v", err)
+	}
+	second, err := Create(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+
+	deleted, err := Prune(context.Background(), "photos", storage)
+	if err != nil {
+		t.Fatalf("Prune returned error: %v", err)
+	}
+	if len(deleted) != 1 || !strings.HasPrefix(deleted[0], "photos.data/packs/") {
+		t.Fatalf("unexpected deleted objects: %v", deleted)
+	}
+
+	targetDir := restoreLatest(t, storage, second.ManifestKey)
+	content, err := os.ReadFile(filepath.Join(targetDir, "photos", "a.jpg"))
+	if err != nil || string(content) != "photo a edited" {
+		t.Fatalf("unexpected restored content %q, err: %v", content, err)
+	}
+}
+
+func TestPreviewPruneDoesNotDelete(t *testing.T) {
+	storage := newTestStorage(t)
+	source := filepath.Join(t.TempDir(), "photos")
+	writeTestFile(t, filepath.Join(source, "a.jpg"), "photo a")
+
+	opts := Options{TaskID: "photos", Source: source, Storage: storage, PackSize: 1 << 20, Logger: slog.Default()}
+	first, err := Create(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+
+	// 保留规则会删除这个清单，它的索引和数据包随之不再被引用
+	pruned, err := PreviewPrune(context.Background(), "photos", storage, []string{first.ManifestKey})
+	if err != nil {
+		t.Fatalf("PreviewPrune returned error: %v", err)
+	}
+	var packs, indexes int
+	for _, key := range pruned {
+		switch {
+		case strings.HasPrefix(key, "photos.data/packs/"):
+			packs++
+		case strings.HasPrefix(key, "photos.data/index/"):
+			indexes++
+		}
+	}
+	if packs != 1 || indexes != 1 || len(pruned) != 2 {
+		t.Fatalf("unexpected pruned objects: %v", pruned)
+	}
+
+	for _, key := range append(pruned, first.ManifestKey) {
+		if _, err := os.Stat(filepath.Join(storage.BucketName(), key)); err != nil {
+			t.Fatalf("PreviewPrune deleted %s: %v", key, err)
+		}
+	}
+}
+
+func TestGroupPacks(t *testing.T) {
+	groups := groupPacks([]pendingBlob{
+		{hash: "a", size: 4},
+		{hash: "b", size: 4},
+		{hash: "c", size: 10},
+		{hash: "d", size: 1},
+	}, 8)
+
+	var got []int
+	for _, group := range groups {
+		got = append(got, len(group))
+	}
+	if len(got) != 3 || got[0] != 2 || got[1] != 1 || got[2] != 1 {
+		t.Fatalf("unexpected groups: %v", got)
+	}
+}
@@ -8,9 +8,34 @@ import (
 	"time"
 )
 
+const (
+	StatusSuccess = "success"
+	StatusFailed  = "failed"
+	// StatusInterrupted 表示任务在停止服务时被取消，计入失败次数
+	StatusInterrupted = "interrupted"
+	// StatusSkipped 表示依赖任务没有成功，本次没有执行，计入失败次数
+	StatusSkipped = "skipped"
+)
+
 type TaskState struct {
-	LastRun    time.Time `json:"last_run"`
-	LastStatus string    `json:"last_status"`
+	LastRun     time.Time `json:"last_run"`
+	LastStatus  string    `json:"last_status"`
+	LastSuccess time.Time `json:"last_success"`
+	// LastDuration 最近一次运行的耗时（秒）
+	LastDuration float64 `json:"last_duration"`
+	// LastSize 最近一次成功上传的字节数
+	LastSize int64 `json:"last_size"`
+	// Runs、Failures、UploadedBytes 是累计值，用于 Prometheus counter
+	Runs          int64 `json:"runs"`
+	Failures      int64 `json:"failures"`
+	UploadedBytes int64 `json:"uploaded_bytes"`
+}
+
+// TaskRun 描述一次任务运行的结果。
+type TaskRun struct {
+	Status   string
+	Duration time.Duration
+	Size     int64
 }
 
 type State struct {
@@ -45,7 +70,7 @@ func (s *State) load() {
 	}
 
 	var tasks map[string]*TaskState
-	if err := json.Unmarshal(data, &tasks); err != nil {
+	if err := json.Unmarshal(data, &tasks); err != nil || tasks == nil {
 		return
 	}
 
@@ -84,14 +109,37 @@ func (s *State) GetTaskState(taskID string) *TaskState {
 	return nil
 }
 
-func (s *State) SetTaskRun(taskID string, status string) {
+// RecordTaskRun 记录一次任务运行结果并写回状态文件。
+func (s *State) RecordTaskRun(taskID string, run TaskRun) {
 	s.mu.Lock()
 	if s.tasks[taskID] == nil {
 		s.tasks[taskID] = &TaskState{}
 	}
-	s.tasks[taskID].LastRun = time.Now()
-	s.tasks[taskID].LastStatus = status
+	taskState := s.tasks[taskID]
+	taskState.LastRun = time.Now()
+	taskState.LastStatus = run.Status
+	taskState.LastDuration = run.Duration.Seconds()
+	taskState.Runs++
+	if run.Status == StatusSuccess {
+		taskState.LastSuccess = taskState.LastRun
+		taskState.LastSize = run.Size
+		taskState.UploadedBytes += run.Size
+	} else {
+		taskState.Failures++
+	}
 	s.mu.Unlock()
 
 	s.save()
 }
+
+// Tasks 返回全部任务状态的副本，调用方可以在不加锁的情况下读取。
+func (s *State) Tasks() map[string]TaskState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tasks := make(map[string]TaskState, len(s.tasks))
+	for id, taskState := range s.tasks {
+		tasks[id] = *taskState
+	}
+	return tasks
+}
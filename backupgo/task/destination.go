@@ -0,0 +1,32 @@
+package task
+
+import (
+	"backupgo/config"
+	"backupgo/oss"
+)
+
+// Destination 是任务的一个上传目标，每个目标按各自的保留规则清理历史备份
+type Destination struct {
+	// Name 是存储名称
+	Name      string
+	Storage   oss.Storage
+	Retention config.RetentionConfig
+}
+
+// NewDestinations 按任务配置从 storages 中取出全部上传目标，第一个是主存储。
+func NewDestinations(conf config.BackupConfig, storages *oss.Registry) ([]Destination, error) {
+	configs := conf.GetDestinations()
+	destinations := make([]Destination, 0, len(configs))
+	for _, destination := range configs {
+		storage, err := storages.Get(destination.Storage)
+		if err != nil {
+			return nil, err
+		}
+		destinations = append(destinations, Destination{
+			Name:      destination.Storage,
+			Storage:   storage,
+			Retention: destination.GetRetention(),
+		})
+	}
+	return destinations, nil
+}
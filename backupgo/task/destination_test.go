@@ -0,0 +1,166 @@
+package task
+
+import (
+	"backupgo/config"
+	"backupgo/exporter"
+	"backupgo/manifest"
+	"backupgo/notice"
+	"backupgo/oss"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type failingStorage struct{}
+
+func (failingStorage) BucketName() string { return "broken" }
+
+func (failingStorage) Upload(ctx context.Context, objKey, filePath string) (oss.UploadResult, error) {
+	return oss.UploadResult{Bucket: "broken", Key: objKey}, errors.New("access denied")
+}
+
+func (failingStorage) UploadStream(ctx context.Context, objKey string, r io.Reader, opts oss.StreamOptions) (oss.UploadResult, error) {
+	return oss.UploadResult{Bucket: "broken", Key: objKey}, errors.New("access denied")
+}
+
+func (failingStorage) Download(ctx context.Context, objKey, filePath string) error {
+	return errors.New("access denied")
+}
+
+func (failingStorage) ListObjects(ctx context.Context) ([]oss.ObjectInfo, error) {
+	return nil, errors.New("access denied")
+}
+
+func (failingStorage) DeleteObjects(ctx context.Context, keys []string) ([]string, error) {
+	return nil, errors.New("access denied")
+}
+
+func TestUploadToSeveralDestinations(t *testing.T) {
+	archiveFile := filepath.Join(t.TempDir(), "app_2024_03_09.zip")
+	if err := os.WriteFile(archiveFile, []byte("backupgo"), 0644); err != nil {
+		t.Fatalf("write archive: %v", err)
+	}
+
+	nas, err := oss.NewLocalStorage(config.LocalStorageConfig{Path: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewLocalStorage returned error: %v", err)
+	}
+	for _, key := range []string{"app_2024_03_01.zip", "app_2024_03_01.zip.manifest.json"} {
+		if _, err := nas.Upload(context.Background(), key, archiveFile); err != nil {
+			t.Fatalf("Upload returned error: %v", err)
+		}
+	}
+
+	holder := &TaskHolder{
+		ID:    "app",
+		conf:  config.BackupConfig{ID: "app"},
+		names: testNames(t, config.BackupConfig{ID: "app"}),
+		destinations: []Destination{
+			{Name: "broken", Storage: failingStorage{}, Retention: config.RetentionConfig{KeepLast: 1}},
+			{Name: "nas", Storage: nas, Retention: config.RetentionConfig{KeepLast: 1}},
+		},
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		report: notice.NewTaskReport("app"),
+	}
+
+	m, err := holder.archiveManifest(archiveFile, "app_2024_03_09.zip", time.Now())
+	if err != nil {
+		t.Fatalf("archiveManifest returned error: %v", err)
+	}
+	uploads, err := holder.uploadBackup(context.Background(), archiveFile, m)
+	if err == nil || !strings.Contains(err.Error(), "upload to broken failed") {
+		t.Fatalf("expected broken destination to fail, got %v", err)
+	}
+	if len(uploads) != 1 || uploads[0].dest.Name != "nas" {
+		t.Fatalf("unexpected uploads: %+v", uploads)
+	}
+
+	if err := holder.finishBackup(context.Background(), "备份", uploads, err); err == nil {
+		t.Fatal("expected finishBackup to report the failed destination")
+	}
+	if holder.uploaded.key != "app_2024_03_09.zip" || len(holder.uploads) != 1 {
+		t.Fatalf("unexpected uploaded objects: %+v %+v", holder.uploaded, holder.uploads)
+	}
+
+	uploadedManifest, err := manifest.Read(context.Background(), nas, "app_2024_03_09.zip")
+	if err != nil {
+		t.Fatalf("Read returned error: %v", err)
+	}
+	if uploadedManifest.TaskID != "app" || uploadedManifest.Size != 8 || uploadedManifest.SHA256 != m.SHA256 {
+		t.Fatalf("unexpected manifest: %+v", uploadedManifest)
+	}
+
+	// 上传失败的存储不清理，上传成功的存储按自己的保留规则清理，过期备份的清单一起删除
+	if err := holder.cleanHistory(context.Background()); err != nil {
+		t.Fatalf("cleanHistory returned error: %v", err)
+	}
+	objects, err := nas.ListObjects(context.Background())
+	if err != nil {
+		t.Fatalf("ListObjects returned error: %v", err)
+	}
+	if len(objects) != 2 || objects[0].Key != "app_2024_03_09.zip" || objects[1].Key != "app_2024_03_09.zip.manifest.json" {
+		t.Fatalf("unexpected objects after cleanup: %+v", objects)
+	}
+
+	report := holder.report.Snapshot()
+	if len(report.Uploads) != 2 || report.Uploads[0].Status != notice.UploadStatusFailed || report.Uploads[1].Status != notice.UploadStatusSuccess {
+		t.Fatalf("unexpected upload reports: %+v", report.Uploads)
+	}
+}
+
+func TestSnapshotBackupUploadsManifest(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	source := filepath.Join(t.TempDir(), "photos")
+	if err := os.MkdirAll(source, 0755); err != nil {
+		t.Fatalf("create source dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(source, "a.jpg"), []byte("photo a"), 0644); err != nil {
+		t.Fatalf("write source file: %v", err)
+	}
+
+	storage, err := oss.NewLocalStorage(config.LocalStorageConfig{Path: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewLocalStorage returned error: %v", err)
+	}
+	conf := config.BackupConfig{ID: "photos", BackupPath: source, Incremental: &config.IncrementalConfig{Enabled: true}}
+	holder := &TaskHolder{
+		ID:           "photos",
+		conf:         conf,
+		names:        testNames(t, conf),
+		destinations: []Destination{{Name: "nas", Storage: storage}},
+		logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+		report:       notice.NewTaskReport("photos"),
+	}
+
+	uploaded, err := holder.snapshotBackup(context.Background(), &exporter.PreparedData{Path: source}, time.Now())
+	if err != nil {
+		t.Fatalf("snapshotBackup returned error: %v", err)
+	}
+
+	m, err := manifest.Read(context.Background(), storage, uploaded.key)
+	if err != nil {
+		t.Fatalf("Read returned error: %v", err)
+	}
+	if m.Format != manifest.FormatSnapshot || len(m.Files) != 1 || m.Files[0].Size != int64(len("photo a")) {
+		t.Fatalf("unexpected manifest: %+v", m)
+	}
+
+	// 清单中的大小和摘要对应快照清单对象，restore 下载后可以直接核对
+	snapshotFile := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := storage.Download(context.Background(), uploaded.key, snapshotFile); err != nil {
+		t.Fatalf("Download returned error: %v", err)
+	}
+	size, sum, err := manifest.HashFile(snapshotFile)
+	if err != nil {
+		t.Fatalf("HashFile returned error: %v", err)
+	}
+	if size != m.Size || sum != m.SHA256 {
+		t.Fatalf("manifest checksum %s (%d) does not match snapshot %s (%d)", m.SHA256, m.Size, sum, size)
+	}
+}
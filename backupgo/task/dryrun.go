@@ -0,0 +1,135 @@
+package task
+
+import (
+	"backupgo/notice"
+	"backupgo/snapshot"
+	"backupgo/utils"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+// DryRunResult 是一次演练的结果
+type DryRunResult struct {
+	// Key 是正式运行时会上传的对象，增量备份为空
+	Key string
+	// Size 是压缩（以及加密）后的大小；增量备份为需要扫描的文件总大小
+	Size int64
+	// Files 是增量备份需要扫描的文件数，其他模式为 0
+	Files        int64
+	Excluded     utils.FilterStats
+	Destinations []DryRunDestination
+
+	// BeforeCmd、AfterCmd 是正式运行时会执行的前置、后置命令，演练不执行
+	BeforeCmd string
+	AfterCmd  string
+}
+
+// DryRunDestination 是一个存储中保留策略会删除的对象
+type DryRunDestination struct {
+	Name    string
+	Bucket  string
+	Expired []string
+	// Pruned 是增量备份删除过期快照后，会清理的索引和数据包。
+	// 本次快照可能复用其中的数据包，实际删除的只会更少。
+	Pruned []string
+}
+
+// DryRun 执行导出和压缩，但压缩结果直接丢弃，不上传、不校验，也不删除历史备份，
+// 只计算保留策略会删除哪些对象。前置、后置命令只记录不执行，导出使用源目录中现有的数据。
+// 增量备份只扫描源目录，不计算哪些内容需要上传，另外列出会清理的增量数据。
+// 演练不写运行状态和运行历史，也不发送通知。
+func (c *TaskHolder) DryRun(ctx context.Context) (DryRunResult, error) {
+	const stageName = "演练"
+	c.report.Reset()
+	c.logger.Info("dry run started")
+
+	result := DryRunResult{BeforeCmd: c.conf.BeforeCmd, AfterCmd: c.conf.AfterCmd}
+	if result.BeforeCmd != "" {
+		c.logger.Info("command skipped", "stage", stageName, "command", result.BeforeCmd)
+	}
+
+	prepared, err := c.prepareBackup(ctx)
+	if err != nil {
+		return result, err
+	}
+	defer func() {
+		if cleanupErr := prepared.Cleanup(); cleanupErr != nil {
+			c.logger.Error("temporary cleanup failed", "stage", stageName, "error", cleanupErr)
+		}
+	}()
+
+	if c.conf.Incremental.IsEnabled() {
+		result.Excluded, err = utils.WalkFiltered(prepared.Path, prepared.Filter, func(path string, info fs.FileInfo, err error) error {
+			if err != nil || !info.Mode().IsRegular() {
+				return err
+			}
+			result.Files++
+			result.Size += info.Size()
+			return nil
+		})
+		if err != nil {
+			c.logStageError(stageName, "scan source failed", err)
+			return result, err
+		}
+	} else {
+		const compressStageName = "压缩文件"
+		counter := &countingWriter{w: io.Discard}
+		err := c.runStage(ctx, compressStageName, c.conf.GetTimeout().GetCompress(), func(ctx context.Context) error {
+			counter.n = 0
+			return c.writeArchive(ctx, counter, prepared, compressStageName)
+		})
+		if err != nil {
+			c.logStageError(compressStageName, "compression failed", err)
+			return result, err
+		}
+
+		report := c.report.Snapshot()
+		result.Key = c.objectKey()
+		result.Size = counter.n
+		result.Excluded = utils.FilterStats{Files: report.ExcludedFiles, Bytes: report.ExcludedBytes}
+	}
+
+	if result.AfterCmd != "" {
+		c.logger.Info("command skipped", "stage", stageName, "command", result.AfterCmd)
+	}
+
+	pending := result.Key
+	if c.conf.Incremental.IsEnabled() {
+		pending = snapshot.ManifestKey(c.ID, c.conf.Encryption)
+	}
+	for _, dest := range c.destinations {
+		expired, err := c.expiredKeys(ctx, dest, pending)
+		if err != nil {
+			c.logger.Error("list objects failed", "stage", stageName, "storage", dest.Name, "error", err)
+			return result, fmt.Errorf("list objects of %s failed: %w", dest.Name, err)
+		}
+		result.Destinations = append(result.Destinations, DryRunDestination{Name: dest.Name, Bucket: dest.Storage.BucketName(), Expired: expired})
+	}
+
+	// 增量备份只上传到主存储，正式运行时在保留规则清理之后清理不再引用的数据包
+	if c.conf.Incremental.IsEnabled() && len(result.Destinations) > 0 {
+		primary := &result.Destinations[0]
+		primary.Pruned, err = snapshot.PreviewPrune(ctx, c.ID, c.primary().Storage, primary.Expired)
+		if err != nil {
+			c.logger.Error("preview snapshot prune failed", "stage", stageName, "error", err)
+			return result, fmt.Errorf("preview snapshot prune failed: %w", err)
+		}
+	}
+
+	c.logger.Info("dry run completed", "key", result.Key, "size", notice.FormatBytes(result.Size))
+	return result, nil
+}
+
+// countingWriter 统计写入的字节数，演练时用来得到压缩后的大小
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,168 @@
+package task
+
+import (
+	"backupgo/config"
+	"backupgo/notice"
+	"backupgo/oss"
+	"backupgo/utils"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDryRunDoesNotUploadOrDelete(t *testing.T) {
+	source := t.TempDir()
+	if err := os.WriteFile(filepath.Join(source, "data.txt"), []byte("backupgo"), 0644); err != nil {
+		t.Fatalf("write source file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(source, "debug.log"), []byte("log"), 0644); err != nil {
+		t.Fatalf("write source file: %v", err)
+	}
+
+	storage, err := oss.NewLocalStorage(config.LocalStorageConfig{Path: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewLocalStorage returned error: %v", err)
+	}
+	existing := []string{"app_2024_03_01.zip", "app_2024_03_02.zip", "other_2024_03_01.zip"}
+	for _, key := range existing {
+		if _, err := storage.Upload(context.Background(), key, filepath.Join(source, "data.txt")); err != nil {
+			t.Fatalf("Upload returned error: %v", err)
+		}
+	}
+
+	conf := config.BackupConfig{
+		ID:         "app",
+		BackupPath: source,
+		Exclude:    []string{"*.log"},
+	}
+	holder := &TaskHolder{
+		ID:           "app",
+		conf:         conf,
+		names:        testNames(t, conf),
+		destinations: []Destination{{Name: "nas", Storage: storage, Retention: config.RetentionConfig{KeepLast: 2}}},
+		logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+		report:       notice.NewTaskReport("app"),
+	}
+
+	result, err := holder.DryRun(context.Background())
+	if err != nil {
+		t.Fatalf("DryRun returned error: %v", err)
+	}
+
+	if !strings.HasPrefix(result.Key, "app_") || !strings.HasSuffix(result.Key, ".zip") || result.Size == 0 {
+		t.Fatalf("unexpected archive result: %+v", result)
+	}
+	if result.Excluded.Files != 1 {
+		t.Fatalf("expected 1 excluded file, got %+v", result.Excluded)
+	}
+	// 正式运行会先上传今天的备份，keep_last: 2 只保留它和 03_02
+	if len(result.Destinations) != 1 {
+		t.Fatalf("unexpected destinations: %+v", result.Destinations)
+	}
+	if want := []string{"app_2024_03_01.zip"}; !reflect.DeepEqual(result.Destinations[0].Expired, want) {
+		t.Fatalf("unexpected expired keys: %#v", result.Destinations[0].Expired)
+	}
+
+	objects, err := storage.ListObjects(context.Background())
+	if err != nil {
+		t.Fatalf("ListObjects returned error: %v", err)
+	}
+	if len(objects) != len(existing) {
+		t.Fatalf("dry run changed the storage: %+v", objects)
+	}
+}
+
+func testNames(t *testing.T, conf config.BackupConfig) *utils.FileNameProcessor {
+	t.Helper()
+	names, err := conf.FileNameProcessor()
+	if err != nil {
+		t.Fatalf("FileNameProcessor returned error: %v", err)
+	}
+	return names
+}
+
+func TestDryRunUsesKeyTemplate(t *testing.T) {
+	source := t.TempDir()
+	if err := os.WriteFile(filepath.Join(source, "data.txt"), []byte("backupgo"), 0644); err != nil {
+		t.Fatalf("write source file: %v", err)
+	}
+
+	storage, err := oss.NewLocalStorage(config.LocalStorageConfig{Path: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewLocalStorage returned error: %v", err)
+	}
+	// 同一天的两次备份不会互相覆盖；根目录下按默认模板上传的备份不属于这个模板，不会被清理
+	existing := []string{"app/2024/app_2024_03_01_020000.zip", "app/2024/app_2024_03_01_140000.zip", "app_2024_03_01.zip"}
+	for _, key := range existing {
+		if _, err := storage.Upload(context.Background(), key, filepath.Join(source, "data.txt")); err != nil {
+			t.Fatalf("Upload returned error: %v", err)
+		}
+	}
+
+	conf := config.BackupConfig{ID: "app", BackupPath: source, KeyTemplate: "{id}/{yyyy}/{id}_{timestamp}.{ext}"}
+	holder := &TaskHolder{
+		ID:           "app",
+		conf:         conf,
+		names:        testNames(t, conf),
+		destinations: []Destination{{Name: "nas", Storage: storage, Retention: config.RetentionConfig{KeepLast: 2}}},
+		logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+		report:       notice.NewTaskReport("app"),
+	}
+
+	result, err := holder.DryRun(context.Background())
+	if err != nil {
+		t.Fatalf("DryRun returned error: %v", err)
+	}
+
+	if _, err := holder.names.Parse(result.Key); err != nil || !strings.HasPrefix(result.Key, "app/") {
+		t.Fatalf("unexpected key %q: %v", result.Key, err)
+	}
+	if want := []string{"app/2024/app_2024_03_01_020000.zip"}; !reflect.DeepEqual(result.Destinations[0].Expired, want) {
+		t.Fatalf("unexpected expired keys: %#v", result.Destinations[0].Expired)
+	}
+}
+
+func TestDryRunSkipsCommands(t *testing.T) {
+	source := t.TempDir()
+	if err := os.WriteFile(filepath.Join(source, "data.txt"), []byte("backupgo"), 0644); err != nil {
+		t.Fatalf("write source file: %v", err)
+	}
+	storage, err := oss.NewLocalStorage(config.LocalStorageConfig{Path: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewLocalStorage returned error: %v", err)
+	}
+
+	marker := filepath.Join(t.TempDir(), "marker")
+	conf := config.BackupConfig{
+		ID:         "app",
+		BackupPath: source,
+		BeforeCmd:  "touch " + marker + ".before",
+		AfterCmd:   "touch " + marker + ".after",
+	}
+	holder := &TaskHolder{
+		ID:           "app",
+		conf:         conf,
+		names:        testNames(t, conf),
+		destinations: []Destination{{Name: "nas", Storage: storage}},
+		logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+		report:       notice.NewTaskReport("app"),
+	}
+
+	result, err := holder.DryRun(context.Background())
+	if err != nil {
+		t.Fatalf("DryRun returned error: %v", err)
+	}
+	if result.BeforeCmd != conf.BeforeCmd || result.AfterCmd != conf.AfterCmd {
+		t.Fatalf("unexpected commands: %+v", result)
+	}
+	for _, suffix := range []string{".before", ".after"} {
+		if _, err := os.Stat(marker + suffix); !os.IsNotExist(err) {
+			t.Fatalf("dry run executed a command: %v", err)
+		}
+	}
+}
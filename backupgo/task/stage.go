@@ -0,0 +1,51 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// runStage 按任务的 timeout 和 retry 配置执行一个阶段。每次尝试有独立的超时时间，
+// 失败后等待 backoff 再重试，等待时间每次翻倍；任务被取消时不再重试。
+// 每次尝试的结果都会记录到任务报告中。
+func (c *TaskHolder) runStage(ctx context.Context, stageName string, timeout time.Duration, fn func(ctx context.Context) error) error {
+	retry := c.conf.GetRetry()
+	attempts := retry.GetAttempts()
+	backoff := retry.GetBackoff()
+
+	for attempt := 1; ; attempt++ {
+		startedAt := time.Now()
+		err := runWithTimeout(ctx, timeout, fn)
+		c.report.AddAttempt(stageName, attempt, time.Since(startedAt), err)
+		if err == nil || attempt >= attempts || ctx.Err() != nil {
+			return err
+		}
+
+		c.logger.Warn("stage attempt failed, retrying", "stage", stageName, "attempt", attempt, "attempts", attempts, "backoff", backoff, "error", err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+func runWithTimeout(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) error) error {
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := fn(attemptCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s: %w", timeout, err)
+	}
+	return err
+}
@@ -0,0 +1,76 @@
+package task
+
+import (
+	"backupgo/config"
+	"backupgo/notice"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestHolder(retry *config.RetryConfig) *TaskHolder {
+	return &TaskHolder{
+		ID:     "app",
+		conf:   config.BackupConfig{ID: "app", Retry: retry},
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		report: notice.NewTaskReport("app"),
+	}
+}
+
+func TestRunStageRetriesUntilSuccess(t *testing.T) {
+	holder := newTestHolder(&config.RetryConfig{Attempts: 3, Backoff: "1ms"})
+
+	calls := 0
+	err := holder.runStage(context.Background(), "上传到OSS", 0, func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("connection reset")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("runStage returned error: %v", err)
+	}
+
+	attempts := holder.report.Snapshot().Attempts
+	if calls != 3 || len(attempts) != 3 {
+		t.Fatalf("expected 3 attempts, got calls %d, attempts %+v", calls, attempts)
+	}
+	if attempts[0].Error != "connection reset" || attempts[2].Error != "" || attempts[2].Attempt != 3 {
+		t.Fatalf("unexpected attempts: %+v", attempts)
+	}
+}
+
+func TestRunStageTimesOutEachAttempt(t *testing.T) {
+	holder := newTestHolder(&config.RetryConfig{Attempts: 2, Backoff: "1ms"})
+
+	err := holder.runStage(context.Background(), "导出数据", 10*time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err == nil || !strings.Contains(err.Error(), "timed out after 10ms") {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	if attempts := holder.report.Snapshot().Attempts; len(attempts) != 2 {
+		t.Fatalf("expected 2 attempts, got %+v", attempts)
+	}
+}
+
+func TestRunStageDoesNotRetryCanceledTask(t *testing.T) {
+	holder := newTestHolder(&config.RetryConfig{Attempts: 3, Backoff: "1ms"})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := 0
+	err := holder.runStage(ctx, "压缩文件", 0, func(ctx context.Context) error {
+		calls++
+		return ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Fatalf("expected a single canceled attempt, got calls %d, err %v", calls, err)
+	}
+}
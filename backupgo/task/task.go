@@ -2,103 +2,293 @@ package task
 
 import (
 	"backupgo/config"
+	"backupgo/encrypt"
 	"backupgo/exporter"
+	"backupgo/history"
+	"backupgo/manifest"
 	"backupgo/notice"
 	"backupgo/oss"
+	"backupgo/pkg/consts"
+	"backupgo/retention"
+	"backupgo/snapshot"
 	"backupgo/state"
 	"backupgo/utils"
+	"backupgo/verify"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
 	"log/slog"
 	"os"
 	"os/exec"
-	"path/filepath"
+	"slices"
+	"time"
 )
 
+// uploadedObject 记录本次上传的对象，供校验阶段和运行状态使用
+type uploadedObject struct {
+	bucket string
+	key    string
+	// mode 是上传使用的存储模式（NORMAL/FAST），增量备份为空
+	mode string
+	// size 是上传的字节数，增量备份为新增内容的大小
+	size int64
+	dest Destination
+}
+
 type TaskHolder struct {
 	ID            string
 	conf          config.BackupConfig
-	ossClient     *oss.OssClient
+	destinations  []Destination
+	names         *utils.FileNameProcessor
 	noticeManager *notice.NoticeManager
 	logger        *slog.Logger
 	report        *notice.TaskReport
+	// historyKeep 是运行历史保留的条数，创建任务时从当前配置取值，运行中不读取全局配置
+	historyKeep int
+	// uploaded 是第一个上传成功的对象，用于运行状态和运行历史
+	uploaded uploadedObject
+	// uploads 是上传并校验成功的对象，只清理这些存储中的历史备份
+	uploads []uploadedObject
+	// entries 是最近一次压缩写入备份的文件，用于生成清单
+	entries []manifest.File
 }
 
-func NewTaskHolder(conf config.BackupConfig, ossClient *oss.OssClient, noticeManager *notice.NoticeManager) *TaskHolder {
+func NewTaskHolder(conf config.BackupConfig, destinations []Destination, noticeManager *notice.NoticeManager, historyKeep int) *TaskHolder {
 	if err := conf.Validate(); err != nil {
 		panic(err)
 	}
+	names, err := conf.FileNameProcessor()
+	if err != nil {
+		panic(err)
+	}
 
 	holder := &TaskHolder{
 		ID:            conf.GetID(),
 		conf:          conf,
-		ossClient:     ossClient,
+		destinations:  destinations,
+		names:         names,
 		noticeManager: noticeManager,
+		historyKeep:   historyKeep,
 		logger:        slog.Default().With("component", "backup_task", "task_id", conf.GetID()),
 		report:        notice.NewTaskReport(conf.GetID()),
 	}
 	return holder
 }
 
-func (c *TaskHolder) BackupTask() {
+// BackupTask 执行一次备份。ctx 被取消时各阶段尽快中止、清理临时文件，任务记为 interrupted。
+func (c *TaskHolder) BackupTask(ctx context.Context) {
 	c.report.Reset()
+	c.uploaded = uploadedObject{}
+	c.uploads = nil
+	startedAt := time.Now()
 	c.logger.Info("backup task started")
 
-	if err := c.backup(); err != nil {
-		state.GetState().SetTaskRun(c.ID, "failed")
-		c.report.Finish()
-		c.sendMessages()
+	err := c.backup(ctx, startedAt)
+	if ctx.Err() == nil {
+		// 部分存储上传失败时，已经上传成功的存储照常清理历史备份
+		err = errors.Join(err, c.cleanHistory(ctx))
+	}
+	if err == nil && c.conf.Incremental.IsEnabled() {
+		err = c.pruneSnapshots(ctx)
+	}
+	if err != nil {
+		c.finishTask(ctx, startedAt, state.StatusFailed, err)
 		return
 	}
 
-	state.GetState().SetTaskRun(c.ID, "success")
+	c.finishTask(ctx, startedAt, state.StatusSuccess, nil)
+}
 
-	if err := c.cleanHistory(); err != nil {
-		state.GetState().SetTaskRun(c.ID, "failed")
-	}
+// SkipTask 记录一次因依赖任务没有成功而没有执行的运行，同样写入运行历史并发送通知。
+func (c *TaskHolder) SkipTask(reason string) {
+	c.report.Reset()
+	c.uploaded = uploadedObject{}
+	c.uploads = nil
+	startedAt := time.Now()
+	c.logger.Warn("backup task skipped", "reason", reason)
+
+	c.report.MarkError(reason)
+	c.finishTask(context.Background(), startedAt, state.StatusSkipped, nil)
+}
 
+// finishTask 记录运行结果供 status、history 命令和 HTTP 接口使用，并发送通知。
+func (c *TaskHolder) finishTask(ctx context.Context, startedAt time.Time, status string, err error) {
+	if err != nil && ctx.Err() != nil {
+		status = state.StatusInterrupted
+		c.report.MarkInterrupted()
+	}
 	c.report.Finish()
-	c.logger.Info("backup task completed", "status", taskStatus(c.report.HasErrors))
+	state.GetState().RecordTaskRun(c.ID, state.TaskRun{
+		Status:   status,
+		Duration: c.report.Duration,
+		Size:     c.uploaded.size,
+	})
+	c.appendHistory(startedAt, status, err)
+	c.logger.Info("backup task completed", "status", status)
 	c.sendMessages()
 }
 
-func (c *TaskHolder) cleanHistory() error {
+// appendHistory 把本次运行追加到历史记录，写入失败只记录日志，不影响任务结果。
+func (c *TaskHolder) appendHistory(startedAt time.Time, status string, err error) {
+	errs := append([]string(nil), c.report.Errors...)
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	record := history.Record{
+		TaskID:     c.ID,
+		StartedAt:  startedAt,
+		FinishedAt: startedAt.Add(c.report.Duration),
+		Duration:   c.report.Duration.Seconds(),
+		Status:     status,
+		Size:       c.uploaded.size,
+		Bucket:     c.uploaded.bucket,
+		Key:        c.uploaded.key,
+		Mode:       c.uploaded.mode,
+		Errors:     errs,
+	}
+	path, pathErr := consts.HistoryFilePath()
+	if pathErr != nil {
+		c.logger.Error("resolve history file failed", "error", pathErr)
+		return
+	}
+	if appendErr := history.Append(path, record, c.historyKeep); appendErr != nil {
+		c.logger.Error("append run history failed", "error", appendErr)
+	}
+}
+
+// cleanHistory 按各存储的保留规则清理历史备份，只清理本次上传并校验成功的存储。
+func (c *TaskHolder) cleanHistory(ctx context.Context) error {
 	const stageName = "清理历史文件"
+	if len(c.uploads) == 0 {
+		return nil
+	}
 	c.logStageStart(stageName)
 
-	deleted, err := c.ossClient.DeleteObjectsByPredicate(func(key string) bool {
-		return utils.IsNeedDeleteFile(c.ID, key)
-	})
+	var errs []error
+	for _, uploaded := range c.uploads {
+		if err := c.cleanDestination(ctx, stageName, uploaded.dest); err != nil {
+			c.report.MarkError("清理历史文件失败")
+			errs = append(errs, fmt.Errorf("clean history of %s failed: %w", uploaded.dest.Name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	c.logStageFinish(stageName)
+	return nil
+}
+
+func (c *TaskHolder) cleanDestination(ctx context.Context, stageName string, dest Destination) error {
+	expired, err := c.expiredKeys(ctx, dest, "")
 	if err != nil {
-		c.logger.Error("clean history failed", "stage", stageName, "error", err)
-		c.report.MarkError("清理历史文件失败")
+		c.logger.Error("list objects failed", "stage", stageName, "storage", dest.Name, "error", err)
+		return err
+	}
+	if len(expired) == 0 {
+		c.logger.Info("no historical objects need deletion", "stage", stageName, "storage", dest.Name)
+		return nil
+	}
+
+	deleted, err := dest.Storage.DeleteObjects(ctx, expired)
+	if err != nil {
+		c.logger.Error("clean history failed", "stage", stageName, "storage", dest.Name, "error", err)
 		return err
 	}
 
 	if len(deleted) == 0 {
-		c.logger.Info("no historical objects need deletion", "stage", stageName)
-		c.logStageFinish(stageName)
+		c.logger.Info("no historical objects need deletion", "stage", stageName, "storage", dest.Name)
 		return nil
 	}
 
-	c.logger.Info("historical objects deleted", "stage", stageName, "deleted_count", len(deleted), "deleted_keys", deleted)
+	c.logger.Info("historical objects deleted", "stage", stageName, "storage", dest.Name, "deleted_count", len(deleted), "deleted_keys", deleted)
+	return nil
+}
+
+// expiredKeys 返回 dest 的保留规则要删除的对象，包括过期备份的清单。pending 是还没有上传的对象，
+// 演练时把它当作已存在，让 keep_last 等规则的结果与正式运行一致。
+func (c *TaskHolder) expiredKeys(ctx context.Context, dest Destination, pending string) ([]string, error) {
+	objects, err := dest.Storage.ListObjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(objects)+1)
+	manifests := make(map[string]bool)
+	for _, obj := range objects {
+		// 清单不参与保留计算，跟随对应的备份一起删除
+		if manifest.IsKey(obj.Key) {
+			manifests[obj.Key] = true
+			continue
+		}
+		keys = append(keys, obj.Key)
+	}
+	if pending != "" && !slices.Contains(keys, pending) {
+		keys = append(keys, pending)
+	}
+
+	expired := retention.ExpiredKeys(c.names, keys, retentionPolicy(dest.Retention), time.Now())
+	for _, key := range expired {
+		if manifests[manifest.Key(key)] {
+			expired = append(expired, manifest.Key(key))
+		}
+	}
+	return expired, nil
+}
+
+// pruneSnapshots 在清理过期快照清单后，删除不再被任何快照引用的增量数据包。
+func (c *TaskHolder) pruneSnapshots(ctx context.Context) error {
+	const stageName = "清理增量数据"
+	c.logStageStart(stageName)
+
+	deleted, err := snapshot.Prune(ctx, c.ID, c.primary().Storage)
+	if err != nil {
+		c.logger.Error("prune snapshot data failed", "stage", stageName, "error", err)
+		c.report.MarkError("清理增量数据失败")
+		return err
+	}
+
+	c.logger.Info("snapshot data pruned", "stage", stageName, "deleted_count", len(deleted))
 	c.logStageFinish(stageName)
 	return nil
 }
 
-func (c *TaskHolder) backup() error {
+// primary 返回主存储。流式和增量备份只支持一个存储，只上传到主存储。
+func (c *TaskHolder) primary() Destination {
+	return c.destinations[0]
+}
+
+func retentionPolicy(conf config.RetentionConfig) retention.Policy {
+	return retention.Policy{
+		KeepLast:    conf.KeepLast,
+		KeepDays:    conf.KeepDays,
+		KeepDaily:   conf.KeepDaily,
+		KeepWeekly:  conf.KeepWeekly,
+		KeepMonthly: conf.KeepMonthly,
+		KeepYearly:  conf.KeepYearly,
+	}
+}
+
+func (c *TaskHolder) backup(ctx context.Context, startedAt time.Time) error {
 	const stageName = "备份"
 	conf := c.conf
 
 	c.logStageStart(stageName)
 
 	if conf.BeforeCmd != "" {
-		if err := c.runCommandStep("执行前置命令", conf.BeforeCmd, "前置命令执行失败"); err != nil {
+		if err := c.runCommandStep(ctx, "执行前置命令", conf.BeforeCmd, "前置命令执行失败"); err != nil {
 			c.logStageError(stageName, "backup stage failed", err)
 			c.report.EnsureFailed("备份失败")
 			return err
 		}
 	}
 
-	prepared, err := exporter.Prepare(c.ID, conf, c.logger)
+	prepared, err := c.prepareBackup(ctx)
 	if err != nil {
 		c.logger.Error("backup data preparation failed", "stage", stageName, "error", err)
 		c.report.MarkError("备份准备失败")
@@ -117,31 +307,114 @@ func (c *TaskHolder) backup() error {
 
 	}()
 
-	c.logger.Info("backup source prepared", "path", prepared.Path)
+	c.logger.Info("backup source prepared", "path", prepared.Path, "streams", len(prepared.Streams))
+
+	if conf.Incremental.IsEnabled() {
+		uploaded, err := c.snapshotBackup(ctx, prepared, startedAt)
+		if err != nil {
+			c.logStageError(stageName, "backup stage failed", err)
+			c.report.EnsureFailed("备份失败")
+			return err
+		}
+
+		if err := c.runAfterCmd(ctx, stageName); err != nil {
+			return err
+		}
+
+		return c.finishBackup(ctx, stageName, []uploadedObject{uploaded}, nil)
+	}
 
-	zipFile, err := c.compressBackup(prepared.Path)
+	// 流式模式下数据边导出边上传，后置命令要等上传结束、数据读取完成后才能执行
+	if conf.Streaming.IsEnabled() {
+		uploaded, err := c.streamBackup(ctx, prepared, startedAt)
+		if err != nil {
+			c.logStageError(stageName, "backup stage failed", err)
+			c.report.EnsureFailed("备份失败")
+			return err
+		}
+
+		if err := c.runAfterCmd(ctx, stageName); err != nil {
+			return err
+		}
+
+		return c.finishBackup(ctx, stageName, []uploadedObject{uploaded}, nil)
+	}
+
+	// key 在压缩前生成，时间与模板中的时分秒对应备份开始压缩的时刻
+	objKey := c.objectKey()
+	compressedFile, err := c.compressBackup(ctx, prepared.ArchiveDirs())
 	if err != nil {
 		c.logStageError(stageName, "backup stage failed", err)
 		c.report.EnsureFailed("备份失败")
 		return err
 	}
-	defer func(path string) {
-		err = os.Remove(path)
-		if err != nil {
-			c.logger.Error("zip cleanup failed", "file", path, "error", err)
-			c.report.MarkError("清理zip文件失败")
-		}
-	}(zipFile)
+	defer c.removeArchive(compressedFile)
 
-	if conf.AfterCmd != "" {
-		if err := c.runCommandStep("执行后置命令", conf.AfterCmd, "后置命令执行失败"); err != nil {
+	archiveFile := compressedFile
+	if conf.Encryption != nil {
+		encryptedFile, err := c.encryptBackup(compressedFile)
+		if err != nil {
 			c.logStageError(stageName, "backup stage failed", err)
 			c.report.EnsureFailed("备份失败")
 			return err
 		}
+		defer c.removeArchive(encryptedFile)
+
+		archiveFile = encryptedFile
 	}
 
-	if err := c.uploadBackup(zipFile); err != nil {
+	if err := c.runAfterCmd(ctx, stageName); err != nil {
+		return err
+	}
+
+	m, err := c.archiveManifest(archiveFile, objKey, startedAt)
+	if err != nil {
+		c.logStageError(stageName, "create manifest failed", err)
+		c.report.MarkError("生成清单失败")
+		c.report.EnsureFailed("备份失败")
+		return err
+	}
+
+	uploads, err := c.uploadBackup(ctx, archiveFile, m)
+	return c.finishBackup(ctx, stageName, uploads, err)
+}
+
+// prepareBackup 导出备份数据。流式模式下导出命令在压缩上传时才执行，由上传阶段的超时和重试控制。
+func (c *TaskHolder) prepareBackup(ctx context.Context) (*exporter.PreparedData, error) {
+	const stageName = "导出数据"
+
+	prepare := exporter.Prepare
+	if c.conf.Streaming.IsEnabled() {
+		prepare = exporter.PrepareStream
+	}
+
+	var prepared *exporter.PreparedData
+	err := c.runStage(ctx, stageName, c.conf.GetTimeout().GetExport(), func(ctx context.Context) error {
+		var err error
+		prepared, err = prepare(ctx, c.ID, c.conf, c.logger)
+		return err
+	})
+	return prepared, err
+}
+
+// finishBackup 在上传完成后按配置校验每个上传成功的备份。uploadErr 是上传失败的存储的错误；
+// 有存储上传或校验失败时任务记为失败，这些存储也不会清理历史备份。
+func (c *TaskHolder) finishBackup(ctx context.Context, stageName string, uploads []uploadedObject, uploadErr error) error {
+	errs := []error{uploadErr}
+	for _, uploaded := range uploads {
+		if c.uploaded.key == "" {
+			c.uploaded = uploaded
+		}
+		if c.conf.Verify.IsEnabled() {
+			if err := c.verifyBackup(ctx, uploaded); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		c.uploads = append(c.uploads, uploaded)
+	}
+
+	if err := errors.Join(errs...); err != nil {
 		c.logStageError(stageName, "backup stage failed", err)
 		c.report.EnsureFailed("备份失败")
 		return err
@@ -151,12 +424,68 @@ func (c *TaskHolder) backup() error {
 	return nil
 }
 
-func (c *TaskHolder) runCommandStep(stepName string, command string, errorMessage string) error {
+// verifyBackup 重新读取刚上传的备份，确认它可以恢复。
+func (c *TaskHolder) verifyBackup(ctx context.Context, uploaded uploadedObject) error {
+	const stageName = "校验备份"
+	c.logStageStart(stageName)
+
+	opts := verify.Options{TaskID: c.ID, Conf: c.conf, Storage: uploaded.dest.Storage, Logger: c.logger}
+	var result verify.Result
+	var err error
+	if snapshot.IsManifestKey(uploaded.key) {
+		result, err = verify.Snapshot(ctx, opts, uploaded.key)
+	} else {
+		result, err = verify.Archive(ctx, opts, uploaded.key, uploaded.size)
+	}
+	if err != nil {
+		c.logger.Error("verify failed", "stage", stageName, "storage", uploaded.dest.Name, "key", uploaded.key, "mode", result.Mode, "error", err)
+		c.report.SetVerifyFailed(err.Error())
+		c.report.MarkError("校验失败")
+		return err
+	}
+
+	c.logger.Info("verify succeeded", "stage", stageName, "storage", uploaded.dest.Name, "key", uploaded.key, "mode", result.Mode, "files", result.Files, "dump_checked", result.DumpChecked)
+	// 多个存储中有一个校验失败时，通知中保留失败的结果
+	if c.report.Verify.Status != notice.VerifyStatusFailed {
+		c.report.SetVerifyPassed(verifyDetail(result))
+	}
+	c.logStageFinish(stageName)
+	return nil
+}
+
+func verifyDetail(result verify.Result) string {
+	if result.Mode == config.VerifyModeQuick {
+		return "quick，对象已上传完整"
+	}
+
+	detail := fmt.Sprintf("full，读取 %d 个文件", result.Files)
+	if result.DumpChecked {
+		detail += "，导出文件可被恢复工具读取"
+	}
+	return detail
+}
+
+func (c *TaskHolder) runAfterCmd(ctx context.Context, stageName string) error {
+	if c.conf.AfterCmd == "" {
+		return nil
+	}
+
+	if err := c.runCommandStep(ctx, "执行后置命令", c.conf.AfterCmd, "后置命令执行失败"); err != nil {
+		c.logStageError(stageName, "backup stage failed", err)
+		c.report.EnsureFailed("备份失败")
+		return err
+	}
+	return nil
+}
+
+func (c *TaskHolder) runCommandStep(ctx context.Context, stepName string, command string, errorMessage string) error {
 	c.logStageStart(stepName)
 	c.logger.Info("command executing", "stage", stepName, "command", command)
 
-	cmd := exec.Command("bash", "-c", command)
-	if err := cmd.Run(); err != nil {
+	err := c.runStage(ctx, stepName, c.conf.GetTimeout().GetCommand(), func(ctx context.Context) error {
+		return exec.CommandContext(ctx, "bash", "-c", command).Run()
+	})
+	if err != nil {
 		c.logger.Error("command execution failed", "stage", stepName, "command", command, "error", err)
 		c.report.MarkError(errorMessage)
 		return err
@@ -166,15 +495,17 @@ func (c *TaskHolder) runCommandStep(stepName string, command string, errorMessag
 	return nil
 }
 
-func (c *TaskHolder) compressBackup(path string) (string, error) {
+func (c *TaskHolder) compressBackup(ctx context.Context, dirs []utils.SourceDir) (string, error) {
 	const stageName = "压缩文件"
 	c.logStageStart(stageName)
 
-	zipFile, err := utils.ZipPath(path, utils.GetFileName(c.ID), func(filePath string, processed, total int64, percentage float64) {
-		c.logger.Info("compression progress", "stage", stageName, "file", filePath, "processed", notice.FormatBytes(processed), "total", notice.FormatBytes(total), "percentage", percentage)
-	}, func(total int64) {
-		c.report.SetCompressedSize(total)
-		c.logger.Info("compression completed", "stage", stageName, "size", notice.FormatBytes(total), "bytes", total)
+	progress, done := c.compressionProgress(stageName)
+	var compressedFile string
+	err := c.runStage(ctx, stageName, c.conf.GetTimeout().GetCompress(), func(ctx context.Context) error {
+		var err error
+		c.entries = nil
+		compressedFile, err = utils.ArchivePath(ctx, dirs, utils.GetFileName(c.ID, c.archiveOptions(stageName).Extension()), c.archiveOptions(stageName), progress, done)
+		return err
 	})
 	if err != nil {
 		c.logger.Error("compression failed", "stage", stageName, "error", err)
@@ -183,30 +514,324 @@ func (c *TaskHolder) compressBackup(path string) (string, error) {
 	}
 
 	c.logStageFinish(stageName)
-	return zipFile, nil
+	return compressedFile, nil
+}
+
+func (c *TaskHolder) compressionProgress(stageName string) (utils.ProgressCallback, utils.ProgressDoneCallback) {
+	return func(filePath string, processed, total int64, percentage float64) {
+			c.logger.Info("compression progress", "stage", stageName, "file", filePath, "processed", notice.FormatBytes(processed), "total", notice.FormatBytes(total), "percentage", percentage)
+		}, func(total int64) {
+			c.report.SetCompressedSize(total)
+			c.logger.Info("compression completed", "stage", stageName, "size", notice.FormatBytes(total), "bytes", total)
+		}
 }
 
-func (c *TaskHolder) uploadBackup(zipFile string) error {
-	const stageName = "上传到OSS"
-	objKey := filepath.Base(zipFile)
-	ossClient := c.ossClient
-	bucketName := ossClient.BucketName()
+// snapshotBackup 增量备份目录，只上传新增或变化的文件内容。
+func (c *TaskHolder) snapshotBackup(ctx context.Context, prepared *exporter.PreparedData, startedAt time.Time) (uploadedObject, error) {
+	const stageName = "增量备份"
+	dest := c.primary()
+	bucketName := dest.Storage.BucketName()
+
+	c.logStageStart(stageName)
+	var result snapshot.Result
+	err := c.runStage(ctx, stageName, c.conf.GetTimeout().GetUpload(), func(ctx context.Context) error {
+		var err error
+		result, err = snapshot.Create(ctx, snapshot.Options{
+			TaskID:     c.ID,
+			Source:     prepared.Path,
+			Storage:    dest.Storage,
+			Encryption: c.conf.Encryption,
+			PackSize:   c.conf.Incremental.GetPackSize(),
+			Filter:     prepared.Filter,
+			Logger:     c.logger,
+		})
+		return err
+	})
+	if err != nil {
+		c.logger.Error("snapshot backup failed", "stage", stageName, "bucket", bucketName, "error", err)
+		c.report.AddUploadFailure(bucketName, result.ManifestKey, err.Error())
+		c.report.MarkError("增量备份失败")
+		return uploadedObject{}, err
+	}
+
+	m := c.snapshotManifest(result, startedAt)
+	if err := manifest.Upload(ctx, dest.Storage, m); err != nil {
+		c.logger.Error("manifest upload failed", "stage", stageName, "bucket", bucketName, "key", manifest.Key(result.ManifestKey), "error", err)
+		c.report.AddUploadFailure(bucketName, manifest.Key(result.ManifestKey), err.Error())
+		c.report.MarkError("上传清单失败")
+		return uploadedObject{}, err
+	}
+
+	c.report.SetCompressedSize(result.NewBytes)
+	c.setExcluded(stageName, result.Excluded)
+	c.logger.Info("snapshot backup succeeded", "stage", stageName, "bucket", bucketName, "key", result.ManifestKey,
+		"files", result.Files, "total", notice.FormatBytes(result.TotalBytes),
+		"new_blobs", result.NewBlobs, "new_size", notice.FormatBytes(result.NewBytes), "packs", result.Packs)
+	c.report.AddUploadSuccess(bucketName, result.ManifestKey)
+	c.logStageFinish(stageName)
+	return uploadedObject{bucket: bucketName, key: result.ManifestKey, size: result.NewBytes, dest: dest}, nil
+}
+
+// errUploadAborted 表示上传端提前结束，压缩协程因此写入失败，不应再记为压缩错误
+var errUploadAborted = errors.New("upload aborted")
+
+// streamBackup 把压缩（以及加密）输出通过管道直接交给存储的分片上传，不在本地生成备份文件。
+func (c *TaskHolder) streamBackup(ctx context.Context, prepared *exporter.PreparedData, startedAt time.Time) (uploadedObject, error) {
+	const stageName = "流式压缩上传"
+	streaming := *c.conf.Streaming
+	objKey := c.objectKey()
+	dest := c.primary()
+	bucketName := dest.Storage.BucketName()
+
+	c.logStageStart(stageName)
+	c.logger.Info("stream upload started", "stage", stageName, "bucket", bucketName, "key", objKey, "part_size", notice.FormatBytes(streaming.GetPartSize()))
+
+	// 每次尝试都重新执行导出命令并从头上传，只保留最后一次尝试的结果
+	var result oss.UploadResult
+	var counter *countingReader
+	var compressErr, uploadErr error
+	_ = c.runStage(ctx, stageName, c.conf.GetTimeout().GetUpload(), func(ctx context.Context) error {
+		reader, writer := io.Pipe()
+		compressErrCh := make(chan error, 1)
+		c.entries = nil
+		go func() {
+			err := c.writeArchive(ctx, writer, prepared, stageName)
+			_ = writer.CloseWithError(err)
+			compressErrCh <- err
+		}()
+
+		counter = &countingReader{r: reader, hash: sha256.New()}
+		result, uploadErr = dest.Storage.UploadStream(ctx, objKey, counter, oss.StreamOptions{
+			PartSize:    streaming.GetPartSize(),
+			PartRetries: streaming.GetPartRetries(),
+		})
+		_ = reader.CloseWithError(errUploadAborted)
+		compressErr = <-compressErrCh
+
+		if compressErr != nil && !errors.Is(compressErr, errUploadAborted) {
+			return compressErr
+		}
+		return uploadErr
+	})
+
+	if compressErr != nil && !errors.Is(compressErr, errUploadAborted) {
+		c.logger.Error("compression failed", "stage", stageName, "error", compressErr)
+		c.report.AddUploadFailure(bucketName, objKey, compressErr.Error())
+		c.report.MarkError("压缩失败")
+		return uploadedObject{}, compressErr
+	}
+	if uploadErr != nil {
+		c.logger.Error("upload failed", "stage", stageName, "bucket", result.Bucket, "key", result.Key, "error", uploadErr)
+		c.report.AddUploadFailure(result.Bucket, result.Key, uploadErr.Error())
+		c.report.MarkError("上传失败")
+		return uploadedObject{}, uploadErr
+	}
+
+	// 清单在备份上传完成后才能生成，上传失败时备份对象已存在，但任务仍记为失败
+	m := c.newManifest(objKey, startedAt)
+	m.Size, m.SHA256 = counter.n, hex.EncodeToString(counter.hash.Sum(nil))
+	if err := manifest.Upload(ctx, dest.Storage, m); err != nil {
+		c.logger.Error("manifest upload failed", "stage", stageName, "bucket", result.Bucket, "key", manifest.Key(objKey), "error", err)
+		c.report.AddUploadFailure(result.Bucket, manifest.Key(objKey), err.Error())
+		c.report.MarkError("上传清单失败")
+		return uploadedObject{}, err
+	}
+
+	c.logger.Info("upload succeeded", "stage", stageName, "bucket", result.Bucket, "key", result.Key, "mode", result.Mode, "size", notice.FormatBytes(counter.n))
+	c.report.AddUploadSuccess(result.Bucket, result.Key)
+	c.logStageFinish(stageName)
+	return uploadedObject{bucket: result.Bucket, key: objKey, mode: string(result.Mode), size: counter.n, dest: dest}, nil
+}
+
+// countingReader 统计流式上传读取的字节数和摘要，用于校验上传后的对象大小和生成清单
+type countingReader struct {
+	r    io.Reader
+	n    int64
+	hash hash.Hash
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	if r.hash != nil {
+		r.hash.Write(p[:n])
+	}
+	return n, err
+}
+
+// newManifest 返回本次备份的清单，备份对象的大小和摘要由调用方填写。
+func (c *TaskHolder) newManifest(objKey string, startedAt time.Time) manifest.Manifest {
+	host, err := os.Hostname()
+	if err != nil {
+		c.logger.Warn("get hostname failed", "error", err)
+	}
+	m := manifest.New(c.conf, host, consts.Version)
+	m.Key = objKey
+	m.Files = c.entries
+	m.StartedAt = startedAt
+	m.FinishedAt = time.Now()
+	return m
+}
+
+// archiveManifest 计算本地备份文件的大小和摘要，生成上传到各存储的清单。
+func (c *TaskHolder) archiveManifest(archiveFile string, objKey string, startedAt time.Time) (manifest.Manifest, error) {
+	m := c.newManifest(objKey, startedAt)
+	size, sum, err := manifest.HashFile(archiveFile)
+	if err != nil {
+		return manifest.Manifest{}, err
+	}
+	m.Size, m.SHA256 = size, sum
+	return m, nil
+}
+
+// snapshotManifest 生成增量备份的清单，Size 和 SHA256 对应快照清单对象，Files 是快照中的全部文件。
+func (c *TaskHolder) snapshotManifest(result snapshot.Result, startedAt time.Time) manifest.Manifest {
+	m := c.newManifest(result.ManifestKey, startedAt)
+	m.Format = manifest.FormatSnapshot
+	m.Size, m.SHA256 = result.ManifestSize, result.ManifestSHA256
+	m.Files = make([]manifest.File, 0, len(result.Manifest.Files))
+	for _, file := range result.Manifest.Files {
+		m.Files = append(m.Files, manifest.File{Path: file.Path, Size: file.Size})
+	}
+	return m
+}
+
+func (c *TaskHolder) writeArchive(ctx context.Context, w io.Writer, prepared *exporter.PreparedData, stageName string) error {
+	var encWriter io.WriteCloser
+	if c.conf.Encryption != nil {
+		var err error
+		encWriter, err = encrypt.NewWriter(*c.conf.Encryption, w)
+		if err != nil {
+			return fmt.Errorf("create encrypt writer failed: %w", err)
+		}
+		w = encWriter
+	}
+
+	progress, done := c.compressionProgress(stageName)
+	if err := utils.WriteArchive(ctx, w, c.archiveOptions(stageName), prepared.ArchiveDirs(), prepared.Streams, progress, done); err != nil {
+		return err
+	}
+
+	if encWriter != nil {
+		if err := encWriter.Close(); err != nil {
+			return fmt.Errorf("finish encryption failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// objectKey 按任务的 key 模板返回本次备份上传的对象 key。
+func (c *TaskHolder) objectKey() string {
+	extension := c.archiveOptions("").Extension()
+	if c.conf.Encryption != nil {
+		extension += encrypt.Extension(*c.conf.Encryption)
+	}
+	return c.names.Generate(time.Now(), extension)
+}
+
+func (c *TaskHolder) archiveOptions(stageName string) utils.ArchiveOptions {
+	archive := c.conf.GetArchive()
+	return utils.ArchiveOptions{
+		Format: archive.GetFormat(),
+		Level:  archive.Level,
+		OnExcluded: func(stats utils.FilterStats) {
+			c.setExcluded(stageName, stats)
+		},
+		OnEntry: func(name string, size int64) {
+			c.entries = append(c.entries, manifest.File{Path: name, Size: size})
+		},
+	}
+}
+
+func (c *TaskHolder) setExcluded(stageName string, stats utils.FilterStats) {
+	if stats.Files == 0 {
+		return
+	}
+	c.report.SetExcluded(stats.Files, stats.Bytes)
+	c.logger.Info("files excluded", "stage", stageName, "files", stats.Files, "size", notice.FormatBytes(stats.Bytes), "bytes", stats.Bytes)
+}
+
+func (c *TaskHolder) encryptBackup(compressedFile string) (string, error) {
+	const stageName = "加密文件"
+	c.logStageStart(stageName)
+
+	encryptedFile := compressedFile + encrypt.Extension(*c.conf.Encryption)
+	if err := encrypt.EncryptFile(*c.conf.Encryption, compressedFile, encryptedFile); err != nil {
+		c.logger.Error("encryption failed", "stage", stageName, "error", err)
+		c.report.MarkError("加密失败")
+		return "", err
+	}
+
+	c.logger.Info("encryption completed", "stage", stageName, "type", c.conf.Encryption.GetType(), "file", encryptedFile)
+	c.logStageFinish(stageName)
+	return encryptedFile, nil
+}
+
+func (c *TaskHolder) removeArchive(path string) {
+	if err := os.Remove(path); err != nil {
+		c.logger.Error("archive cleanup failed", "file", path, "error", err)
+		c.report.MarkError("清理备份文件失败")
+	}
+}
+
+// uploadBackup 依次上传到每个存储，一个存储失败不影响其他存储。返回上传成功的对象，以及上传失败的存储的错误。
+func (c *TaskHolder) uploadBackup(ctx context.Context, archiveFile string, m manifest.Manifest) ([]uploadedObject, error) {
+	var uploads []uploadedObject
+	var errs []error
+	for _, dest := range c.destinations {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+
+		uploaded, err := c.uploadTo(ctx, dest, archiveFile, m)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("upload to %s failed: %w", dest.Name, err))
+			continue
+		}
+		uploads = append(uploads, uploaded)
+	}
+	return uploads, errors.Join(errs...)
+}
+
+// uploadTo 上传备份文件和它的清单，重试时两者一起重新上传。
+func (c *TaskHolder) uploadTo(ctx context.Context, dest Destination, archiveFile string, m manifest.Manifest) (uploadedObject, error) {
+	stageName := "上传到OSS"
+	if len(c.destinations) > 1 {
+		stageName = "上传到" + dest.Name
+	}
+	storage := dest.Storage
+	bucketName := storage.BucketName()
+	objKey := m.Key
+
+	var size int64
+	if info, err := os.Stat(archiveFile); err == nil {
+		size = info.Size()
+	}
 
 	c.logStageStart(stageName)
 	c.logger.Info("upload started", "stage", stageName, "bucket", bucketName, "key", objKey)
 
-	result, err := ossClient.Upload(objKey, zipFile)
+	var result oss.UploadResult
+	err := c.runStage(ctx, stageName, c.conf.GetTimeout().GetUpload(), func(ctx context.Context) error {
+		var err error
+		result, err = storage.Upload(ctx, objKey, archiveFile)
+		if err != nil {
+			return err
+		}
+		return manifest.Upload(ctx, storage, m)
+	})
 	if err != nil {
 		c.logger.Error("upload failed", "stage", stageName, "bucket", result.Bucket, "key", result.Key, "error", err)
 		c.report.AddUploadFailure(result.Bucket, result.Key, err.Error())
 		c.report.MarkError("上传失败")
-		return err
+		return uploadedObject{}, err
 	}
 
 	c.logger.Info("upload succeeded", "stage", stageName, "bucket", result.Bucket, "key", result.Key, "mode", result.Mode)
 	c.report.AddUploadSuccess(result.Bucket, result.Key)
 	c.logStageFinish(stageName)
-	return nil
+	return uploadedObject{bucket: result.Bucket, key: objKey, mode: string(result.Mode), size: size, dest: dest}, nil
 }
 
 func (c *TaskHolder) sendMessages() {
@@ -224,10 +849,3 @@ func (c *TaskHolder) logStageFinish(stageName string) {
 func (c *TaskHolder) logStageError(stageName string, message string, err error) {
 	c.logger.Error(message, "stage", stageName, "error", err)
 }
-
-func taskStatus(hasErrors bool) string {
-	if hasErrors {
-		return "failed"
-	}
-	return "success"
-}
@@ -0,0 +1,438 @@
+package utils
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	ArchiveFormatZip    = "zip"
+	ArchiveFormatTarGz  = "tar.gz"
+	ArchiveFormatTarZst = "tar.zst"
+)
+
+// ArchiveOptions 备份文件的格式和压缩级别，Format 为空时使用 zip，Level 为 0 时使用格式的默认级别。
+type ArchiveOptions struct {
+	Format string
+	Level  int
+	// OnExcluded 在统计完源目录后调用，传入各目录被 Filter 排除的文件数和字节数之和
+	OnExcluded func(stats FilterStats)
+	// OnEntry 在每个文件写入备份后调用，传入条目路径和写入的字节数，目录和符号链接不调用
+	OnEntry func(name string, size int64)
+}
+
+// SourceDir 是写入备份的目录。
+type SourceDir struct {
+	// Name 是目录在备份中的路径，使用 '/' 分隔
+	Name string
+	Path string
+	// Filter 筛选目录中写入备份的文件，为 nil 时写入全部文件
+	Filter *PathFilter
+}
+
+// NewSourceDir 返回以目录名作为备份中路径的 SourceDir。
+func NewSourceDir(path string, filter *PathFilter) SourceDir {
+	path = filepath.Clean(path)
+	return SourceDir{Name: filepath.Base(path), Path: path, Filter: filter}
+}
+
+func (o ArchiveOptions) GetFormat() string {
+	if o.Format == "" {
+		return ArchiveFormatZip
+	}
+	return o.Format
+}
+
+// Extension 返回备份文件的后缀，例如 ".tar.zst"。
+func (o ArchiveOptions) Extension() string {
+	return "." + o.GetFormat()
+}
+
+// ArchiveFormatOf 根据文件名后缀识别备份格式，无法识别时返回 false。
+func ArchiveFormatOf(name string) (string, bool) {
+	for _, format := range []string{ArchiveFormatZip, ArchiveFormatTarGz, ArchiveFormatTarZst} {
+		if strings.HasSuffix(name, "."+format) {
+			return format, true
+		}
+	}
+	return "", false
+}
+
+// TrimArchiveExtension 去掉备份格式后缀。
+func TrimArchiveExtension(name string) string {
+	if format, ok := ArchiveFormatOf(name); ok {
+		return strings.TrimSuffix(name, "."+format)
+	}
+	return name
+}
+
+// ExtractArchive 按文件名后缀把 zip、tar.gz 或 tar.zst 备份解压到 targetDir。
+func ExtractArchive(file string, targetDir string) error {
+	format, ok := ArchiveFormatOf(file)
+	if !ok {
+		return fmt.Errorf("unsupported archive format: %s", filepath.Base(file))
+	}
+	if format == ArchiveFormatZip {
+		return UnzipFile(file, targetDir)
+	}
+	return untarFile(file, format, targetDir)
+}
+
+// writeTar 写入 tar 归档，保留文件权限、属主和符号链接。
+// tar 条目需要预先知道大小，streams 会先逐个写到临时文件，再写入归档。
+func writeTar(ctx context.Context, w io.Writer, opts ArchiveOptions, dirs []SourceDir, streams []StreamFile, tracker *ProgressTracker) error {
+	compressor, err := newCompressor(w, opts)
+	if err != nil {
+		return err
+	}
+
+	archive := tar.NewWriter(compressor)
+	for _, dir := range dirs {
+		if err := tarDir(ctx, archive, opts, dir, tracker); err != nil {
+			_ = compressor.Close()
+			return fmt.Errorf("tar failed: %w", err)
+		}
+	}
+
+	for _, stream := range streams {
+		if err := tarStream(ctx, archive, opts, stream, tracker); err != nil {
+			_ = compressor.Close()
+			return fmt.Errorf("tar failed: %w", err)
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		_ = compressor.Close()
+		return fmt.Errorf("close tar failed: %w", err)
+	}
+	if err := compressor.Close(); err != nil {
+		return fmt.Errorf("close %s compressor failed: %w", opts.GetFormat(), err)
+	}
+	return nil
+}
+
+func newCompressor(w io.Writer, opts ArchiveOptions) (io.WriteCloser, error) {
+	switch opts.GetFormat() {
+	case ArchiveFormatTarGz:
+		level := gzip.DefaultCompression
+		if opts.Level != 0 {
+			level = opts.Level
+		}
+		return gzip.NewWriterLevel(w, level)
+	case ArchiveFormatTarZst:
+		level := zstd.SpeedDefault
+		if opts.Level != 0 {
+			level = zstd.EncoderLevelFromZstd(opts.Level)
+		}
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(level))
+	default:
+		return nil, fmt.Errorf("unsupported tar format: %s", opts.GetFormat())
+	}
+}
+
+func tarDir(ctx context.Context, archive *tar.Writer, opts ArchiveOptions, dir SourceDir, tracker *ProgressTracker) error {
+	_, err := WalkFiltered(dir.Path, dir.Filter, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return fmt.Errorf("walk failed: %w", err)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var link string
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err = os.Readlink(path)
+			if err != nil {
+				return fmt.Errorf("read symlink failed: %w", err)
+			}
+		case !info.IsDir() && !info.Mode().IsRegular():
+			// socket、设备文件等不写入备份
+			log.Printf("skip irregular file: %s", path)
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return fmt.Errorf("create file header failed: %w", err)
+		}
+
+		relPath, err := filepath.Rel(dir.Path, path)
+		if err != nil {
+			return fmt.Errorf("rel path failed: %w", err)
+		}
+		header.Name = entryName(dir.Name, relPath)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+
+		if err := archive.WriteHeader(header); err != nil {
+			return fmt.Errorf("write header failed: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("open file failed: %w", err)
+		}
+		defer file.Close()
+
+		tracker.UpdateCurrentFile(path)
+		return opts.copyEntry(ctx, header.Name, archive, file, tracker)
+	})
+	return err
+}
+
+func tarStream(ctx context.Context, archive *tar.Writer, opts ArchiveOptions, stream StreamFile, tracker *ProgressTracker) error {
+	spool, err := os.CreateTemp("", "backupgo-stream-")
+	if err != nil {
+		return fmt.Errorf("create stream spool file failed: %w", err)
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	reader, err := stream.Open(ctx)
+	if err != nil {
+		return fmt.Errorf("open stream %s failed: %w", stream.Name, err)
+	}
+	if _, err := io.Copy(spool, &contextReader{ctx: ctx, r: reader}); err != nil {
+		_ = reader.Close()
+		return fmt.Errorf("read stream %s failed: %w", stream.Name, err)
+	}
+	if err := reader.Close(); err != nil {
+		return fmt.Errorf("read stream %s failed: %w", stream.Name, err)
+	}
+
+	size, err := spool.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("stat stream spool file failed: %w", err)
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("rewind stream spool file failed: %w", err)
+	}
+
+	header := &tar.Header{
+		Name:     stream.Name,
+		Typeflag: tar.TypeReg,
+		Mode:     0644,
+		Size:     size,
+		ModTime:  time.Now(),
+	}
+	if err := archive.WriteHeader(header); err != nil {
+		return fmt.Errorf("write header failed: %w", err)
+	}
+
+	tracker.UpdateCurrentFile(stream.Name)
+	return opts.copyEntry(ctx, stream.Name, archive, spool, tracker)
+}
+
+// contextReader 在 ctx 取消后停止读取
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// untarFile 解压 tar 归档，恢复权限和符号链接；以 root 运行时同时恢复属主。
+// 拒绝解压到 targetDir 之外的条目，以及经过符号链接写到 targetDir 之外的条目。
+func untarFile(file string, format string, targetDir string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("open archive file failed: %w", err)
+	}
+	defer f.Close()
+
+	var reader io.Reader
+	switch format {
+	case ArchiveFormatTarGz:
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("open gzip stream failed: %w", err)
+		}
+		defer gz.Close()
+		reader = gz
+	case ArchiveFormatTarZst:
+		zr, err := zstd.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("open zstd stream failed: %w", err)
+		}
+		defer zr.Close()
+		reader = zr
+	default:
+		return fmt.Errorf("unsupported tar format: %s", format)
+	}
+
+	targetDir = filepath.Clean(targetDir)
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return fmt.Errorf("create target directory failed: %w", err)
+	}
+	restoreOwner := os.Geteuid() == 0
+
+	type dirMode struct {
+		path string
+		mode os.FileMode
+	}
+	var dirs []dirMode
+
+	archive := tar.NewReader(reader)
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read tar entry failed: %w", err)
+		}
+
+		targetPath := filepath.Join(targetDir, filepath.FromSlash(header.Name))
+		if !isWithin(targetDir, targetPath) {
+			return fmt.Errorf("illegal file path in tar: %s", header.Name)
+		}
+		if err := checkNoSymlinkEscape(targetDir, filepath.Dir(targetPath)); err != nil {
+			return fmt.Errorf("illegal file path in tar: %s: %w", header.Name, err)
+		}
+
+		mode := header.FileInfo().Mode()
+		switch header.Typeflag {
+		case tar.TypeDir:
+			// 已存在的符号链接（例如前面的条目 a -> /etc）不能当成目录，否则最后的 chmod 会改到目标目录之外
+			if info, err := os.Lstat(targetPath); err == nil && info.Mode()&os.ModeSymlink != 0 {
+				return fmt.Errorf("illegal file path in tar: %s: directory is a symlink", header.Name)
+			}
+			if err := os.MkdirAll(targetPath, 0755); err != nil {
+				return fmt.Errorf("create directory failed: %w", err)
+			}
+			// 目录权限最后再设置，避免只读目录导致后面的文件无法写入
+			dirs = append(dirs, dirMode{path: targetPath, mode: mode.Perm()})
+		case tar.TypeReg:
+			if err := untarEntry(archive, targetPath, mode.Perm()); err != nil {
+				return err
+			}
+			_ = os.Chtimes(targetPath, header.ModTime, header.ModTime)
+		case tar.TypeSymlink:
+			if err := createSymlink(targetDir, targetPath, header.Linkname); err != nil {
+				return fmt.Errorf("illegal symlink in tar: %s -> %s: %w", header.Name, header.Linkname, err)
+			}
+		default:
+			continue
+		}
+
+		if restoreOwner {
+			// 属主恢复失败（例如文件系统不支持）不影响解压结果
+			_ = os.Lchown(targetPath, header.Uid, header.Gid)
+		}
+	}
+
+	for _, dir := range dirs {
+		if err := os.Chmod(dir.path, dir.mode); err != nil {
+			return fmt.Errorf("chmod directory failed: %w", err)
+		}
+	}
+	return nil
+}
+
+func untarEntry(reader io.Reader, targetPath string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+		return fmt.Errorf("create directory failed: %w", err)
+	}
+
+	// 先删除已有文件，避免通过已存在的符号链接写到其他位置
+	_ = os.Remove(targetPath)
+	dst, err := os.OpenFile(targetPath, os.O_CREATE|os.O_WRONLY|os.O_EXCL, mode)
+	if err != nil {
+		return fmt.Errorf("create file failed: %w", err)
+	}
+	if _, err := io.Copy(dst, reader); err != nil {
+		_ = dst.Close()
+		return fmt.Errorf("write file failed: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		return fmt.Errorf("write file failed: %w", err)
+	}
+	// OpenFile 的权限受 umask 影响
+	return os.Chmod(targetPath, mode)
+}
+
+func isWithin(root string, path string) bool {
+	return path == root || strings.HasPrefix(path, root+string(filepath.Separator))
+}
+
+// createSymlink 在 linkPath 创建指向 linkname 的符号链接，链接目标必须在 root 之内：
+// 先按路径检查，创建后再解析一次，防止经由已解压的其他链接指到 root 之外。
+// 目标尚不存在的链接只做路径检查，之后经由它写入的条目会被 checkNoSymlinkEscape 拒绝。
+func createSymlink(root string, linkPath string, linkname string) error {
+	target := filepath.FromSlash(linkname)
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(linkPath), target)
+	}
+	if !isWithin(root, filepath.Clean(target)) {
+		return errors.New("symlink target escapes target directory")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(linkPath), 0755); err != nil {
+		return fmt.Errorf("create directory failed: %w", err)
+	}
+	_ = os.Remove(linkPath)
+	if err := os.Symlink(filepath.FromSlash(linkname), linkPath); err != nil {
+		return fmt.Errorf("create symlink failed: %w", err)
+	}
+
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+	resolved, err := filepath.EvalSymlinks(linkPath)
+	if err == nil && !isWithin(resolvedRoot, resolved) {
+		_ = os.Remove(linkPath)
+		return errors.New("symlink target escapes target directory")
+	}
+	return nil
+}
+
+// checkNoSymlinkEscape 确认 dir 解析符号链接后仍在 root 之内。
+func checkNoSymlinkEscape(root string, dir string) error {
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+
+	// 逐级向上找到已经存在的目录再解析，尚未创建的目录不可能是符号链接
+	existing := dir
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		if existing == root {
+			return nil
+		}
+		existing = filepath.Dir(existing)
+	}
+
+	resolved, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return err
+	}
+	if !isWithin(resolvedRoot, resolved) {
+		return errors.New("path escapes target directory through a symlink")
+	}
+	return nil
+}
@@ -0,0 +1,264 @@
+package utils
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestArchivePathTarRoundTrip(t *testing.T) {
+	for _, format := range []string{ArchiveFormatTarGz, ArchiveFormatTarZst} {
+		t.Run(format, func(t *testing.T) {
+			sourceDir := filepath.Join(t.TempDir(), "app")
+			if err := os.MkdirAll(filepath.Join(sourceDir, "bin"), 0750); err != nil {
+				t.Fatalf("create source dir: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(sourceDir, "bin", "run.sh"), []byte("#!/bin/sh\n"), 0755); err != nil {
+				t.Fatalf("write source file: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(sourceDir, "secret.env"), []byte("TOKEN=1"), 0600); err != nil {
+				t.Fatalf("write source file: %v", err)
+			}
+			if err := os.Symlink("bin/run.sh", filepath.Join(sourceDir, "run")); err != nil {
+				t.Fatalf("create symlink: %v", err)
+			}
+
+			opts := ArchiveOptions{Format: format, Level: 3}
+			archiveFile := filepath.Join(t.TempDir(), "app_2024_03_08"+opts.Extension())
+			if _, err := ArchivePath(context.Background(), []SourceDir{NewSourceDir(sourceDir, nil)}, archiveFile, opts, func(string, int64, int64, float64) {}, nil); err != nil {
+				t.Fatalf("archive path: %v", err)
+			}
+
+			targetDir := t.TempDir()
+			if err := ExtractArchive(archiveFile, targetDir); err != nil {
+				t.Fatalf("extract archive: %v", err)
+			}
+
+			for name, want := range map[string]os.FileMode{"bin": 0750, "bin/run.sh": 0755, "secret.env": 0600} {
+				info, err := os.Stat(filepath.Join(targetDir, "app", filepath.FromSlash(name)))
+				if err != nil {
+					t.Fatalf("stat %s: %v", name, err)
+				}
+				if info.Mode().Perm() != want {
+					t.Fatalf("%s mode = %v, want %v", name, info.Mode().Perm(), want)
+				}
+			}
+
+			link, err := os.Readlink(filepath.Join(targetDir, "app", "run"))
+			if err != nil || link != "bin/run.sh" {
+				t.Fatalf("Readlink() = %q, %v, want bin/run.sh", link, err)
+			}
+		})
+	}
+}
+
+func TestWriteArchiveTarWithStreams(t *testing.T) {
+	var buf bytes.Buffer
+	var doneSize int64
+	streams := []StreamFile{{
+		Name: "app/db.dump",
+		Open: func(context.Context) (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader("dump data")), nil
+		},
+	}}
+
+	opts := ArchiveOptions{Format: ArchiveFormatTarZst}
+	if err := WriteArchive(context.Background(), &buf, opts, nil, streams, func(string, int64, int64, float64) {}, func(total int64) {
+		doneSize = total
+	}); err != nil {
+		t.Fatalf("write archive: %v", err)
+	}
+	if doneSize != int64(len("dump data")) {
+		t.Fatalf("unexpected processed size: %d", doneSize)
+	}
+
+	archiveFile := filepath.Join(t.TempDir(), "app.tar.zst")
+	if err := os.WriteFile(archiveFile, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("write archive file: %v", err)
+	}
+
+	targetDir := t.TempDir()
+	if err := ExtractArchive(archiveFile, targetDir); err != nil {
+		t.Fatalf("extract archive: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(targetDir, "app", "db.dump"))
+	if err != nil {
+		t.Fatalf("read extracted file: %v", err)
+	}
+	if string(content) != "dump data" {
+		t.Fatalf("unexpected extracted content: %q", content)
+	}
+}
+
+func TestExtractArchiveRejectsSymlinkEscape(t *testing.T) {
+	outside := t.TempDir()
+	archiveFile := filepath.Join(t.TempDir(), "evil.tar.gz")
+	writeTarGz(t, archiveFile, []tar.Header{
+		{Name: "app/link", Typeflag: tar.TypeSymlink, Linkname: outside, Mode: 0777},
+		{Name: "app/link/evil.txt", Typeflag: tar.TypeReg, Mode: 0644, Size: 4},
+	})
+
+	if err := ExtractArchive(archiveFile, t.TempDir()); err == nil {
+		t.Fatal("expected ExtractArchive to reject writing through a symlink")
+	}
+	if _, err := os.Stat(filepath.Join(outside, "evil.txt")); !os.IsNotExist(err) {
+		t.Fatalf("file written outside target directory, stat error: %v", err)
+	}
+}
+
+func TestExtractArchiveRejectsSymlinkDirChmod(t *testing.T) {
+	outside := t.TempDir()
+	if err := os.Chmod(outside, 0755); err != nil {
+		t.Fatalf("chmod outside dir: %v", err)
+	}
+	targetDir := t.TempDir()
+	cases := map[string][]tar.Header{
+		"absolute target": {
+			{Name: "app/link", Typeflag: tar.TypeSymlink, Linkname: outside, Mode: 0777},
+			{Name: "app/link/", Typeflag: tar.TypeDir, Mode: 0700},
+		},
+		"relative target": {
+			{Name: "app/link", Typeflag: tar.TypeSymlink, Linkname: "../../" + filepath.Base(outside), Mode: 0777},
+			{Name: "app/link/", Typeflag: tar.TypeDir, Mode: 0700},
+		},
+		// 路径检查在 root 之内，但经由另一个链接解析后到了 root 之外
+		"through another link": {
+			{Name: "app/x/y/root", Typeflag: tar.TypeSymlink, Linkname: targetDir, Mode: 0777},
+			{Name: "app/link", Typeflag: tar.TypeSymlink, Linkname: "x/y/root/../" + filepath.Base(outside), Mode: 0777},
+			{Name: "app/link/", Typeflag: tar.TypeDir, Mode: 0700},
+		},
+		"directory entry on an internal link": {
+			{Name: "app/inner/", Typeflag: tar.TypeDir, Mode: 0755},
+			{Name: "app/link", Typeflag: tar.TypeSymlink, Linkname: "inner", Mode: 0777},
+			{Name: "app/link/", Typeflag: tar.TypeDir, Mode: 0700},
+		},
+	}
+	for name, headers := range cases {
+		t.Run(name, func(t *testing.T) {
+			archiveFile := filepath.Join(t.TempDir(), "evil.tar.gz")
+			writeTarGz(t, archiveFile, headers)
+
+			if err := os.RemoveAll(filepath.Join(targetDir, "app")); err != nil {
+				t.Fatalf("clean target dir: %v", err)
+			}
+			if err := ExtractArchive(archiveFile, targetDir); err == nil {
+				t.Fatal("expected ExtractArchive to reject the symlink")
+			}
+			info, err := os.Stat(outside)
+			if err != nil || info.Mode().Perm() != 0755 {
+				t.Fatalf("directory outside target dir changed: %v %v", info.Mode(), err)
+			}
+		})
+	}
+}
+
+func TestExtractArchiveRestoresInternalSymlink(t *testing.T) {
+	archiveFile := filepath.Join(t.TempDir(), "app.tar.gz")
+	writeTarGz(t, archiveFile, []tar.Header{
+		{Name: "app/bin/run.sh", Typeflag: tar.TypeReg, Mode: 0755, Size: 4},
+		{Name: "app/run", Typeflag: tar.TypeSymlink, Linkname: "bin/run.sh", Mode: 0777},
+	})
+
+	targetDir := t.TempDir()
+	if err := ExtractArchive(archiveFile, targetDir); err != nil {
+		t.Fatalf("ExtractArchive returned error: %v", err)
+	}
+	link, err := os.Readlink(filepath.Join(targetDir, "app", "run"))
+	if err != nil || link != "bin/run.sh" {
+		t.Fatalf("unexpected symlink %q: %v", link, err)
+	}
+}
+
+func TestExtractArchiveRejectsPathTraversal(t *testing.T) {
+	archiveFile := filepath.Join(t.TempDir(), "evil.tar.gz")
+	writeTarGz(t, archiveFile, []tar.Header{
+		{Name: "../evil.txt", Typeflag: tar.TypeReg, Mode: 0644, Size: 4},
+	})
+
+	if err := ExtractArchive(archiveFile, t.TempDir()); err == nil {
+		t.Fatal("expected ExtractArchive to reject path traversal")
+	}
+}
+
+func TestExtractArchiveRejectsUnknownFormat(t *testing.T) {
+	if err := ExtractArchive(filepath.Join(t.TempDir(), "app.rar"), t.TempDir()); err == nil {
+		t.Fatal("expected ExtractArchive to reject unknown format")
+	}
+}
+
+// writeTarGz 写入一个 tar.gz，普通文件的内容为 "evil"
+func writeTarGz(t *testing.T, path string, headers []tar.Header) {
+	t.Helper()
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create archive file: %v", err)
+	}
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+	archive := tar.NewWriter(gz)
+	for _, header := range headers {
+		if err := archive.WriteHeader(&header); err != nil {
+			t.Fatalf("write tar header: %v", err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			if _, err := archive.Write([]byte("evil")); err != nil {
+				t.Fatalf("write tar entry: %v", err)
+			}
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("close tar: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("close gzip: %v", err)
+	}
+}
+
+func TestWriteArchiveReportsEntries(t *testing.T) {
+	sourceDir := filepath.Join(t.TempDir(), "app")
+	if err := os.MkdirAll(filepath.Join(sourceDir, "sub"), 0755); err != nil {
+		t.Fatalf("create source dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(sourceDir, "sub", "a.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatalf("write source file: %v", err)
+	}
+	if err := os.Symlink("sub/a.txt", filepath.Join(sourceDir, "link")); err != nil {
+		t.Fatalf("create symlink: %v", err)
+	}
+	streams := []StreamFile{{
+		Name: "app/db.dump",
+		Open: func(context.Context) (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader("dump data")), nil
+		},
+	}}
+
+	for _, format := range []string{ArchiveFormatZip, ArchiveFormatTarGz} {
+		t.Run(format, func(t *testing.T) {
+			entries := map[string]int64{}
+			opts := ArchiveOptions{Format: format, OnEntry: func(name string, size int64) { entries[name] = size }}
+			if err := WriteArchive(context.Background(), io.Discard, opts, []SourceDir{NewSourceDir(sourceDir, nil)}, streams, func(string, int64, int64, float64) {}, nil); err != nil {
+				t.Fatalf("write archive: %v", err)
+			}
+
+			// 符号链接只写入链接内容，不作为文件条目上报
+			want := map[string]int64{"app/sub/a.txt": 5, "app/db.dump": 9}
+			if len(entries) != len(want) {
+				t.Fatalf("unexpected entries: %v", entries)
+			}
+			for name, size := range want {
+				if entries[name] != size {
+					t.Fatalf("entry %s size = %d, want %d (entries %v)", name, entries[name], size, entries)
+				}
+			}
+		})
+	}
+}
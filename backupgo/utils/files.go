@@ -2,11 +2,13 @@ package utils
 
 import (
 	"archive/zip"
-	"errors"
+	"compress/flate"
+	"context"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
 	"sync/atomic"
 	"time"
@@ -46,12 +48,8 @@ func (pt *ProgressTracker) Start() {
 		for {
 			select {
 			case <-ticker.C:
-				pt.callback(
-					pt.currentFile,
-					atomic.LoadInt64(pt.processed),
-					pt.total,
-					float64(atomic.LoadInt64(pt.processed))/float64(pt.total)*100,
-				)
+				processed := atomic.LoadInt64(pt.processed)
+				pt.callback(pt.currentFile, processed, pt.total, pt.percentage(processed))
 			case <-pt.done:
 				ticker.Stop()
 				return
@@ -64,10 +62,18 @@ func (pt *ProgressTracker) Stop() {
 	close(pt.done)
 
 	if pt.doneCallback != nil {
-		pt.doneCallback(pt.total)
+		pt.doneCallback(atomic.LoadInt64(pt.processed))
 	}
 }
 
+// percentage 计算进度百分比，流式数据源无法预先知道总大小时返回 0
+func (pt *ProgressTracker) percentage(processed int64) float64 {
+	if pt.total <= 0 {
+		return 0
+	}
+	return float64(processed) / float64(pt.total) * 100
+}
+
 func (pt *ProgressTracker) UpdateCurrentFile(path string) {
 	pt.currentFile = path
 }
@@ -76,18 +82,19 @@ func (pt *ProgressTracker) IncProcessed(size int) {
 	atomic.AddInt64(pt.processed, int64(size))
 }
 
-func ZipPath(source string, target string, callback ProgressCallback, doneCallback ProgressDoneCallback) (string, error) {
-	source = filepath.Clean(source)
-	target = filepath.Clean(target)
-	log.Printf("zip path: %s, target: %s", source, target)
-
-	info, err := os.Stat(source)
-	if err != nil {
-		return "", fmt.Errorf("stat source path failed: %w", err)
-	}
+// StreamFile 表示以数据流形式写入 zip 的条目，例如数据库导出命令的标准输出。
+type StreamFile struct {
+	// Name 是 zip 中的条目路径，使用 '/' 分隔
+	Name string
+	// Open 在写入该条目时调用，ctx 被取消时返回的数据流应尽快结束
+	Open func(ctx context.Context) (io.ReadCloser, error)
+}
 
-	if !info.IsDir() {
-		return "", errors.New("source path is not a directory")
+// ArchivePath 把 dirs 中的目录按 opts 指定的格式压缩成 target 文件。
+func ArchivePath(ctx context.Context, dirs []SourceDir, target string, opts ArchiveOptions, callback ProgressCallback, doneCallback ProgressDoneCallback) (string, error) {
+	target = filepath.Clean(target)
+	for _, dir := range dirs {
+		log.Printf("archive path: %s, target: %s, format: %s", dir.Path, target, opts.GetFormat())
 	}
 
 	// 验证目标路径
@@ -96,26 +103,57 @@ func ZipPath(source string, target string, callback ProgressCallback, doneCallba
 		return "", fmt.Errorf("create target directory failed: %w", err)
 	}
 
-	baseDir := filepath.Base(source)
-	zipfile, err := os.Create(target)
+	file, err := os.Create(target)
 	if err != nil {
-		return "", fmt.Errorf("create zip file failed: %w", err)
+		return "", fmt.Errorf("create archive file failed: %w", err)
+	}
+
+	if err := WriteArchive(ctx, file, opts, dirs, nil, callback, doneCallback); err != nil {
+		_ = file.Close()
+		_ = os.Remove(target)
+		return "", err
 	}
-	defer zipfile.Close()
+	if err := file.Close(); err != nil {
+		_ = os.Remove(target)
+		return "", fmt.Errorf("close archive file failed: %w", err)
+	}
+
+	return target, nil
+}
 
-	// 计算总大小
+// WriteArchive 把 dirs 中的目录和 streams 中的数据流按 opts 指定的格式压缩写入 w。dirs 为空时只写入 streams，
+// 流式上传时 w 直接连到上传管道，不会在本地生成备份文件。ctx 取消后在下一次读取时中止压缩。
+func WriteArchive(ctx context.Context, w io.Writer, opts ArchiveOptions, dirs []SourceDir, streams []StreamFile, callback ProgressCallback, doneCallback ProgressDoneCallback) error {
 	var totalSize int64
-	err = filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+	var excluded FilterStats
+	for _, dir := range dirs {
+		info, err := os.Stat(dir.Path)
 		if err != nil {
-			return err
+			return fmt.Errorf("stat source path failed: %w", err)
 		}
+
 		if !info.IsDir() {
-			totalSize += info.Size()
+			return fmt.Errorf("source path %s is not a directory", dir.Path)
 		}
-		return nil
-	})
-	if err != nil {
-		return "", fmt.Errorf("calculate total size failed: %w", err)
+
+		// 计算总大小
+		stats, err := WalkFiltered(dir.Path, dir.Filter, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() {
+				totalSize += info.Size()
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("calculate total size failed: %w", err)
+		}
+		excluded.Files += stats.Files
+		excluded.Bytes += stats.Bytes
+	}
+	if len(dirs) > 0 && opts.OnExcluded != nil {
+		opts.OnExcluded(excluded)
 	}
 
 	// 创建进度追踪器
@@ -123,26 +161,62 @@ func ZipPath(source string, target string, callback ProgressCallback, doneCallba
 	tracker.Start()
 	defer tracker.Stop()
 
-	archive := zip.NewWriter(zipfile)
-	defer archive.Close()
+	switch opts.GetFormat() {
+	case ArchiveFormatTarGz, ArchiveFormatTarZst:
+		return writeTar(ctx, w, opts, dirs, streams, tracker)
+	default:
+		return writeZip(ctx, w, opts, dirs, streams, tracker)
+	}
+}
+
+func writeZip(ctx context.Context, w io.Writer, opts ArchiveOptions, dirs []SourceDir, streams []StreamFile, tracker *ProgressTracker) error {
+	archive := zip.NewWriter(w)
+	if opts.Level != 0 {
+		archive.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(out, opts.Level)
+		})
+	}
+
+	for _, dir := range dirs {
+		if err := zipDir(ctx, archive, opts, dir, tracker); err != nil {
+			return fmt.Errorf("zip failed: %w", err)
+		}
+	}
+
+	for _, stream := range streams {
+		if err := zipStream(ctx, archive, opts, stream, tracker); err != nil {
+			return fmt.Errorf("zip failed: %w", err)
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("close zip failed: %w", err)
+	}
 
-	err = filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+	return nil
+}
+
+func zipDir(ctx context.Context, archive *zip.Writer, opts ArchiveOptions, dir SourceDir, tracker *ProgressTracker) error {
+	_, err := WalkFiltered(dir.Path, dir.Filter, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return fmt.Errorf("walk failed: %w", err)
 		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 
 		header, err := zip.FileInfoHeader(info)
 		if err != nil {
 			return fmt.Errorf("create file header failed: %w", err)
 		}
 
-		relPath, err := filepath.Rel(source, path)
+		relPath, err := filepath.Rel(dir.Path, path)
 		if err != nil {
 			return fmt.Errorf("rel path failed: %w", err)
 		}
 
 		// Windows 路径分隔符转换为 ZIP 标准的 '/' 分隔符
-		header.Name = filepath.ToSlash(filepath.Join(baseDir, relPath))
+		header.Name = entryName(dir.Name, relPath)
 		if info.IsDir() {
 			header.Name += "/"
 		} else {
@@ -157,6 +231,17 @@ func ZipPath(source string, target string, callback ProgressCallback, doneCallba
 		if info.IsDir() {
 			return nil
 		}
+		// 符号链接写入链接内容，与 tar 一致，不写入链接指向的文件
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err := os.Readlink(path)
+			if err != nil {
+				return fmt.Errorf("read symlink failed: %w", err)
+			}
+			if _, err := writer.Write([]byte(filepath.ToSlash(link))); err != nil {
+				return fmt.Errorf("write symlink failed: %w", err)
+			}
+			return nil
+		}
 
 		file, err := os.Open(path)
 		if err != nil {
@@ -165,37 +250,178 @@ func ZipPath(source string, target string, callback ProgressCallback, doneCallba
 		defer file.Close()
 
 		tracker.UpdateCurrentFile(path)
+		return opts.copyEntry(ctx, header.Name, writer, file, tracker)
+	})
+	return err
+}
 
-		buf := make([]byte, 32*1024) // buffer
-		for {
-			nr, er := file.Read(buf)
-			if nr > 0 {
-				nw, ew := writer.Write(buf[:nr])
-				if nw > 0 {
-					tracker.IncProcessed(nw)
-				}
-				if ew != nil {
-					return fmt.Errorf("write file failed: %w", ew)
-				}
-				if nw != nr {
-					return fmt.Errorf("short write: wrote %d of %d bytes", nw, nr)
-				}
+// entryName 返回目录中的文件在备份中的条目路径
+func entryName(dirName string, relPath string) string {
+	return path.Join(dirName, filepath.ToSlash(relPath))
+}
+
+func zipStream(ctx context.Context, archive *zip.Writer, opts ArchiveOptions, stream StreamFile, tracker *ProgressTracker) error {
+	header := &zip.FileHeader{
+		Name:     stream.Name,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	}
+	header.SetMode(0644)
+
+	writer, err := archive.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("create header failed: %w", err)
+	}
+
+	reader, err := stream.Open(ctx)
+	if err != nil {
+		return fmt.Errorf("open stream %s failed: %w", stream.Name, err)
+	}
+	defer reader.Close()
+
+	tracker.UpdateCurrentFile(stream.Name)
+	return opts.copyEntry(ctx, stream.Name, writer, reader, tracker)
+}
+
+// copyEntry 把一个条目的内容写入备份，完成后通过 OnEntry 报告写入的字节数。
+func (o ArchiveOptions) copyEntry(ctx context.Context, name string, writer io.Writer, reader io.Reader, tracker *ProgressTracker) error {
+	counter := &entryCounter{w: writer}
+	if err := copyWithProgress(ctx, counter, reader, tracker); err != nil {
+		return err
+	}
+	if o.OnEntry != nil {
+		o.OnEntry(name, counter.n)
+	}
+	return nil
+}
+
+// entryCounter 统计写入一个条目的字节数
+type entryCounter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *entryCounter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func copyWithProgress(ctx context.Context, writer io.Writer, reader io.Reader, tracker *ProgressTracker) error {
+	buf := make([]byte, 32*1024) // buffer
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		nr, er := reader.Read(buf)
+		if nr > 0 {
+			nw, ew := writer.Write(buf[:nr])
+			if nw > 0 {
+				tracker.IncProcessed(nw)
 			}
-			if er == io.EOF {
-				break
+			if ew != nil {
+				return fmt.Errorf("write file failed: %w", ew)
 			}
-			if er != nil {
-				return fmt.Errorf("read file failed: %w", er)
+			if nw != nr {
+				return fmt.Errorf("short write: wrote %d of %d bytes", nw, nr)
 			}
 		}
+		if er == io.EOF {
+			return nil
+		}
+		if er != nil {
+			return fmt.Errorf("read file failed: %w", er)
+		}
+	}
+}
 
-		return nil
-	})
+// UnzipFile 把 zip 文件解压到 targetDir，拒绝解压到 targetDir 之外的条目、经过符号链接写到
+// targetDir 之外的条目，以及指向 targetDir 之外的符号链接。
+func UnzipFile(zipFile string, targetDir string) error {
+	reader, err := zip.OpenReader(zipFile)
+	if err != nil {
+		return fmt.Errorf("open zip file failed: %w", err)
+	}
+	defer reader.Close()
 
+	targetDir = filepath.Clean(targetDir)
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return fmt.Errorf("create target directory failed: %w", err)
+	}
+
+	for _, entry := range reader.File {
+		targetPath := filepath.Join(targetDir, filepath.FromSlash(entry.Name))
+		if !isWithin(targetDir, targetPath) {
+			return fmt.Errorf("illegal file path in zip: %s", entry.Name)
+		}
+		if err := checkNoSymlinkEscape(targetDir, filepath.Dir(targetPath)); err != nil {
+			return fmt.Errorf("illegal file path in zip: %s: %w", entry.Name, err)
+		}
+
+		switch mode := entry.Mode(); {
+		case mode.IsDir():
+			if err := os.MkdirAll(targetPath, 0755); err != nil {
+				return fmt.Errorf("create directory failed: %w", err)
+			}
+		case mode&os.ModeSymlink != 0:
+			if err := unzipSymlink(entry, targetDir, targetPath); err != nil {
+				return err
+			}
+		default:
+			if err := unzipEntry(entry, targetPath); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// unzipSymlink 创建 zip 中的符号链接，链接内容是条目的数据，只允许指向 targetDir 之内。
+func unzipSymlink(entry *zip.File, targetDir string, targetPath string) error {
+	src, err := entry.Open()
+	if err != nil {
+		return fmt.Errorf("open zip entry failed: %w", err)
+	}
+	linkname, err := io.ReadAll(io.LimitReader(src, 4096))
+	_ = src.Close()
 	if err != nil {
-		e := fmt.Errorf("zip failed: %w", err)
-		return "", e
+		return fmt.Errorf("read zip entry failed: %w", err)
 	}
 
-	return target, nil
+	if err := createSymlink(targetDir, targetPath, string(linkname)); err != nil {
+		return fmt.Errorf("illegal symlink in zip: %s -> %s: %w", entry.Name, linkname, err)
+	}
+	return nil
+}
+
+func unzipEntry(entry *zip.File, targetPath string) error {
+	if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+		return fmt.Errorf("create directory failed: %w", err)
+	}
+
+	src, err := entry.Open()
+	if err != nil {
+		return fmt.Errorf("open zip entry failed: %w", err)
+	}
+	defer src.Close()
+
+	mode := entry.Mode().Perm()
+	if mode == 0 {
+		mode = 0644
+	}
+
+	// 先删除已有文件，避免通过已存在的符号链接写到其他位置
+	_ = os.Remove(targetPath)
+	dst, err := os.OpenFile(targetPath, os.O_CREATE|os.O_WRONLY|os.O_EXCL, mode)
+	if err != nil {
+		return fmt.Errorf("create file failed: %w", err)
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		_ = dst.Close()
+		return fmt.Errorf("write file failed: %w", err)
+	}
+
+	return dst.Close()
 }
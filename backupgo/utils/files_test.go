@@ -1,9 +1,16 @@
 package utils
 
 import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 func Test_zipPath(t *testing.T) {
@@ -17,7 +24,7 @@ func Test_zipPath(t *testing.T) {
 
 	callbackCalled := false
 	doneCalled := false
-	path, err := ZipPath(sourceDir, target, func(filePath string, processed, total int64, percentage float64) {
+	path, err := ArchivePath(context.Background(), []SourceDir{NewSourceDir(sourceDir, nil)}, target, ArchiveOptions{}, func(filePath string, processed, total int64, percentage float64) {
 		callbackCalled = callbackCalled || total >= 0 || processed >= 0 || percentage >= 0 || filePath == ""
 	}, func(total int64) {
 		doneCalled = total > 0
@@ -39,3 +46,223 @@ func Test_zipPath(t *testing.T) {
 		t.Log("progress callback executed during test")
 	}
 }
+
+func TestUnzipFile(t *testing.T) {
+	sourceDir := filepath.Join(t.TempDir(), "app")
+	if err := os.MkdirAll(filepath.Join(sourceDir, "nested"), 0755); err != nil {
+		t.Fatalf("create source dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(sourceDir, "nested", "demo.txt"), []byte("backupgo test"), 0644); err != nil {
+		t.Fatalf("write source file: %v", err)
+	}
+
+	zipFile := filepath.Join(t.TempDir(), "app.zip")
+	if _, err := ArchivePath(context.Background(), []SourceDir{NewSourceDir(sourceDir, nil)}, zipFile, ArchiveOptions{}, func(string, int64, int64, float64) {}, nil); err != nil {
+		t.Fatalf("zip path: %v", err)
+	}
+
+	targetDir := t.TempDir()
+	if err := UnzipFile(zipFile, targetDir); err != nil {
+		t.Fatalf("unzip file: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(targetDir, "app", "nested", "demo.txt"))
+	if err != nil {
+		t.Fatalf("read extracted file: %v", err)
+	}
+	if string(content) != "backupgo test" {
+		t.Fatalf("unexpected extracted content: %q", content)
+	}
+}
+
+func TestUnzipFileRejectsPathTraversal(t *testing.T) {
+	zipFile := filepath.Join(t.TempDir(), "evil.zip")
+	file, err := os.Create(zipFile)
+	if err != nil {
+		t.Fatalf("create zip file: %v", err)
+	}
+	archive := zip.NewWriter(file)
+	writer, err := archive.Create("../evil.txt")
+	if err != nil {
+		t.Fatalf("create zip entry: %v", err)
+	}
+	if _, err := writer.Write([]byte("evil")); err != nil {
+		t.Fatalf("write zip entry: %v", err)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	file.Close()
+
+	if err := UnzipFile(zipFile, t.TempDir()); err == nil {
+		t.Fatal("expected UnzipFile to reject path traversal")
+	}
+}
+
+// writeZipEntries 生成测试用的 zip，mode 带 os.ModeSymlink 的条目内容为链接目标
+func writeZipEntries(t *testing.T, entries []zipTestEntry) string {
+	t.Helper()
+	zipFile := filepath.Join(t.TempDir(), "test.zip")
+	file, err := os.Create(zipFile)
+	if err != nil {
+		t.Fatalf("create zip file: %v", err)
+	}
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+	for _, entry := range entries {
+		header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate}
+		header.SetMode(entry.mode)
+		writer, err := archive.CreateHeader(header)
+		if err != nil {
+			t.Fatalf("create zip entry: %v", err)
+		}
+		if _, err := writer.Write([]byte(entry.content)); err != nil {
+			t.Fatalf("write zip entry: %v", err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	return zipFile
+}
+
+type zipTestEntry struct {
+	name    string
+	mode    os.FileMode
+	content string
+}
+
+func TestUnzipFileRejectsSymlinkEscape(t *testing.T) {
+	outside := t.TempDir()
+	cases := map[string][]zipTestEntry{
+		"absolute target": {{name: "app/link", mode: os.ModeSymlink | 0777, content: outside}},
+		"relative target": {{name: "app/link", mode: os.ModeSymlink | 0777, content: "../../etc"}},
+		"write through existing link": {
+			{name: "app/link", mode: os.ModeSymlink | 0777, content: "../.."},
+			{name: "app/link/evil.txt", mode: 0644, content: "evil"},
+		},
+	}
+	for name, entries := range cases {
+		t.Run(name, func(t *testing.T) {
+			targetDir := t.TempDir()
+			if err := UnzipFile(writeZipEntries(t, entries), targetDir); err == nil {
+				t.Fatal("expected UnzipFile to reject symlink escape")
+			}
+			if _, err := os.Stat(filepath.Join(outside, "evil.txt")); !os.IsNotExist(err) {
+				t.Fatalf("file written outside target dir: %v", err)
+			}
+		})
+	}
+
+	// 通过目标目录中已存在的符号链接写到外面
+	targetDir := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(targetDir, "app")); err != nil {
+		t.Fatalf("create symlink: %v", err)
+	}
+	zipFile := writeZipEntries(t, []zipTestEntry{{name: "app/evil.txt", mode: 0644, content: "evil"}})
+	if err := UnzipFile(zipFile, targetDir); err == nil {
+		t.Fatal("expected UnzipFile to reject writing through an existing symlink")
+	}
+	if _, err := os.Stat(filepath.Join(outside, "evil.txt")); !os.IsNotExist(err) {
+		t.Fatalf("file written outside target dir: %v", err)
+	}
+}
+
+func TestUnzipFileRestoresInternalSymlink(t *testing.T) {
+	targetDir := t.TempDir()
+	zipFile := writeZipEntries(t, []zipTestEntry{
+		{name: "app/bin/run.sh", mode: 0755, content: "#!/bin/sh"},
+		{name: "app/run", mode: os.ModeSymlink | 0777, content: "bin/run.sh"},
+	})
+	if err := UnzipFile(zipFile, targetDir); err != nil {
+		t.Fatalf("UnzipFile returned error: %v", err)
+	}
+
+	link, err := os.Readlink(filepath.Join(targetDir, "app", "run"))
+	if err != nil || link != "bin/run.sh" {
+		t.Fatalf("unexpected symlink %q: %v", link, err)
+	}
+}
+
+func TestZipRoundTripKeepsSymlink(t *testing.T) {
+	sourceDir := filepath.Join(t.TempDir(), "app")
+	if err := os.MkdirAll(filepath.Join(sourceDir, "bin"), 0755); err != nil {
+		t.Fatalf("create source dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(sourceDir, "bin", "run.sh"), []byte("#!/bin/sh"), 0755); err != nil {
+		t.Fatalf("write source file: %v", err)
+	}
+	if err := os.Symlink("bin/run.sh", filepath.Join(sourceDir, "run")); err != nil {
+		t.Fatalf("create symlink: %v", err)
+	}
+
+	zipFile := filepath.Join(t.TempDir(), "app.zip")
+	if _, err := ArchivePath(context.Background(), []SourceDir{NewSourceDir(sourceDir, nil)}, zipFile, ArchiveOptions{}, func(string, int64, int64, float64) {}, nil); err != nil {
+		t.Fatalf("zip path: %v", err)
+	}
+
+	targetDir := t.TempDir()
+	if err := UnzipFile(zipFile, targetDir); err != nil {
+		t.Fatalf("UnzipFile returned error: %v", err)
+	}
+	link, err := os.Readlink(filepath.Join(targetDir, "app", "run"))
+	if err != nil || link != "bin/run.sh" {
+		t.Fatalf("unexpected symlink %q: %v", link, err)
+	}
+	content, err := os.ReadFile(filepath.Join(targetDir, "app", "run"))
+	if err != nil || string(content) != "#!/bin/sh" {
+		t.Fatalf("unexpected content through symlink %q: %v", content, err)
+	}
+}
+
+func TestWriteZipWithStreams(t *testing.T) {
+	var buf bytes.Buffer
+	var doneSize int64
+	streams := []StreamFile{{
+		Name: "app/db.dump",
+		Open: func(context.Context) (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader("dump data")), nil
+		},
+	}}
+
+	if err := WriteArchive(context.Background(), &buf, ArchiveOptions{}, nil, streams, func(string, int64, int64, float64) {}, func(total int64) {
+		doneSize = total
+	}); err != nil {
+		t.Fatalf("write zip: %v", err)
+	}
+	if doneSize != int64(len("dump data")) {
+		t.Fatalf("unexpected processed size: %d", doneSize)
+	}
+
+	zipFile := filepath.Join(t.TempDir(), "app.zip")
+	if err := os.WriteFile(zipFile, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("write zip file: %v", err)
+	}
+
+	targetDir := t.TempDir()
+	if err := UnzipFile(zipFile, targetDir); err != nil {
+		t.Fatalf("unzip file: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(targetDir, "app", "db.dump"))
+	if err != nil {
+		t.Fatalf("read extracted file: %v", err)
+	}
+	if string(content) != "dump data" {
+		t.Fatalf("unexpected extracted content: %q", content)
+	}
+}
+
+func TestWriteZipReturnsStreamError(t *testing.T) {
+	streams := []StreamFile{{
+		Name: "app/db.dump",
+		Open: func(context.Context) (io.ReadCloser, error) {
+			return io.NopCloser(iotest.ErrReader(errors.New("dump failed"))), nil
+		},
+	}}
+
+	if err := WriteArchive(context.Background(), io.Discard, ArchiveOptions{}, nil, streams, func(string, int64, int64, float64) {}, nil); err == nil {
+		t.Fatal("expected WriteArchive to fail when a stream fails")
+	}
+}
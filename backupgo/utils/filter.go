@@ -0,0 +1,260 @@
+package utils
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gobwas/glob"
+)
+
+// BackupIgnoreFile 是源目录中的排除规则文件，规则只作用于它所在的目录及其子目录
+const BackupIgnoreFile = ".backupignore"
+
+// PathFilter 按 include/exclude 规则和 .backupignore 文件筛选目录中的文件。
+// 规则与 .gitignore 类似：不含 '/' 的规则匹配任意层级的文件名或目录名；含 '/' 的规则从源目录
+// （.backupignore 中的规则从该文件所在目录）开始匹配相对路径；以 '/' 结尾的规则只匹配目录。
+// '*' 不跨越目录，'**' 匹配任意层级。
+type PathFilter struct {
+	include []filterRule
+	exclude []filterRule
+}
+
+// FilterStats 统计被排除的文件数和字节数。
+type FilterStats struct {
+	Files int64
+	Bytes int64
+}
+
+type filterRule struct {
+	matcher  glob.Glob
+	anchored bool
+	dirOnly  bool
+}
+
+// NewPathFilter 编译 include 和 exclude 规则。include 为空时包含全部文件，
+// 否则只包含自身或所在目录匹配 include 的文件；exclude 优先于 include。
+func NewPathFilter(include []string, exclude []string) (*PathFilter, error) {
+	includeRules, err := compileRules(include)
+	if err != nil {
+		return nil, fmt.Errorf("compile include patterns failed: %w", err)
+	}
+	excludeRules, err := compileRules(exclude)
+	if err != nil {
+		return nil, fmt.Errorf("compile exclude patterns failed: %w", err)
+	}
+	return &PathFilter{include: includeRules, exclude: excludeRules}, nil
+}
+
+// ValidatePattern 检查单条过滤规则能否编译。
+func ValidatePattern(pattern string) error {
+	_, ok, err := compileRule(pattern)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("pattern can not be empty")
+	}
+	return nil
+}
+
+func compileRules(patterns []string) ([]filterRule, error) {
+	rules := make([]filterRule, 0, len(patterns))
+	for _, pattern := range patterns {
+		rule, ok, err := compileRule(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+// compileRule 编译单条规则，空规则返回 false
+func compileRule(pattern string) (filterRule, bool, error) {
+	pattern = strings.TrimPrefix(strings.TrimSpace(pattern), "./")
+	rule := filterRule{}
+	if strings.HasSuffix(pattern, "/") {
+		rule.dirOnly = true
+		pattern = strings.TrimRight(pattern, "/")
+	}
+	if strings.HasPrefix(pattern, "/") {
+		rule.anchored = true
+		pattern = strings.TrimLeft(pattern, "/")
+	}
+	if pattern == "" {
+		return rule, false, nil
+	}
+	rule.anchored = rule.anchored || strings.Contains(pattern, "/")
+
+	matcher, err := glob.Compile(pattern, '/')
+	if err != nil {
+		return rule, false, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	rule.matcher = matcher
+	return rule, true, nil
+}
+
+// match 判断相对于规则所在目录的路径 rel（使用 '/' 分隔）是否匹配
+func (r filterRule) match(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.anchored {
+		return r.matcher.Match(rel)
+	}
+	return r.matcher.Match(path.Base(rel))
+}
+
+func matchAny(rules []filterRule, rel string, isDir bool) bool {
+	for _, rule := range rules {
+		if rule.match(rel, isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// WalkFiltered 与 filepath.Walk 相同，但跳过被 filter 排除的文件和目录，并返回被排除的统计。
+// filter 为 nil 时不做任何过滤，也不读取 .backupignore。
+func WalkFiltered(root string, filter *PathFilter, fn filepath.WalkFunc) (FilterStats, error) {
+	var stats FilterStats
+	if filter == nil {
+		return stats, filepath.Walk(root, fn)
+	}
+
+	// ignores 按目录相对路径记录 .backupignore 中的规则
+	ignores := make(map[string][]filterRule)
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return fn(p, info, err)
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return fmt.Errorf("rel path failed: %w", err)
+		}
+		rel = filepath.ToSlash(rel)
+
+		if rel != "." && filter.excluded(rel, info.IsDir(), ignores) {
+			if info.IsDir() {
+				files, bytes, err := dirUsage(p)
+				if err != nil {
+					return err
+				}
+				stats.Files += files
+				stats.Bytes += bytes
+				return filepath.SkipDir
+			}
+			stats.Files++
+			stats.Bytes += info.Size()
+			return nil
+		}
+
+		if info.IsDir() {
+			rules, err := loadIgnoreFile(filepath.Join(p, BackupIgnoreFile))
+			if err != nil {
+				return err
+			}
+			if len(rules) > 0 {
+				ignores[rel] = rules
+			}
+		}
+
+		return fn(p, info, nil)
+	})
+	return stats, err
+}
+
+// excluded 判断 rel 是否被 exclude、.backupignore 排除，或者不在 include 范围内
+func (f *PathFilter) excluded(rel string, isDir bool, ignores map[string][]filterRule) bool {
+	if matchAny(f.exclude, rel, isDir) {
+		return true
+	}
+
+	for dir := path.Dir(rel); ; dir = path.Dir(dir) {
+		if rules := ignores[dir]; len(rules) > 0 {
+			sub := rel
+			if dir != "." {
+				sub = strings.TrimPrefix(rel, dir+"/")
+			}
+			if matchAny(rules, sub, isDir) {
+				return true
+			}
+		}
+		if dir == "." {
+			break
+		}
+	}
+
+	// 目录始终遍历，include 只作用于文件
+	if isDir || len(f.include) == 0 {
+		return false
+	}
+	if matchAny(f.include, rel, false) {
+		return false
+	}
+	for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+		if matchAny(f.include, dir, true) {
+			return false
+		}
+	}
+	return true
+}
+
+// loadIgnoreFile 读取 .backupignore，忽略空行和以 '#' 开头的注释，文件不存在时返回 nil
+func loadIgnoreFile(file string) ([]filterRule, error) {
+	f, err := os.Open(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open %s failed: %w", file, err)
+	}
+	defer f.Close()
+
+	var rules []filterRule
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		rule, ok, err := compileRule(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", file, line, err)
+		}
+		if ok {
+			rules = append(rules, rule)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %s failed: %w", file, err)
+	}
+	return rules, nil
+}
+
+// dirUsage 统计被排除目录中的文件数和字节数
+func dirUsage(dir string) (int64, int64, error) {
+	var files, bytes int64
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			files++
+			bytes += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("calculate excluded size failed: %w", err)
+	}
+	return files, bytes, nil
+}
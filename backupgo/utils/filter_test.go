@@ -0,0 +1,167 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func writeFilterTestFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("create dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+}
+
+// walkedFiles 返回 WalkFiltered 访问到的文件相对路径
+func walkedFiles(t *testing.T, root string, filter *PathFilter) ([]string, FilterStats) {
+	t.Helper()
+
+	var files []string
+	stats, err := WalkFiltered(root, filter, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			rel, _ := filepath.Rel(root, path)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WalkFiltered returned error: %v", err)
+	}
+	sort.Strings(files)
+	return files, stats
+}
+
+func TestWalkFilteredExcludeRules(t *testing.T) {
+	root := t.TempDir()
+	for path, content := range map[string]string{
+		"app.js":                     "app",
+		"app.log":                    "log",
+		"node_modules/lib/index.js":  "1234567890",
+		"web/node_modules/x.js":      "12345",
+		"cache/a.bin":                "aa",
+		"data/cache":                 "cache file",
+		"data/keep.txt":              "keep",
+		"data/tmp/session":           "s",
+		"data/nested/tmp/session.db": "keep",
+	} {
+		writeFilterTestFile(t, filepath.Join(root, filepath.FromSlash(path)), content)
+	}
+
+	filter, err := NewPathFilter(nil, []string{"node_modules", "*.log", "cache/", "data/tmp"})
+	if err != nil {
+		t.Fatalf("NewPathFilter returned error: %v", err)
+	}
+
+	files, stats := walkedFiles(t, root, filter)
+	want := []string{"app.js", "data/cache", "data/keep.txt", "data/nested/tmp/session.db"}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("walked files = %v, want %v", files, want)
+	}
+	if stats.Files != 5 || stats.Bytes != int64(len("log")+10+5+2+1) {
+		t.Fatalf("unexpected excluded stats: %+v", stats)
+	}
+}
+
+func TestWalkFilteredIncludeRules(t *testing.T) {
+	root := t.TempDir()
+	for _, path := range []string{"conf/app.yml", "conf/extra/db.yml", "uploads/a.jpg", "tmp/b.jpg", "README.md"} {
+		writeFilterTestFile(t, filepath.Join(root, filepath.FromSlash(path)), "x")
+	}
+
+	filter, err := NewPathFilter([]string{"conf", "uploads/*.jpg"}, []string{"db.yml"})
+	if err != nil {
+		t.Fatalf("NewPathFilter returned error: %v", err)
+	}
+
+	files, stats := walkedFiles(t, root, filter)
+	if want := []string{"conf/app.yml", "uploads/a.jpg"}; !reflect.DeepEqual(files, want) {
+		t.Fatalf("walked files = %v, want %v", files, want)
+	}
+	if stats.Files != 3 {
+		t.Fatalf("unexpected excluded stats: %+v", stats)
+	}
+}
+
+func TestWalkFilteredBackupIgnore(t *testing.T) {
+	root := t.TempDir()
+	writeFilterTestFile(t, filepath.Join(root, BackupIgnoreFile), "# 根目录规则\n*.tmp\n\n/build/\n")
+	writeFilterTestFile(t, filepath.Join(root, "web", BackupIgnoreFile), "dist\n")
+	for _, path := range []string{"a.tmp", "b.txt", "build/out", "src/build/keep", "web/dist/app.js", "web/index.html", "dist/keep"} {
+		writeFilterTestFile(t, filepath.Join(root, filepath.FromSlash(path)), "x")
+	}
+
+	filter, err := NewPathFilter(nil, nil)
+	if err != nil {
+		t.Fatalf("NewPathFilter returned error: %v", err)
+	}
+
+	files, stats := walkedFiles(t, root, filter)
+	want := []string{BackupIgnoreFile, "b.txt", "dist/keep", "src/build/keep", "web/" + BackupIgnoreFile, "web/index.html"}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("walked files = %v, want %v", files, want)
+	}
+	if stats.Files != 3 {
+		t.Fatalf("unexpected excluded stats: %+v", stats)
+	}
+
+	// 不传 filter 时不读取 .backupignore
+	if all, _ := walkedFiles(t, root, nil); len(all) != 9 {
+		t.Fatalf("expected all files without filter, got %v", all)
+	}
+}
+
+func TestWriteArchiveReportsExcludedFiles(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "app")
+	writeFilterTestFile(t, filepath.Join(source, "main.go"), "package main")
+	writeFilterTestFile(t, filepath.Join(source, "logs", "app.log"), "0123456789")
+
+	filter, err := NewPathFilter(nil, []string{"logs/"})
+	if err != nil {
+		t.Fatalf("NewPathFilter returned error: %v", err)
+	}
+
+	var excluded FilterStats
+	opts := ArchiveOptions{OnExcluded: func(stats FilterStats) { excluded = stats }}
+	var buf bytes.Buffer
+	if err := WriteArchive(context.Background(), &buf, opts, []SourceDir{NewSourceDir(source, filter)}, nil, func(string, int64, int64, float64) {}, nil); err != nil {
+		t.Fatalf("WriteArchive returned error: %v", err)
+	}
+	if excluded.Files != 1 || excluded.Bytes != 10 {
+		t.Fatalf("unexpected excluded stats: %+v", excluded)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	var names []string
+	for _, file := range reader.File {
+		names = append(names, file.Name)
+	}
+	if want := []string{"app/", "app/main.go"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("zip entries = %v, want %v", names, want)
+	}
+}
+
+func TestValidatePattern(t *testing.T) {
+	if err := ValidatePattern("**/*.log"); err != nil {
+		t.Fatalf("ValidatePattern returned error: %v", err)
+	}
+	for _, pattern := range []string{"", "  ", "/", "[abc"} {
+		if err := ValidatePattern(pattern); err == nil {
+			t.Fatalf("expected ValidatePattern(%q) to fail", pattern)
+		}
+	}
+}
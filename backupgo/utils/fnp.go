@@ -4,23 +4,49 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 )
 
-const (
-	prefixMatchIndex = 1
-	yearMatchIndex   = 2
-	monthMatchIndex  = 3
-	dayMatchIndex    = 4
+// DefaultKeyTemplate 是未配置 key_template 时的对象 key 模板，即 <id>_YYYY_MM_DD.<ext>
+const DefaultKeyTemplate = "{id}_{date}.{ext}"
 
-	retentionDays = 7
+// keyTemplateAliases 是组合占位符，解析模板前展开为基本占位符
+var keyTemplateAliases = strings.NewReplacer(
+	"{date}", "{yyyy}_{mm}_{dd}",
+	"{time}", "{hh}{mi}{ss}",
+	"{timestamp}", "{yyyy}_{mm}_{dd}_{hh}{mi}{ss}",
 )
 
+// keyFields 是模板支持的基本占位符及其匹配规则。{id} 和 {host} 有值时按原文匹配，
+// 没有值时按这里的规则匹配任意任务或主机。
+var keyFields = map[string]string{
+	"id":   `[a-zA-Z0-9_]+`,
+	"host": `[^/]+`,
+	"yyyy": `\d{4}`,
+	"mm":   `\d{2}`,
+	"dd":   `\d{2}`,
+	"hh":   `\d{2}`,
+	"mi":   `\d{2}`,
+	"ss":   `\d{2}`,
+	"ext":  `[a-zA-Z0-9]+(?:\.[a-zA-Z0-9]+)*`,
+}
+
+// keyTemplatePart 是模板中的一段，field 为空时是原样输出的文本
+type keyTemplatePart struct {
+	field   string
+	literal string
+}
+
+// FileNameProcessor 按对象 key 模板生成和解析备份文件的 key
 type FileNameProcessor struct {
+	parts  []keyTemplatePart
 	rg     *regexp.Regexp // match string
-	format string
+	fields []string       // 每个分组对应的占位符
+	id     string
+	host   string
 }
 
 type FNParserResult struct {
@@ -28,87 +54,204 @@ type FNParserResult struct {
 	Year   int
 	Month  int
 	Day    int
+	Hour   int
+	Minute int
+	Second int
 }
 
 var (
-	defaultProcessor = &FileNameProcessor{
-		rg:     regexp.MustCompile(`^([a-zA-Z0-9_]+)_(\d{4})_(\d{2})_(\d{2})`),
-		format: `%s_%d_%02d_%02d`,
-	}
-	nowFunc = time.Now
+	defaultProcessor = mustFileNameProcessor(DefaultKeyTemplate, "", "")
+	nowFunc          = time.Now
 )
 
-// Generate 生成包含前缀和日期的字符串
-func (sp *FileNameProcessor) Generate(prefix string, t time.Time) string {
-	return fmt.Sprintf(sp.format, prefix, t.Year(), t.Month(), t.Day())
-}
-
-// Parse 解析包含前缀和日期的字符串，并返回充结构体
-func (sp *FileNameProcessor) Parse(s string) (*FNParserResult, error) {
-	matches := sp.rg.FindStringSubmatch(s)
-	if matches == nil {
-		return nil, errors.New("invalid string format")
-	}
-
-	prefix := matches[prefixMatchIndex]
-	year, err := parseMatchInt(matches, yearMatchIndex)
+// NewFileNameProcessor 编译对象 key 模板。id 和 host 是 {id}、{host} 的取值，
+// 为空时解析会匹配任意任务或主机。
+func NewFileNameProcessor(template string, id string, host string) (*FileNameProcessor, error) {
+	parts, err := parseKeyTemplate(keyTemplateAliases.Replace(template))
 	if err != nil {
 		return nil, err
 	}
 
-	month, err := parseMatchIntInRange(matches, monthMatchIndex, 1, 12, "invalid month value")
-	if err != nil {
-		return nil, err
+	sp := &FileNameProcessor{parts: parts, id: id, host: host}
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	for _, part := range parts {
+		if part.field == "" {
+			pattern.WriteString(regexp.QuoteMeta(part.literal))
+			continue
+		}
+
+		value := sp.fieldValue(part.field)
+		if value != "" {
+			pattern.WriteString(regexp.QuoteMeta(value))
+			continue
+		}
+		pattern.WriteString("(" + keyFields[part.field] + ")")
+		sp.fields = append(sp.fields, part.field)
 	}
+	pattern.WriteString("$")
+	sp.rg = regexp.MustCompile(pattern.String())
+	return sp, nil
+}
 
-	day, err := parseMatchIntInRange(matches, dayMatchIndex, 1, 31, "invalid day value")
+func mustFileNameProcessor(template string, id string, host string) *FileNameProcessor {
+	sp, err := NewFileNameProcessor(template, id, host)
 	if err != nil {
-		return nil, err
+		panic(err)
 	}
-
-	return &FNParserResult{
-		Prefix: prefix,
-		Year:   year,
-		Month:  month,
-		Day:    day,
-	}, nil
+	return sp
 }
 
-func parseMatchInt(matches []string, index int) (int, error) {
-	return strconv.Atoi(matches[index])
-}
+// parseKeyTemplate 把模板拆分为文本和占位符，并检查模板能生成合法、可解析的 key。
+func parseKeyTemplate(template string) ([]keyTemplatePart, error) {
+	if template == "" {
+		return nil, errors.New("template can not be empty")
+	}
 
-func parseMatchIntInRange(matches []string, index int, minValue int, maxValue int, errMessage string) (int, error) {
-	value, err := parseMatchInt(matches, index)
-	if err != nil {
-		return 0, err
+	var parts []keyTemplatePart
+	rest := template
+	for rest != "" {
+		start := strings.IndexAny(rest, "{}")
+		if start < 0 {
+			parts = append(parts, keyTemplatePart{literal: rest})
+			break
+		}
+		if start > 0 {
+			parts = append(parts, keyTemplatePart{literal: rest[:start]})
+		}
+		if rest[start] == '}' {
+			return nil, errors.New("unexpected }")
+		}
+
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return nil, errors.New("unclosed placeholder")
+		}
+		field := rest[start+1 : start+end]
+		if _, ok := keyFields[field]; !ok {
+			return nil, fmt.Errorf("unknown placeholder {%s}", field)
+		}
+		parts = append(parts, keyTemplatePart{field: field})
+		rest = rest[start+end+1:]
 	}
-	if value < minValue || value > maxValue {
-		return 0, errors.New(errMessage)
+
+	contains := func(field string) bool {
+		return slices.ContainsFunc(parts, func(part keyTemplatePart) bool { return part.field == field })
+	}
+	if !contains("id") {
+		return nil, errors.New("template must contain {id}")
+	}
+	if !contains("yyyy") || !contains("mm") || !contains("dd") {
+		return nil, errors.New("template must contain the date, use {date}, {timestamp} or {yyyy}, {mm} and {dd}")
+	}
+	if !strings.HasSuffix(template, ".{ext}") || slices.IndexFunc(parts, func(part keyTemplatePart) bool { return part.field == "ext" }) != len(parts)-1 {
+		return nil, errors.New("template must end with .{ext} and use {ext} only once")
+	}
+	for _, segment := range strings.Split(template, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return nil, errors.New("template can not contain empty, . or .. path segments")
+		}
 	}
 
-	return value, nil
+	return parts, nil
 }
 
-func (r *FNParserResult) ToTime() time.Time {
-	return time.Date(r.Year, time.Month(r.Month), r.Day, 0, 0, 0, 0, time.UTC)
+func (sp *FileNameProcessor) fieldValue(field string) string {
+	switch field {
+	case "id":
+		return sp.id
+	case "host":
+		return sp.host
+	}
+	return ""
 }
 
-func IsNeedDeleteFile(prefix, name string) bool {
-	result, err := defaultProcessor.Parse(name)
-	if err != nil {
-		return false
+// Generate 按模板生成 t 时刻的对象 key，extension 为文件后缀，例如 ".tar.zst.age"
+func (sp *FileNameProcessor) Generate(t time.Time, extension string) string {
+	var key strings.Builder
+	for _, part := range sp.parts {
+		switch part.field {
+		case "":
+			key.WriteString(part.literal)
+		case "yyyy":
+			fmt.Fprintf(&key, "%04d", t.Year())
+		case "mm":
+			fmt.Fprintf(&key, "%02d", t.Month())
+		case "dd":
+			fmt.Fprintf(&key, "%02d", t.Day())
+		case "hh":
+			fmt.Fprintf(&key, "%02d", t.Hour())
+		case "mi":
+			fmt.Fprintf(&key, "%02d", t.Minute())
+		case "ss":
+			fmt.Fprintf(&key, "%02d", t.Second())
+		case "ext":
+			key.WriteString(strings.TrimPrefix(extension, "."))
+		default:
+			key.WriteString(sp.fieldValue(part.field))
+		}
+	}
+	return key.String()
+}
+
+// Parse 解析按模板生成的对象 key，并返回充结构体。模板不含时分秒时结果中为 0。
+func (sp *FileNameProcessor) Parse(s string) (*FNParserResult, error) {
+	matches := sp.rg.FindStringSubmatch(s)
+	if matches == nil {
+		return nil, errors.New("invalid string format")
 	}
-	if !strings.EqualFold(result.Prefix, prefix) {
-		return false
+
+	// 同一个占位符可以出现多次，例如目录和文件名中都有 {yyyy}，取值必须一致
+	values := map[string]string{"id": sp.id}
+	for i, field := range sp.fields {
+		value := matches[i+1]
+		if previous, ok := values[field]; ok && previous != "" && previous != value {
+			return nil, fmt.Errorf("inconsistent {%s} value", field)
+		}
+		values[field] = value
 	}
 
-	fileDate := result.ToTime()
-	beforeDate := nowFunc().AddDate(0, 0, -retentionDays)
+	result := &FNParserResult{Prefix: values["id"]}
+	for _, field := range []struct {
+		name     string
+		label    string
+		target   *int
+		min, max int
+	}{
+		{"yyyy", "year", &result.Year, 0, 9999},
+		{"mm", "month", &result.Month, 1, 12},
+		{"dd", "day", &result.Day, 1, 31},
+		{"hh", "hour", &result.Hour, 0, 23},
+		{"mi", "minute", &result.Minute, 0, 59},
+		{"ss", "second", &result.Second, 0, 59},
+	} {
+		value, ok := values[field.name]
+		if !ok {
+			continue
+		}
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, err
+		}
+		if number < field.min || number > field.max {
+			return nil, fmt.Errorf("invalid %s value", field.label)
+		}
+		*field.target = number
+	}
+
+	return result, nil
+}
+
+func (r *FNParserResult) ToTime() time.Time {
+	return time.Date(r.Year, time.Month(r.Month), r.Day, r.Hour, r.Minute, r.Second, 0, time.UTC)
+}
 
-	return fileDate.Before(beforeDate)
+// ParseFileName 使用默认格式解析备份文件名
+func ParseFileName(name string) (*FNParserResult, error) {
+	return defaultProcessor.Parse(name)
 }
 
-func GetFileName(prefix string) string {
-	return defaultProcessor.Generate(prefix, nowFunc()) + ".zip"
+// GetFileName 生成当天的备份文件名，extension 为文件后缀，例如 ".tar.zst"
+func GetFileName(prefix string, extension string) string {
+	return mustFileNameProcessor(DefaultKeyTemplate, prefix, "").Generate(nowFunc(), extension)
 }
@@ -9,9 +9,60 @@ import (
 func TestFileNameProcessor_Generate(t *testing.T) {
 	timestamp := time.Date(2024, 3, 8, 12, 0, 0, 0, time.UTC)
 
-	out := defaultProcessor.Generate("test", timestamp)
-	if out != "test_2024_03_08" {
-		t.Fatalf("expected generated file name %q, got %q", "test_2024_03_08", out)
+	out := mustFileNameProcessor(DefaultKeyTemplate, "test", "").Generate(timestamp, ".zip")
+	if out != "test_2024_03_08.zip" {
+		t.Fatalf("expected generated file name %q, got %q", "test_2024_03_08.zip", out)
+	}
+}
+
+func TestFileNameProcessorTemplate(t *testing.T) {
+	processor, err := NewFileNameProcessor("{host}/{id}/{yyyy}/{mm}/{id}_{timestamp}.{ext}", "app", "web-1")
+	if err != nil {
+		t.Fatalf("compile template: %v", err)
+	}
+
+	timestamp := time.Date(2024, 3, 8, 2, 30, 5, 0, time.UTC)
+	key := processor.Generate(timestamp, ".tar.zst.age")
+	if want := "web-1/app/2024/03/app_2024_03_08_023005.tar.zst.age"; key != want {
+		t.Fatalf("expected key %q, got %q", want, key)
+	}
+
+	got, err := processor.Parse(key)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if !got.ToTime().Equal(timestamp) || got.Prefix != "app" {
+		t.Fatalf("unexpected result %+v", got)
+	}
+
+	for _, other := range []string{
+		"web-2/app/2024/03/app_2024_03_08_023005.zip",
+		"web-1/app_2/2024/03/app_2_2024_03_08_023005.zip",
+		"web-1/app/2024/04/app_2024_03_08_023005.zip",
+		"app_2024_03_08.zip",
+	} {
+		if _, err := processor.Parse(other); err == nil {
+			t.Fatalf("expected %q not to match", other)
+		}
+	}
+}
+
+func TestNewFileNameProcessorRejectsInvalidTemplates(t *testing.T) {
+	for _, template := range []string{
+		"",
+		"{date}.{ext}",
+		"{id}.{ext}",
+		"{id}_{date}",
+		"{id}_{date}.{ext}.bak",
+		"{id}_{date}_{unknown}.{ext}",
+		"{id}_{date.{ext}",
+		"/{id}_{date}.{ext}",
+		"../{id}_{date}.{ext}",
+		"{id}//{id}_{date}.{ext}",
+	} {
+		if _, err := NewFileNameProcessor(template, "app", "host"); err == nil {
+			t.Fatalf("expected template %q to be rejected", template)
+		}
 	}
 }
 
@@ -32,6 +83,16 @@ func TestFileNameProcessor_Parse(t *testing.T) {
 				Day:    8,
 			},
 		},
+		{
+			name:  "tar archive with encryption suffix",
+			input: "app_2024_03_08.tar.zst.age",
+			want: &FNParserResult{
+				Prefix: "app",
+				Year:   2024,
+				Month:  3,
+				Day:    8,
+			},
+		},
 		{
 			name:    "invalid format",
 			input:   "bad-name",
@@ -69,34 +130,6 @@ func TestFileNameProcessor_Parse(t *testing.T) {
 	}
 }
 
-func TestNeedDeleteFile(t *testing.T) {
-	previousNow := nowFunc
-	nowFunc = func() time.Time {
-		return time.Date(2024, 3, 10, 10, 0, 0, 0, time.UTC)
-	}
-	defer func() {
-		nowFunc = previousNow
-	}()
-
-	oldName := defaultProcessor.Generate("test_cc_s", nowFunc().AddDate(0, 0, -9))
-	if !IsNeedDeleteFile("test_cc_s", oldName) {
-		t.Fatalf("expected %q to be deletable", oldName)
-	}
-
-	recentName := defaultProcessor.Generate("test_cc_s", nowFunc().AddDate(0, 0, -1))
-	if IsNeedDeleteFile("test_cc_s", recentName) {
-		t.Fatalf("expected %q to be kept", recentName)
-	}
-
-	if IsNeedDeleteFile("other_prefix", oldName) {
-		t.Fatalf("expected prefix mismatch to return false")
-	}
-
-	if IsNeedDeleteFile("test_cc_s", "invalid-name") {
-		t.Fatalf("expected invalid file name to return false")
-	}
-}
-
 func TestGetFileName(t *testing.T) {
 	previousNow := nowFunc
 	nowFunc = func() time.Time {
@@ -106,8 +139,8 @@ func TestGetFileName(t *testing.T) {
 		nowFunc = previousNow
 	}()
 
-	got := GetFileName("backup")
-	if got != "backup_2024_03_08.zip" {
-		t.Fatalf("expected file name %q, got %q", "backup_2024_03_08.zip", got)
+	got := GetFileName("backup", ".tar.zst")
+	if got != "backup_2024_03_08.tar.zst" {
+		t.Fatalf("expected file name %q, got %q", "backup_2024_03_08.tar.zst", got)
 	}
 }
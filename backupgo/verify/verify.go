@@ -0,0 +1,200 @@
+package verify
+
+import (
+	"backupgo/config"
+	"backupgo/encrypt"
+	"backupgo/exporter"
+	"backupgo/oss"
+	"backupgo/snapshot"
+	"backupgo/utils"
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Options 校验参数。
+type Options struct {
+	TaskID  string
+	Conf    config.BackupConfig
+	Storage oss.Storage
+	Logger  *slog.Logger
+}
+
+// Result 汇总一次校验的结果。
+type Result struct {
+	Key  string
+	Mode string
+	// Files 是完整校验时读取到的文件数，quick 模式为 0
+	Files int
+	// DumpChecked 表示是否用数据源的恢复工具检查过导出文件
+	DumpChecked bool
+}
+
+// Archive 校验上传的归档备份。quick 模式只确认对象存在且大小与上传的字节数一致；
+// full 模式重新下载、解密并完整解压（zip 校验每个条目的 CRC，gzip 和 zstd 校验整个数据流），再交给备份源检查导出文件。
+func Archive(ctx context.Context, opts Options, key string, size int64) (Result, error) {
+	result := Result{Key: key, Mode: opts.Conf.Verify.GetMode()}
+
+	if result.Mode == config.VerifyModeQuick {
+		return result, checkObject(ctx, opts.Storage, key, size)
+	}
+
+	tempDir, err := os.MkdirTemp("", "backupgo-verify-")
+	if err != nil {
+		return result, fmt.Errorf("create temp dir failed: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	archiveFile, err := download(ctx, opts, key, tempDir)
+	if err != nil {
+		return result, err
+	}
+
+	extractDir := filepath.Join(tempDir, "extract")
+	if err := utils.ExtractArchive(archiveFile, extractDir); err != nil {
+		return result, fmt.Errorf("extract %s failed: %w", key, err)
+	}
+
+	result.Files, err = countFiles(extractDir)
+	if err != nil {
+		return result, err
+	}
+	opts.Logger.Info("archive entries verified", "key", key, "files", result.Files)
+
+	result.DumpChecked, err = exporter.Verify(ctx, opts.TaskID, opts.Conf, extractDir, opts.Logger)
+	if err != nil {
+		return result, fmt.Errorf("verify backup data failed: %w", err)
+	}
+
+	return result, nil
+}
+
+// Snapshot 校验增量快照。quick 模式确认清单引用的数据包都存在；full 模式把快照完整还原到临时目录。
+func Snapshot(ctx context.Context, opts Options, key string) (Result, error) {
+	result := Result{Key: key, Mode: opts.Conf.Verify.GetMode()}
+
+	tempDir, err := os.MkdirTemp("", "backupgo-verify-")
+	if err != nil {
+		return result, fmt.Errorf("create temp dir failed: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	manifestFile, err := download(ctx, opts, key, tempDir)
+	if err != nil {
+		return result, err
+	}
+	manifest, err := snapshot.ReadManifest(manifestFile)
+	if err != nil {
+		return result, err
+	}
+
+	if result.Mode == config.VerifyModeQuick {
+		return result, checkPacks(ctx, opts.Storage, manifest)
+	}
+
+	targetDir := filepath.Join(tempDir, "restore")
+	if err := snapshot.Restore(ctx, opts.Storage, manifest, targetDir, opts.Conf.Encryption, opts.Logger); err != nil {
+		return result, fmt.Errorf("restore snapshot failed: %w", err)
+	}
+
+	for _, file := range manifest.Files {
+		info, err := os.Stat(filepath.Join(targetDir, filepath.FromSlash(file.Path)))
+		if err != nil {
+			return result, fmt.Errorf("stat restored file failed: %w", err)
+		}
+		if info.Size() != file.Size {
+			return result, fmt.Errorf("restored file %s size mismatch: expected %d, got %d", file.Path, file.Size, info.Size())
+		}
+	}
+	result.Files = len(manifest.Files)
+
+	return result, nil
+}
+
+// download 把对象下载到 tempDir，加密的对象解密后返回明文文件路径。
+func download(ctx context.Context, opts Options, key string, tempDir string) (string, error) {
+	localFile := filepath.Join(tempDir, filepath.Base(key))
+	if err := opts.Storage.Download(ctx, key, localFile); err != nil {
+		return "", fmt.Errorf("download %s failed: %w", key, err)
+	}
+
+	if !encrypt.IsEncrypted(localFile) {
+		return localFile, nil
+	}
+	if opts.Conf.Encryption == nil {
+		return "", fmt.Errorf("backup %s is encrypted but task %s has no encryption config", key, opts.TaskID)
+	}
+
+	decryptedFile := encrypt.TrimExtension(localFile)
+	if err := encrypt.DecryptFile(*opts.Conf.Encryption, localFile, decryptedFile); err != nil {
+		return "", fmt.Errorf("decrypt %s failed: %w", key, err)
+	}
+	return decryptedFile, nil
+}
+
+func checkObject(ctx context.Context, storage oss.Storage, key string, size int64) error {
+	objects, err := storage.ListObjects(ctx)
+	if err != nil {
+		return fmt.Errorf("list objects failed: %w", err)
+	}
+
+	for _, obj := range objects {
+		if obj.Key != key {
+			continue
+		}
+		if size > 0 && obj.Size != size {
+			return fmt.Errorf("object %s size mismatch: expected %d, got %d", key, size, obj.Size)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("object %s not found", key)
+}
+
+func checkPacks(ctx context.Context, storage oss.Storage, manifest *snapshot.Manifest) error {
+	objects, err := storage.ListObjects(ctx)
+	if err != nil {
+		return fmt.Errorf("list objects failed: %w", err)
+	}
+
+	existing := make(map[string]bool, len(objects))
+	for _, obj := range objects {
+		existing[obj.Key] = true
+	}
+
+	var missing []string
+	seen := make(map[string]bool)
+	for _, packKey := range manifest.Blobs {
+		if !existing[packKey] && !seen[packKey] {
+			seen[packKey] = true
+			missing = append(missing, packKey)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("snapshot packs not found: %v", missing)
+	}
+
+	return nil
+}
+
+func countFiles(dir string) (int, error) {
+	count := 0
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("walk extracted files failed: %w", err)
+	}
+	return count, nil
+}
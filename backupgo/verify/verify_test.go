@@ -0,0 +1,149 @@
+package verify
+
+import (
+	"backupgo/config"
+	"backupgo/oss"
+	"backupgo/snapshot"
+	"backupgo/utils"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestOptions(t *testing.T, mode string) Options {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	storage, err := oss.NewLocalStorage(config.LocalStorageConfig{Path: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewLocalStorage returned error: %v", err)
+	}
+
+	return Options{
+		TaskID: "photos",
+		Conf: config.BackupConfig{
+			ID:         "photos",
+			BackupPath: "/data/photos",
+			Verify:     &config.VerifyConfig{Enabled: true, Mode: mode},
+		},
+		Storage: storage,
+		Logger:  slog.Default(),
+	}
+}
+
+func uploadTestArchive(t *testing.T, storage oss.Storage) (string, int64) {
+	t.Helper()
+
+	source := filepath.Join(t.TempDir(), "photos")
+	if err := os.MkdirAll(filepath.Join(source, "nested"), 0755); err != nil {
+		t.Fatalf("create source: %v", err)
+	}
+	for name, content := range map[string]string{"a.jpg": "photo a", "nested/b.jpg": "photo b"} {
+		if err := os.WriteFile(filepath.Join(source, filepath.FromSlash(name)), []byte(content), 0644); err != nil {
+			t.Fatalf("write source: %v", err)
+		}
+	}
+
+	zipFile, err := utils.ArchivePath(context.Background(), []utils.SourceDir{utils.NewSourceDir(source, nil)}, filepath.Join(t.TempDir(), "photos_2024_01_02.zip"), utils.ArchiveOptions{}, func(string, int64, int64, float64) {}, nil)
+	if err != nil {
+		t.Fatalf("ArchivePath returned error: %v", err)
+	}
+	info, err := os.Stat(zipFile)
+	if err != nil {
+		t.Fatalf("stat zip: %v", err)
+	}
+
+	key := filepath.Base(zipFile)
+	if _, err := storage.Upload(context.Background(), key, zipFile); err != nil {
+		t.Fatalf("Upload returned error: %v", err)
+	}
+	return key, info.Size()
+}
+
+func TestArchiveFull(t *testing.T) {
+	opts := newTestOptions(t, config.VerifyModeFull)
+	key, size := uploadTestArchive(t, opts.Storage)
+
+	result, err := Archive(context.Background(), opts, key, size)
+	if err != nil {
+		t.Fatalf("Archive returned error: %v", err)
+	}
+	if result.Files != 2 || result.DumpChecked {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
+
+func TestArchiveFullRejectsCorruptedObject(t *testing.T) {
+	opts := newTestOptions(t, config.VerifyModeFull)
+	key, size := uploadTestArchive(t, opts.Storage)
+
+	objectFile := filepath.Join(opts.Storage.BucketName(), key)
+	if err := os.WriteFile(objectFile, []byte(strings.Repeat("x", int(size))), 0644); err != nil {
+		t.Fatalf("corrupt object: %v", err)
+	}
+
+	if _, err := Archive(context.Background(), opts, key, size); err == nil {
+		t.Fatal("expected Archive to fail for a corrupted object")
+	}
+}
+
+func TestArchiveQuickChecksSize(t *testing.T) {
+	opts := newTestOptions(t, config.VerifyModeQuick)
+	key, size := uploadTestArchive(t, opts.Storage)
+
+	if _, err := Archive(context.Background(), opts, key, size); err != nil {
+		t.Fatalf("Archive returned error: %v", err)
+	}
+	if _, err := Archive(context.Background(), opts, key, size+1); err == nil || !strings.Contains(err.Error(), "size mismatch") {
+		t.Fatalf("expected size mismatch error, got %v", err)
+	}
+	if _, err := Archive(context.Background(), opts, "missing.zip", 0); err == nil {
+		t.Fatal("expected Archive to fail for a missing object")
+	}
+}
+
+func TestSnapshotQuickDetectsMissingPack(t *testing.T) {
+	opts := newTestOptions(t, config.VerifyModeQuick)
+	source := filepath.Join(t.TempDir(), "photos")
+	if err := os.MkdirAll(source, 0755); err != nil {
+		t.Fatalf("create source: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(source, "a.jpg"), []byte("photo a"), 0644); err != nil {
+		t.Fatalf("write source: %v", err)
+	}
+
+	created, err := snapshot.Create(context.Background(), snapshot.Options{TaskID: "photos", Source: source, Storage: opts.Storage, PackSize: 1 << 20, Logger: slog.Default()})
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+
+	if _, err := Snapshot(context.Background(), opts, created.ManifestKey); err != nil {
+		t.Fatalf("Snapshot returned error: %v", err)
+	}
+
+	full := opts
+	full.Conf.Verify = &config.VerifyConfig{Enabled: true}
+	result, err := Snapshot(context.Background(), full, created.ManifestKey)
+	if err != nil || result.Files != 1 {
+		t.Fatalf("unexpected full verify result %+v, err: %v", result, err)
+	}
+
+	objects, err := opts.Storage.ListObjects(context.Background())
+	if err != nil {
+		t.Fatalf("ListObjects returned error: %v", err)
+	}
+	for _, obj := range objects {
+		if strings.Contains(obj.Key, "/packs/") {
+			if _, err := opts.Storage.DeleteObjects(context.Background(), []string{obj.Key}); err != nil {
+				t.Fatalf("delete pack: %v", err)
+			}
+		}
+	}
+
+	if _, err := Snapshot(context.Background(), opts, created.ManifestKey); err == nil || !strings.Contains(err.Error(), "packs not found") {
+		t.Fatalf("expected missing pack error, got %v", err)
+	}
+}